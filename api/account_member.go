@@ -0,0 +1,429 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	errNotAccountMember  = errors.New("account doesn't belong to the authenticated user")
+	errMemberNotAllowed  = errors.New("your role on the account does not allow this")
+	errNoPendingInvite   = errors.New("no pending invitation to this account")
+	errSpendLimitNotUsed = errors.New("only spenders have a spend limit")
+)
+
+// authorizeAccount returns the membership of the authenticated user in an account.
+// Users who are not members, or have not accepted their invitation yet, get 401.
+func (server *Server) authorizeAccount(ctx *gin.Context, accountID int64) (db.AccountMember, bool) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	member, err := server.store.GetAccountMember(ctx, db.GetAccountMemberParams{
+		AccountID: accountID,
+		Username:  authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errNotAccountMember))
+			return member, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return member, false
+	}
+
+	if !member.Active() {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errNotAccountMember))
+		return member, false
+	}
+	return member, true
+}
+
+// getMemberAccount loads an account and the membership of the authenticated user in it
+func (server *Server) getMemberAccount(ctx *gin.Context, accountID int64) (db.Account, db.AccountMember, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, db.AccountMember{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, db.AccountMember{}, false
+	}
+
+	member, ok := server.authorizeAccount(ctx, accountID)
+	return account, member, ok
+}
+
+type accountMemberResponse struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	// SpendLimit is the most a spender may transfer out per calendar month in UTC
+	SpendLimit *util.Money         `json:"spend_limit,omitempty"`
+	InvitedBy  string              `json:"invited_by"`
+	AcceptedAt *pgtype.Timestamptz `json:"accepted_at,omitempty"`
+	CreatedAt  pgtype.Timestamptz  `json:"created_at"`
+}
+
+func newAccountMemberResponse(member db.AccountMember, currency string) accountMemberResponse {
+	rsp := accountMemberResponse{
+		AccountID: member.AccountID,
+		Username:  member.Username,
+		Role:      member.Role,
+		InvitedBy: member.InvitedBy,
+		CreatedAt: member.CreatedAt,
+	}
+	if member.Role == db.MemberSpender {
+		spendLimit := util.NewMoney(member.SpendLimit, currency)
+		rsp.SpendLimit = &spendLimit
+	}
+	if member.Active() {
+		rsp.AcceptedAt = &member.AcceptedAt
+	}
+	return rsp
+}
+
+// parseSpendLimit reads the spend limit of a member, a decimal amount only spenders have
+func parseSpendLimit(role string, value string, currency string) (int64, error) {
+	if role != db.MemberSpender {
+		if value != "" {
+			return 0, errSpendLimitNotUsed
+		}
+		return 0, nil
+	}
+
+	spendLimit, err := util.ParseMoney(value, currency)
+	if err != nil {
+		return 0, fmt.Errorf("spend limit: %w", err)
+	}
+	if spendLimit.Amount < 0 {
+		return 0, errors.New("spend limit must not be negative")
+	}
+	return spendLimit.Amount, nil
+}
+
+type accountMembersURIRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// listAccountMembers returns every member of an account, invited or active.
+// Any active member can see who else has access.
+func (server *Server) listAccountMembers(ctx *gin.Context) {
+	var req accountMembersURIRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, _, ok := server.getMemberAccount(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	members, err := server.store.ListAccountMembers(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]accountMemberResponse, len(members))
+	for i, member := range members {
+		rsp[i] = newAccountMemberResponse(member, account.Currency)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type inviteAccountMemberRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+	Role     string `json:"role" binding:"required,oneof=owner co_owner viewer spender"`
+	// SpendLimit is a decimal amount in the currency of the account, required for spenders
+	SpendLimit string `json:"spend_limit"`
+}
+
+// inviteAccountMember invites a user to an account. The user gets no access
+// until they accept. Owners can invite any role, co-owners viewers and spenders.
+func (server *Server) inviteAccountMember(ctx *gin.Context) {
+	var uri accountMembersURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req inviteAccountMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, caller, ok := server.getMemberAccount(ctx, uri.ID)
+	if !ok {
+		return
+	}
+	if !caller.CanManageRole(req.Role) {
+		ctx.JSON(http.StatusForbidden, errorResponse(errMemberNotAllowed))
+		return
+	}
+
+	spendLimit, err := parseSpendLimit(req.Role, req.SpendLimit, account.Currency)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	member, err := server.store.CreateAccountMember(ctx, db.CreateAccountMemberParams{
+		AccountID:  uri.ID,
+		Username:   req.Username,
+		Role:       req.Role,
+		SpendLimit: spendLimit,
+		InvitedBy:  caller.Username,
+	})
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok {
+			switch pgErr.Code {
+			case "23505": // unique_violation
+				ctx.JSON(http.StatusConflict, errorResponse(errors.New("user is already a member or invited")))
+				return
+			case "23503": // foreign_key_violation, the invited user does not exist
+				ctx.JSON(http.StatusNotFound, errorResponse(errors.New("user not found")))
+				return
+			}
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newAccountMemberResponse(member, account.Currency))
+}
+
+type accountMemberURIRequest struct {
+	ID       int64  `uri:"id" binding:"required,min=1"`
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type updateAccountMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner co_owner viewer spender"`
+	// SpendLimit is a decimal amount in the currency of the account, required for spenders
+	SpendLimit string `json:"spend_limit"`
+}
+
+// updateAccountMember changes the role or spend limit of a member. The caller must be
+// allowed to manage both the current and the new role, and an account keeps one owner.
+func (server *Server) updateAccountMember(ctx *gin.Context) {
+	var uri accountMemberURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updateAccountMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, caller, ok := server.getMemberAccount(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	target := caller
+	if uri.Username != caller.Username {
+		target, ok = server.getAccountMember(ctx, uri.ID, uri.Username)
+		if !ok {
+			return
+		}
+	}
+	if !caller.CanManageRole(target.Role) || !caller.CanManageRole(req.Role) {
+		ctx.JSON(http.StatusForbidden, errorResponse(errMemberNotAllowed))
+		return
+	}
+
+	spendLimit, err := parseSpendLimit(req.Role, req.SpendLimit, account.Currency)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	member, err := server.store.UpdateAccountMemberTx(ctx, db.UpdateAccountMemberRoleParams{
+		AccountID:  uri.ID,
+		Username:   uri.Username,
+		Role:       req.Role,
+		SpendLimit: spendLimit,
+	})
+	if err != nil {
+		server.respondMemberTxError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountMemberResponse(member, account.Currency))
+}
+
+// removeAccountMember takes a member off an account or withdraws an invitation.
+// Members can always remove themselves, unless they are the last owner.
+func (server *Server) removeAccountMember(ctx *gin.Context) {
+	var uri accountMemberURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	_, caller, ok := server.getMemberAccount(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	if uri.Username != caller.Username {
+		target, ok := server.getAccountMember(ctx, uri.ID, uri.Username)
+		if !ok {
+			return
+		}
+		if !caller.CanManageRole(target.Role) {
+			ctx.JSON(http.StatusForbidden, errorResponse(errMemberNotAllowed))
+			return
+		}
+	}
+
+	err := server.store.RemoveAccountMemberTx(ctx, db.DeleteAccountMemberParams{
+		AccountID: uri.ID,
+		Username:  uri.Username,
+	})
+	if err != nil {
+		server.respondMemberTxError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// getAccountMember loads the member a request acts on, answering 404 when there is none
+func (server *Server) getAccountMember(ctx *gin.Context, accountID int64, username string) (db.AccountMember, bool) {
+	member, err := server.store.GetAccountMember(ctx, db.GetAccountMemberParams{
+		AccountID: accountID,
+		Username:  username,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errors.New("member not found")))
+			return member, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return member, false
+	}
+	return member, true
+}
+
+func (server *Server) respondMemberTxError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, db.ErrLastOwner):
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	case errors.Is(err, pgx.ErrNoRows):
+		ctx.JSON(http.StatusNotFound, errorResponse(errors.New("member not found")))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
+
+type invitationResponse struct {
+	accountMemberResponse
+	Currency string `json:"currency"`
+}
+
+// listInvitations returns the invitations the authenticated user has not answered yet.
+func (server *Server) listInvitations(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	invitations, err := server.store.ListPendingInvitations(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]invitationResponse, len(invitations))
+	for i, invitation := range invitations {
+		// The currency of the account tells how to read the spend limit
+		account, err := server.store.GetAccount(ctx, invitation.AccountID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		rsp[i] = invitationResponse{
+			accountMemberResponse: newAccountMemberResponse(invitation, account.Currency),
+			Currency:              account.Currency,
+		}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type invitationRequest struct {
+	AccountID int64 `uri:"account_id" binding:"required,min=1"`
+}
+
+// acceptInvitation makes the authenticated user an active member of the account they were invited to.
+func (server *Server) acceptInvitation(ctx *gin.Context) {
+	var req invitationRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	member, err := server.store.AcceptAccountInvitation(ctx, db.AcceptAccountInvitationParams{
+		AccountID: req.AccountID,
+		Username:  authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errNoPendingInvite))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, req.AccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountMemberResponse(member, account.Currency))
+}
+
+// declineInvitation drops an invitation of the authenticated user. Accepted
+// memberships are left with DELETE /accounts/:id/members/:username instead.
+func (server *Server) declineInvitation(ctx *gin.Context) {
+	var req invitationRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.GetAccountMemberParams{
+		AccountID: req.AccountID,
+		Username:  authPayload.Username,
+	}
+	member, err := server.store.GetAccountMember(ctx, arg)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if err != nil || member.Active() {
+		ctx.JSON(http.StatusNotFound, errorResponse(errNoPendingInvite))
+		return
+	}
+
+	err = server.store.DeleteAccountMember(ctx, db.DeleteAccountMemberParams(arg))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,413 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// randomAccountMember returns a member who accepted their invitation
+func randomAccountMember(account db.Account, username string, role string) db.AccountMember {
+	return db.AccountMember{
+		AccountID:  account.ID,
+		Username:   username,
+		Role:       role,
+		InvitedBy:  account.Owner,
+		AcceptedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+}
+
+func memberParams(member db.AccountMember) db.GetAccountMemberParams {
+	return db.GetAccountMemberParams{AccountID: member.AccountID, Username: member.Username}
+}
+
+func TestInviteAccountMemberAPI(t *testing.T) {
+	owner := util.RandomOwner()
+	account := randomAccount(owner)
+	invitee := util.RandomOwner()
+
+	testCases := []struct {
+		name          string
+		role          string
+		body          map[string]any
+		buildStubs    func(store *mockdb.MockStore, caller db.AccountMember)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: db.MemberOwner,
+			body: map[string]any{"username": invitee, "role": db.MemberSpender, "spend_limit": "25.50"},
+			buildStubs: func(store *mockdb.MockStore, caller db.AccountMember) {
+				arg := db.CreateAccountMemberParams{
+					AccountID:  account.ID,
+					Username:   invitee,
+					Role:       db.MemberSpender,
+					SpendLimit: 2550,
+					InvitedBy:  owner,
+				}
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.AccountMember{AccountID: account.ID, Username: invitee, Role: db.MemberSpender, SpendLimit: 2550, InvitedBy: owner}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var member accountMemberResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &member))
+				require.Equal(t, invitee, member.Username)
+				require.Equal(t, util.NewMoney(2550, util.USD), *member.SpendLimit)
+				require.Nil(t, member.AcceptedAt)
+			},
+		},
+		{
+			name: "CoOwnerInvitesOwner",
+			role: db.MemberCoOwner,
+			body: map[string]any{"username": invitee, "role": db.MemberOwner},
+			buildStubs: func(store *mockdb.MockStore, caller db.AccountMember) {
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "ViewerInvites",
+			role: db.MemberViewer,
+			body: map[string]any{"username": invitee, "role": db.MemberViewer},
+			buildStubs: func(store *mockdb.MockStore, caller db.AccountMember) {
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "SpendLimitOfViewer",
+			role: db.MemberOwner,
+			body: map[string]any{"username": invitee, "role": db.MemberViewer, "spend_limit": "10"},
+			buildStubs: func(store *mockdb.MockStore, caller db.AccountMember) {
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyMember",
+			role: db.MemberOwner,
+			body: map[string]any{"username": invitee, "role": db.MemberViewer},
+			buildStubs: func(store *mockdb.MockStore, caller db.AccountMember) {
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.AccountMember{}, &pgconn.PgError{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownUser",
+			role: db.MemberOwner,
+			body: map[string]any{"username": invitee, "role": db.MemberViewer},
+			buildStubs: func(store *mockdb.MockStore, caller db.AccountMember) {
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.AccountMember{}, &pgconn.PgError{Code: "23503"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidRole",
+			role: db.MemberOwner,
+			body: map[string]any{"username": invitee, "role": "admin"},
+			buildStubs: func(store *mockdb.MockStore, caller db.AccountMember) {
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			caller := randomAccountMember(account, owner, tc.role)
+			if tc.name != "InvalidRole" {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(memberParams(caller))).Times(1).Return(caller, nil)
+			}
+			tc.buildStubs(store, caller)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request := newJSONRequest(t, http.MethodPost, fmt.Sprintf("/accounts/%d/members", account.ID), tc.body)
+			addAuthorization(t, request, store, server.tokenMaker, owner, util.DepositorRole)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUpdateAccountMemberAPI(t *testing.T) {
+	owner := util.RandomOwner()
+	account := randomAccount(owner)
+	ownerMember := randomAccountMember(account, owner, db.MemberOwner)
+	spender := randomAccountMember(account, util.RandomOwner(), db.MemberSpender)
+
+	testCases := []struct {
+		name          string
+		caller        db.AccountMember
+		target        db.AccountMember
+		body          map[string]any
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			caller: ownerMember,
+			target: spender,
+			body:   map[string]any{"role": db.MemberSpender, "spend_limit": "100"},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateAccountMemberRoleParams{
+					AccountID:  account.ID,
+					Username:   spender.Username,
+					Role:       db.MemberSpender,
+					SpendLimit: 10000,
+				}
+				updated := spender
+				updated.SpendLimit = 10000
+				store.EXPECT().UpdateAccountMemberTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updated, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "LastOwner",
+			caller: ownerMember,
+			target: ownerMember,
+			body:   map[string]any{"role": db.MemberViewer},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountMemberTx(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, db.ErrLastOwner)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:   "CoOwnerPromotesToCoOwner",
+			caller: randomAccountMember(account, util.RandomOwner(), db.MemberCoOwner),
+			target: spender,
+			body:   map[string]any{"role": db.MemberCoOwner},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountMemberTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(memberParams(tc.caller))).Times(1).Return(tc.caller, nil)
+			if tc.target.Username != tc.caller.Username {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(memberParams(tc.target))).Times(1).Return(tc.target, nil)
+			}
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/members/%s", account.ID, tc.target.Username)
+			request := newJSONRequest(t, http.MethodPatch, url, tc.body)
+			addAuthorization(t, request, store, server.tokenMaker, tc.caller.Username, util.DepositorRole)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRemoveAccountMemberAPI(t *testing.T) {
+	owner := util.RandomOwner()
+	account := randomAccount(owner)
+	ownerMember := randomAccountMember(account, owner, db.MemberOwner)
+	viewer := randomAccountMember(account, util.RandomOwner(), db.MemberViewer)
+
+	testCases := []struct {
+		name          string
+		caller        db.AccountMember
+		target        db.AccountMember
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OwnerRemovesViewer",
+			caller: ownerMember,
+			target: viewer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(memberParams(viewer))).Times(1).Return(viewer, nil)
+				arg := db.DeleteAccountMemberParams{AccountID: account.ID, Username: viewer.Username}
+				store.EXPECT().RemoveAccountMemberTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:   "ViewerLeaves",
+			caller: viewer,
+			target: viewer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RemoveAccountMemberTx(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:   "ViewerRemovesOwner",
+			caller: viewer,
+			target: ownerMember,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(memberParams(ownerMember))).Times(1).Return(ownerMember, nil)
+				store.EXPECT().RemoveAccountMemberTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "LastOwnerLeaves",
+			caller: ownerMember,
+			target: ownerMember,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RemoveAccountMemberTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ErrLastOwner)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(memberParams(tc.caller))).Times(1).Return(tc.caller, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/members/%s", account.ID, tc.target.Username)
+			request := newJSONRequest(t, http.MethodDelete, url, nil)
+			addAuthorization(t, request, store, server.tokenMaker, tc.caller.Username, util.DepositorRole)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestInvitationsAPI(t *testing.T) {
+	account := randomAccount(util.RandomOwner())
+	invitee := util.RandomOwner()
+	invitation := db.AccountMember{
+		AccountID:  account.ID,
+		Username:   invitee,
+		Role:       db.MemberSpender,
+		SpendLimit: 500,
+		InvitedBy:  account.Owner,
+	}
+	arg := db.AcceptAccountInvitationParams{AccountID: account.ID, Username: invitee}
+
+	t.Run("List", func(t *testing.T) {
+		store := mockdb.NewMockStore(gomock.NewController(t))
+		store.EXPECT().ListPendingInvitations(gomock.Any(), gomock.Eq(invitee)).Times(1).Return([]db.AccountMember{invitation}, nil)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+		server := newTestServer(t, store)
+		recorder := httptest.NewRecorder()
+		request := newJSONRequest(t, http.MethodGet, "/invitations", nil)
+		addAuthorization(t, request, store, server.tokenMaker, invitee, util.DepositorRole)
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var invitations []invitationResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &invitations))
+		require.Len(t, invitations, 1)
+		require.Equal(t, account.ID, invitations[0].AccountID)
+		require.Equal(t, util.USD, invitations[0].Currency)
+	})
+
+	t.Run("Accept", func(t *testing.T) {
+		accepted := randomAccountMember(account, invitee, db.MemberSpender)
+		store := mockdb.NewMockStore(gomock.NewController(t))
+		store.EXPECT().AcceptAccountInvitation(gomock.Any(), gomock.Eq(arg)).Times(1).Return(accepted, nil)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+		server := newTestServer(t, store)
+		recorder := httptest.NewRecorder()
+		request := newJSONRequest(t, http.MethodPost, fmt.Sprintf("/invitations/%d/accept", account.ID), nil)
+		addAuthorization(t, request, store, server.tokenMaker, invitee, util.DepositorRole)
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("AcceptWithoutInvitation", func(t *testing.T) {
+		store := mockdb.NewMockStore(gomock.NewController(t))
+		store.EXPECT().AcceptAccountInvitation(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.AccountMember{}, pgx.ErrNoRows)
+
+		server := newTestServer(t, store)
+		recorder := httptest.NewRecorder()
+		request := newJSONRequest(t, http.MethodPost, fmt.Sprintf("/invitations/%d/accept", account.ID), nil)
+		addAuthorization(t, request, store, server.tokenMaker, invitee, util.DepositorRole)
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("Decline", func(t *testing.T) {
+		store := mockdb.NewMockStore(gomock.NewController(t))
+		store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(memberParams(invitation))).Times(1).Return(invitation, nil)
+		store.EXPECT().DeleteAccountMember(gomock.Any(), gomock.Eq(db.DeleteAccountMemberParams(arg))).Times(1).Return(nil)
+
+		server := newTestServer(t, store)
+		recorder := httptest.NewRecorder()
+		request := newJSONRequest(t, http.MethodPost, fmt.Sprintf("/invitations/%d/decline", account.ID), nil)
+		addAuthorization(t, request, store, server.tokenMaker, invitee, util.DepositorRole)
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("DeclineAcceptedMembership", func(t *testing.T) {
+		store := mockdb.NewMockStore(gomock.NewController(t))
+		accepted := randomAccountMember(account, invitee, db.MemberSpender)
+		store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(accepted, nil)
+		store.EXPECT().DeleteAccountMember(gomock.Any(), gomock.Any()).Times(0)
+
+		server := newTestServer(t, store)
+		recorder := httptest.NewRecorder()
+		request := newJSONRequest(t, http.MethodPost, fmt.Sprintf("/invitations/%d/decline", account.ID), nil)
+		addAuthorization(t, request, store, server.tokenMaker, invitee, util.DepositorRole)
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
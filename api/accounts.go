@@ -8,15 +8,40 @@ import (
 
 	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
 	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/Aadityaa2606/Bank-API/util"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type accountResponse struct {
+	ID        int64              `json:"id"`
+	Owner     string             `json:"owner"`
+	Currency  string             `json:"currency"`
+	Product   string             `json:"product"`
+	Balance   util.Money         `json:"balance"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+func newAccountResponse(account db.Account) accountResponse {
+	return accountResponse{
+		ID:        account.ID,
+		Owner:     account.Owner,
+		Currency:  account.Currency,
+		Product:   account.Product,
+		Balance:   util.NewMoney(account.Balance, account.Currency),
+		CreatedAt: account.CreatedAt,
+	}
+}
+
 type createAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"`
+	// Product defaults to checking
+	Product string `json:"product" binding:"omitempty,alphanum,lowercase"`
 }
 
 // createAccount creates a new bank account for the authenticated user.
 // It requires a valid currency in the request body and sets the initial balance to 0.
+// The account must be allowed by the rules of its product.
 func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -26,18 +51,29 @@ func (server *Server) createAccount(ctx *gin.Context) {
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	arg := db.CreateAccountParams{
+	arg := db.CreateAccountTxParams{
 		Owner:    authPayload.Username,
 		Currency: req.Currency,
-		Balance:  0,
+		Product:  req.Product,
+	}
+	if arg.Product == "" {
+		arg.Product = db.DefaultAccountProduct
 	}
-	account, err := server.store.CreateAccount(ctx, arg)
+
+	account, err := server.store.CreateAccountTx(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		switch {
+		case errors.Is(err, db.ErrUnknownProduct), errors.Is(err, db.ErrProductDisabled):
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		case errors.Is(err, db.ErrCurrencyNotAllowed), errors.Is(err, db.ErrTooManyAccounts):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, account)
+	ctx.JSON(http.StatusCreated, newAccountResponse(account))
 }
 
 type getAccountByIdRequest struct {
@@ -45,7 +81,7 @@ type getAccountByIdRequest struct {
 }
 
 // getAccountById retrieves a specific account by its ID.
-// Only returns the account if it belongs to the authenticated user.
+// Only returns the account to its active members.
 func (server *Server) getAccountById(ctx *gin.Context) {
 	var req getAccountByIdRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
@@ -53,22 +89,12 @@ func (server *Server) getAccountById(ctx *gin.Context) {
 		return
 	}
 
-	account, err := server.store.GetAccount(ctx, req.ID)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
-	}
-
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
-		ctx.JSON(
-			http.StatusUnauthorized,
-			errorResponse(errors.New("account doesn't belong to the authenticated user")),
-		)
+	account, _, ok := server.getMemberAccount(ctx, req.ID)
+	if !ok {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 }
 
 type getAccountsRequest struct {
@@ -76,7 +102,7 @@ type getAccountsRequest struct {
 	Offset int32 `form:"offset"`
 }
 
-// getAccounts returns a paginated list of accounts belonging to the authenticated user.
+// getAccounts returns a paginated list of the accounts the authenticated user is an active member of.
 func (server *Server) getAccounts(ctx *gin.Context) {
 	var req getAccountsRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
@@ -85,17 +111,22 @@ func (server *Server) getAccounts(ctx *gin.Context) {
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	accounts, err := server.store.ListAccounts(ctx, db.ListAccountsParams{
-		Owner:  authPayload.Username,
-		Limit:  req.Limit,
-		Offset: req.Offset,
+	accounts, err := server.store.ListMemberAccounts(ctx, db.ListMemberAccountsParams{
+		Username: authPayload.Username,
+		Limit:    req.Limit,
+		Offset:   req.Offset,
 	})
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, accounts)
+
+	rsp := make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		rsp[i] = newAccountResponse(account)
+	}
+	ctx.JSON(http.StatusOK, rsp)
 }
 
 type updateAccountIDRequest struct {
@@ -103,11 +134,12 @@ type updateAccountIDRequest struct {
 }
 
 type updateAccountBalanceRequest struct {
-	Balance int64 `json:"balance" binding:"required"` // Bind balance from JSON body
+	// Balance is a decimal amount in the currency of the account, e.g. "12.34"
+	Balance string `json:"balance" binding:"required"` // Bind balance from JSON body
 }
 
 // updateAccount updates the balance of a specific account.
-// Only allows updates if the account belongs to the authenticated user.
+// Only the owners of the account may update it.
 func (server *Server) updateAccount(ctx *gin.Context) {
 	var req1 updateAccountIDRequest
 	var req2 updateAccountBalanceRequest
@@ -124,36 +156,32 @@ func (server *Server) updateAccount(ctx *gin.Context) {
 		return
 	}
 
-	// Check if the account belongs to the authenticated user
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-
-	isAccountOwner, err := server.store.CheckAccountOwnership(ctx, db.CheckAccountOwnershipParams{
-		ID:    req1.ID,
-		Owner: authPayload.Username,
-	})
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	// The account is loaded first, its currency decides how many decimals the balance may have
+	account, member, ok := server.getMemberAccount(ctx, req1.ID)
+	if !ok {
+		return
+	}
+	if !member.CanManageAccount() {
+		ctx.JSON(http.StatusForbidden, errorResponse(errMemberNotAllowed))
 		return
 	}
 
-	if !isAccountOwner {
-		ctx.JSON(
-			http.StatusUnauthorized,
-			errorResponse(errors.New("account doesn't belong to the authenticated user")),
-		)
+	balance, err := util.ParseMoney(req2.Balance, account.Currency)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	account, err := server.store.UpdateAccount(ctx, db.UpdateAccountParams{
+	account, err = server.store.UpdateAccount(ctx, db.UpdateAccountParams{
 		ID:      req1.ID,
-		Balance: req2.Balance,
+		Balance: balance.Amount,
 	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 }
 
 type deleteAccountRequest struct {
@@ -161,7 +189,7 @@ type deleteAccountRequest struct {
 }
 
 // deleteAccount removes an account from the system.
-// Only allows deletion if the account belongs to the authenticated user.
+// Only the owners of the account may delete it.
 func (server *Server) deleteAccount(ctx *gin.Context) {
 	var req deleteAccountRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
@@ -169,27 +197,16 @@ func (server *Server) deleteAccount(ctx *gin.Context) {
 		return
 	}
 
-	// Check if the account belongs to the authenticated user
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-
-	isAccountOwner, err := server.store.CheckAccountOwnership(ctx, db.CheckAccountOwnershipParams{
-		ID:    req.ID,
-		Owner: authPayload.Username,
-	})
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	member, ok := server.authorizeAccount(ctx, req.ID)
+	if !ok {
 		return
 	}
-
-	if !isAccountOwner {
-		ctx.JSON(
-			http.StatusUnauthorized,
-			errorResponse(errors.New("account doesn't belong to the authenticated user")),
-		)
+	if !member.CanManageAccount() {
+		ctx.JSON(http.StatusForbidden, errorResponse(errMemberNotAllowed))
 		return
 	}
 
-	err = server.store.DeleteAccount(ctx, req.ID)
+	err := server.store.DeleteAccount(ctx, req.ID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -0,0 +1,194 @@
+// This includes administrative operations that are only available to users with the admin role.
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type unlockUserRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// unlockUser lifts a login lockout and clears the consecutive failure count of a user.
+func (server *Server) unlockUser(ctx *gin.Context) {
+	var req unlockUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.ResetUserFailedLogins(ctx, req.Username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// listAllCurrencies returns every currency, including the disabled ones.
+func (server *Server) listAllCurrencies(ctx *gin.Context) {
+	currencies, err := server.currencies.List(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]currencyResponse, len(currencies))
+	for i, currency := range currencies {
+		rsp[i] = newCurrencyResponse(currency)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type currencyCodeRequest struct {
+	Code string `uri:"code" binding:"required,len=3,alpha,uppercase"`
+}
+
+// enableCurrency lets new accounts and transfers use a currency.
+func (server *Server) enableCurrency(ctx *gin.Context) {
+	server.setCurrencyEnabled(ctx, true)
+}
+
+// disableCurrency stops new accounts and transfers in a currency.
+// Existing accounts keep their balance and can still be read.
+func (server *Server) disableCurrency(ctx *gin.Context) {
+	server.setCurrencyEnabled(ctx, false)
+}
+
+func (server *Server) setCurrencyEnabled(ctx *gin.Context, enabled bool) {
+	var req currencyCodeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	currency, err := server.store.SetCurrencyEnabled(ctx, db.SetCurrencyEnabledParams{
+		Code:    req.Code,
+		Enabled: enabled,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	server.currencies.Forget()
+
+	ctx.JSON(http.StatusOK, newCurrencyResponse(currency))
+}
+
+// listAllProducts returns every account product, including the disabled ones.
+func (server *Server) listAllProducts(ctx *gin.Context) {
+	products, err := server.store.ListAccountProducts(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]productResponse, len(products))
+	for i, product := range products {
+		rsp[i] = newProductResponse(product)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// createProductRequest takes amounts in minor units, 0 leaves a limit off
+type createProductRequest struct {
+	Code                       string   `json:"code" binding:"required,alphanum,lowercase,max=32"`
+	Name                       string   `json:"name" binding:"required"`
+	AllowedCurrencies          []string `json:"allowed_currencies" binding:"dive,currency"`
+	MaxAccountsPerUser         int32    `json:"max_accounts_per_user" binding:"min=0"`
+	MinBalance                 int64    `json:"min_balance" binding:"min=0"`
+	MaxMonthlyWithdrawals      int32    `json:"max_monthly_withdrawals" binding:"min=0"`
+	MaxMonthlyWithdrawalAmount int64    `json:"max_monthly_withdrawal_amount" binding:"min=0"`
+	InterestRateBps            int32    `json:"interest_rate_bps" binding:"min=0"`
+	MonthlyFee                 int64    `json:"monthly_fee" binding:"min=0"`
+}
+
+// createProduct adds an account product. Interest and fees are stored with it
+// for the jobs that will apply them, transfers only enforce the limits.
+func (server *Server) createProduct(ctx *gin.Context) {
+	var req createProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	allowedCurrencies := req.AllowedCurrencies
+	if allowedCurrencies == nil {
+		allowedCurrencies = []string{}
+	}
+
+	product, err := server.store.CreateAccountProduct(ctx, db.CreateAccountProductParams{
+		Code:                       req.Code,
+		Name:                       req.Name,
+		AllowedCurrencies:          allowedCurrencies,
+		MaxAccountsPerUser:         req.MaxAccountsPerUser,
+		MinBalance:                 req.MinBalance,
+		MaxMonthlyWithdrawals:      req.MaxMonthlyWithdrawals,
+		MaxMonthlyWithdrawalAmount: req.MaxMonthlyWithdrawalAmount,
+		InterestRateBps:            req.InterestRateBps,
+		MonthlyFee:                 req.MonthlyFee,
+	})
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newProductResponse(product))
+}
+
+type productCodeRequest struct {
+	Code string `uri:"code" binding:"required,alphanum,lowercase"`
+}
+
+// enableProduct lets new accounts be opened with a product.
+func (server *Server) enableProduct(ctx *gin.Context) {
+	server.setProductEnabled(ctx, true)
+}
+
+// disableProduct stops new accounts of a product.
+// Existing accounts keep the product and its rules.
+func (server *Server) disableProduct(ctx *gin.Context) {
+	server.setProductEnabled(ctx, false)
+}
+
+func (server *Server) setProductEnabled(ctx *gin.Context, enabled bool) {
+	var req productCodeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	product, err := server.store.SetAccountProductEnabled(ctx, db.SetAccountProductEnabledParams{
+		Code:    req.Code,
+		Enabled: enabled,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newProductResponse(product))
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/metrics"
+	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/gin-gonic/gin"
+)
+
+type batchTransferLeg struct {
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64 `json:"to_account_id" binding:"required,min=1"`
+	// Amount is a decimal amount in the currency of the batch, e.g. "12.34"
+	Amount string `json:"amount" binding:"required"`
+}
+
+type createBatchTransferRequest struct {
+	Currency string `json:"currency" binding:"required,currency"`
+	// Mode is all_or_nothing or best_effort, see db.BatchTransferTx
+	Mode      string             `json:"mode" binding:"required,oneof=all_or_nothing best_effort"`
+	Transfers []batchTransferLeg `json:"transfers" binding:"required,dive"`
+	// TOTPCode is only needed when the batch total reaches the step-up threshold
+	TOTPCode string `json:"totp_code"`
+}
+
+type batchTransferLegResponse struct {
+	Index     int               `json:"index"`
+	Succeeded bool              `json:"succeeded"`
+	Transfer  *transferResponse `json:"transfer,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+type batchTransferResponse struct {
+	Batch     db.TransferBatch           `json:"batch"`
+	Transfers []batchTransferLegResponse `json:"transfers"`
+	// Accounts are the source accounts the batch took money out of, with their new balance
+	Accounts []accountResponse `json:"accounts"`
+}
+
+// createBatchTransfer makes many transfers from accounts of the authenticated user
+// at once, e.g. to pay salaries. The sum of the batch counts towards the step-up threshold.
+func (server *Server) createBatchTransfer(ctx *gin.Context) {
+	var req createBatchTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.BatchTransferTxParams{
+		Owner:    authPayload.Username,
+		Currency: req.Currency,
+		Mode:     req.Mode,
+		Legs:     make([]db.BatchTransferLeg, len(req.Transfers)),
+	}
+	var total int64
+	for i, leg := range req.Transfers {
+		amount, err := parseTransferAmount(leg.Amount, req.Currency)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("transfer %d: %w", i, err)))
+			return
+		}
+		arg.Legs[i] = db.BatchTransferLeg{
+			FromAccountID: leg.FromAccountID,
+			ToAccountID:   leg.ToAccountID,
+			Amount:        amount,
+		}
+		// Saturates instead of overflowing into a total below the threshold
+		total = min(total, math.MaxInt64-amount) + amount
+	}
+
+	if !server.requireTransferStepUp(ctx, authPayload.Username, total, req.TOTPCode) {
+		return
+	}
+
+	result, err := server.store.BatchTransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientBalance) {
+			metrics.InsufficientBalance()
+		}
+		ctx.JSON(batchTransferErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	rsp := batchTransferResponse{
+		Batch:     result.Batch,
+		Transfers: make([]batchTransferLegResponse, len(result.Legs)),
+		Accounts:  []accountResponse{},
+	}
+	// sources are the accounts the caller sent money from, which they may see
+	sources := make(map[int64]bool)
+	for i, leg := range result.Legs {
+		rsp.Transfers[i] = batchTransferLegResponse{Index: i, Succeeded: leg.Err == nil}
+		if leg.Err != nil {
+			if errors.Is(leg.Err, db.ErrInsufficientBalance) {
+				metrics.InsufficientBalance()
+			}
+			rsp.Transfers[i].Error = leg.Err.Error()
+			continue
+		}
+		transfer := newTransferResponse(leg.Transfer, req.Currency)
+		rsp.Transfers[i].Transfer = &transfer
+		metrics.TransferCompleted(req.Currency, leg.Transfer.Amount)
+		sources[leg.Transfer.FromAccountID] = true
+	}
+	// Recipients' balances are none of the caller's business
+	for _, account := range result.Accounts {
+		if sources[account.ID] {
+			rsp.Accounts = append(rsp.Accounts, newAccountResponse(account))
+		}
+	}
+
+	ctx.JSON(http.StatusCreated, rsp)
+}
+
+func batchTransferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, db.ErrInsufficientBalance),
+		errors.Is(err, db.ErrMinimumBalance),
+		errors.Is(err, db.ErrWithdrawalLimit):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, db.ErrTransferAccountMissing):
+		return http.StatusNotFound
+	case errors.Is(err, db.ErrAccountNotOwned):
+		return http.StatusUnauthorized
+	case errors.Is(err, db.ErrSpendLimit):
+		return http.StatusForbidden
+	case errors.Is(err, db.ErrEmptyBatch),
+		errors.Is(err, db.ErrBatchTooLarge),
+		errors.Is(err, db.ErrUnknownBatchMode),
+		errors.Is(err, db.ErrInvalidTransferAmount),
+		errors.Is(err, db.ErrSameAccountTransfer),
+		errors.Is(err, db.ErrCurrencyMismatch):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateBatchTransferAPI(t *testing.T) {
+	owner := util.RandomOwner()
+	fromAccount := randomAccount(owner)
+	toAccount := randomAccount(util.RandomOwner())
+	toAccount.ID = fromAccount.ID + 1
+
+	legs := []map[string]any{
+		{"from_account_id": fromAccount.ID, "to_account_id": toAccount.ID, "amount": "0.10"},
+		{"from_account_id": fromAccount.ID, "to_account_id": toAccount.ID, "amount": "0.20"},
+	}
+	arg := db.BatchTransferTxParams{
+		Owner:    owner,
+		Currency: util.USD,
+		Mode:     db.BatchBestEffort,
+		Legs: []db.BatchTransferLeg{
+			{FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: 10},
+			{FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: 20},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          map[string]any
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "BestEffort",
+			username: owner,
+			body:     map[string]any{"currency": util.USD, "mode": db.BatchBestEffort, "transfers": legs},
+			buildStubs: func(store *mockdb.MockStore) {
+				result := db.BatchTransferTxResult{
+					Batch: db.TransferBatch{ID: 1, Owner: owner, Mode: db.BatchBestEffort, Legs: 2, Succeeded: 1},
+					Legs: []db.BatchLegResult{
+						{Transfer: db.Transfer{ID: 1, FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: 10}},
+						{Err: db.ErrInsufficientBalance},
+					},
+					Accounts: []db.Account{fromAccount, toAccount},
+				}
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var rsp batchTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Transfers, 2)
+				require.True(t, rsp.Transfers[0].Succeeded)
+				require.Equal(t, util.NewMoney(10, util.USD), rsp.Transfers[0].Transfer.Amount)
+				require.False(t, rsp.Transfers[1].Succeeded)
+				require.Nil(t, rsp.Transfers[1].Transfer)
+				require.Equal(t, db.ErrInsufficientBalance.Error(), rsp.Transfers[1].Error)
+				// The recipient's account is left out
+				require.Equal(t, []accountResponse{newAccountResponse(fromAccount)}, rsp.Accounts)
+			},
+		},
+		{
+			name:     "AllOrNothingInsufficientBalance",
+			username: owner,
+			body:     map[string]any{"currency": util.USD, "mode": db.BatchAllOrNothing, "transfers": legs},
+			buildStubs: func(store *mockdb.MockStore) {
+				err := &db.BatchLegError{Index: 1, Err: db.ErrInsufficientBalance}
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.BatchTransferTxResult{}, err)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:     "AccountNotOwned",
+			username: owner,
+			body:     map[string]any{"currency": util.USD, "mode": db.BatchAllOrNothing, "transfers": legs},
+			buildStubs: func(store *mockdb.MockStore) {
+				err := &db.BatchLegError{Index: 0, Err: db.ErrAccountNotOwned}
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.BatchTransferTxResult{}, err)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "AccountMissing",
+			username: owner,
+			body:     map[string]any{"currency": util.USD, "mode": db.BatchAllOrNothing, "transfers": legs},
+			buildStubs: func(store *mockdb.MockStore) {
+				err := &db.BatchLegError{Index: 0, Err: db.ErrTransferAccountMissing}
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.BatchTransferTxResult{}, err)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidMode",
+			username: owner,
+			body:     map[string]any{"currency": util.USD, "mode": "some", "transfers": legs},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidLegAmount",
+			username: owner,
+			body: map[string]any{"currency": util.USD, "mode": db.BatchBestEffort, "transfers": []map[string]any{
+				{"from_account_id": fromAccount.ID, "to_account_id": toAccount.ID, "amount": "0.001"},
+			}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "BatchTxError",
+			username: owner,
+			body:     map[string]any{"currency": util.USD, "mode": db.BatchBestEffort, "transfers": legs},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.BatchTransferTxResult{}, pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request := newJSONRequest(t, http.MethodPost, "/transfers/batch", tc.body)
+			addAuthorization(t, request, store, server.tokenMaker, tc.username, util.DepositorRole)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
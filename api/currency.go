@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type currencyResponse struct {
+	Code        string             `json:"code"`
+	Exponent    int16              `json:"exponent"`
+	Enabled     bool               `json:"enabled"`
+	DisplayName string             `json:"display_name"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+func newCurrencyResponse(currency db.Currency) currencyResponse {
+	return currencyResponse{
+		Code:        currency.Code,
+		Exponent:    currency.Exponent,
+		Enabled:     currency.Enabled,
+		DisplayName: currency.DisplayName,
+		UpdatedAt:   currency.UpdatedAt,
+	}
+}
+
+// listCurrencies returns the currencies new accounts and transfers can use.
+func (server *Server) listCurrencies(ctx *gin.Context) {
+	currencies, err := server.currencies.List(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := []currencyResponse{}
+	for _, currency := range currencies {
+		if currency.Enabled {
+			rsp = append(rsp, newCurrencyResponse(currency))
+		}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestListCurrenciesAPI(t *testing.T) {
+	store := mockdb.NewMockStore(gomock.NewController(t))
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request := newJSONRequest(t, http.MethodGet, "/currencies", nil)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var currencies []currencyResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &currencies))
+
+	// CAD is disabled
+	var codes []string
+	for _, currency := range currencies {
+		codes = append(codes, currency.Code)
+	}
+	require.Equal(t, []string{util.EUR, util.INR, util.USD}, codes)
+}
+
+func TestSetCurrencyEnabledAPI(t *testing.T) {
+	admin := util.RandomOwner()
+
+	testCases := []struct {
+		name          string
+		url           string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Enable",
+			url:  "/admin/currencies/CAD/enable",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.SetCurrencyEnabledParams{Code: "CAD", Enabled: true}
+				store.EXPECT().SetCurrencyEnabled(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.Currency{Code: "CAD", Exponent: 2, Enabled: true, DisplayName: "Canadian Dollar"}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var currency currencyResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &currency))
+				require.Equal(t, "CAD", currency.Code)
+				require.True(t, currency.Enabled)
+			},
+		},
+		{
+			name: "Disable",
+			url:  "/admin/currencies/USD/disable",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.SetCurrencyEnabledParams{Code: util.USD, Enabled: false}
+				store.EXPECT().SetCurrencyEnabled(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.Currency{Code: util.USD, Exponent: 2, DisplayName: "US Dollar"}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			url:  "/admin/currencies/XYZ/enable",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetCurrencyEnabled(gomock.Any(), gomock.Any()).Times(1).Return(db.Currency{}, pgx.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCode",
+			url:  "/admin/currencies/usd/enable",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetCurrencyEnabled(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			url:  "/admin/currencies/CAD/enable",
+			role: util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetCurrencyEnabled(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request := newJSONRequest(t, http.MethodPost, tc.url, nil)
+			addAuthorization(t, request, store, server.tokenMaker, admin, tc.role)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
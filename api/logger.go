@@ -0,0 +1,84 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// errorBodyRecorder keeps the body of error responses so the logger can report the error
+type errorBodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (recorder *errorBodyRecorder) Write(data []byte) (int, error) {
+	if recorder.Status() >= http.StatusBadRequest {
+		recorder.body.Write(data)
+	}
+	return recorder.ResponseWriter.Write(data)
+}
+
+// requestID takes the request ID sent by the client, or generates one,
+// and makes it available to the handlers, the database logs and the client.
+func requestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := util.RequestIDOrNew(ctx.GetHeader(util.RequestIDHeader))
+
+		ctx.Request = ctx.Request.WithContext(util.ContextWithRequestID(ctx.Request.Context(), requestID))
+		ctx.Header(util.RequestIDHeader, requestID)
+		ctx.Next()
+	}
+}
+
+// httpLogger logs every request with the same fields as gapi.GrpcLogger
+func httpLogger() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		recorder := &errorBodyRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+
+		startTime := time.Now()
+		ctx.Next()
+		duration := time.Since(startTime)
+
+		statusCode := ctx.Writer.Status()
+
+		// Unmatched routes have no pattern, fall back to the raw path
+		path := ctx.FullPath()
+		if path == "" {
+			path = ctx.Request.URL.Path
+		}
+
+		logger := log.Info()
+		if statusCode >= http.StatusBadRequest {
+			logger = log.Error().Bytes("body", recorder.body.Bytes())
+		}
+		if len(ctx.Errors) > 0 {
+			logger = logger.Str("errors", ctx.Errors.String())
+		}
+
+		if spanContext := trace.SpanContextFromContext(ctx.Request.Context()); spanContext.HasTraceID() {
+			logger = logger.Str("trace_id", spanContext.TraceID().String())
+		}
+
+		if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+			logger = logger.Str("username", payload.(*token.Payload).Username)
+		}
+
+		logger.
+			Str("protocol", "http").
+			Str("request_id", util.RequestIDFromContext(ctx)).
+			Str("method", ctx.Request.Method).
+			Str("path", path).
+			Int("status_code", statusCode).
+			Str("status_text", http.StatusText(statusCode)).
+			Dur("duration", duration).
+			Msg("request processed")
+	}
+}
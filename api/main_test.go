@@ -0,0 +1,112 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Aadityaa2606/Bank-API/currency"
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/health"
+	"github.com/Aadityaa2606/Bank-API/revocation"
+	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// testCurrencies stands in for the currencies table, so handler tests need no stubs for it
+type testCurrencies struct{}
+
+func (testCurrencies) ListCurrencies(ctx context.Context) ([]db.Currency, error) {
+	return []db.Currency{
+		{Code: "CAD", Exponent: 2, Enabled: false, DisplayName: "Canadian Dollar"},
+		{Code: util.EUR, Exponent: 2, Enabled: true, DisplayName: "Euro"},
+		{Code: util.INR, Exponent: 2, Enabled: true, DisplayName: "Indian Rupee"},
+		{Code: util.USD, Exponent: 2, Enabled: true, DisplayName: "US Dollar"},
+	}, nil
+}
+
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		MFAChallengeDuration: time.Minute,
+	}
+
+	server, err := NewServer(config, store, nil, revocation.NewChecker(store, time.Minute), currency.NewRegistry(testCurrencies{}, time.Minute), health.NewChecker(nil, 0))
+	require.NoError(t, err)
+
+	return server
+}
+
+// addAuthorization signs an access token for the user and expects the
+// revocation checker to find its session active.
+func addAuthorization(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker, username string, role string) {
+	sessionID := util.RandomString(16)
+	accessToken, _, err := tokenMaker.CreateToken(username, role, sessionID, time.Minute, token.TokenTypeAccessToken)
+	require.NoError(t, err)
+
+	store.EXPECT().
+		GetSessionFamilyState(gomock.Any(), gomock.Eq(db.GetSessionFamilyStateParams{FamilyID: sessionID, Username: username})).
+		AnyTimes().
+		Return(db.GetSessionFamilyStateRow{SessionActive: true}, nil)
+
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+}
+
+// cacheSessionState has the revocation checker cache the session family as active.
+// The returned func reports whether the cached state has been dropped since, by
+// checking again against a store that now finds the family revoked.
+func cacheSessionState(t *testing.T, checker *revocation.Checker, store *mockdb.MockStore, username string, familyID string) func() bool {
+	payload, err := token.NewPayload(username, util.DepositorRole, familyID, time.Minute, token.TokenTypeAccessToken)
+	require.NoError(t, err)
+
+	arg := db.GetSessionFamilyStateParams{FamilyID: familyID, Username: username}
+	gomock.InOrder(
+		store.EXPECT().GetSessionFamilyState(gomock.Any(), gomock.Eq(arg)).Times(1).
+			Return(db.GetSessionFamilyStateRow{SessionActive: true}, nil),
+		store.EXPECT().GetSessionFamilyState(gomock.Any(), gomock.Eq(arg)).MaxTimes(1).
+			Return(db.GetSessionFamilyStateRow{SessionActive: false}, nil),
+	)
+	require.NoError(t, checker.Check(context.Background(), payload))
+
+	return func() bool {
+		return errors.Is(checker.Check(context.Background(), payload), revocation.ErrSessionRevoked)
+	}
+}
+
+func newJSONRequest(t *testing.T, method string, url string, body any) *http.Request {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequest(method, url, reader)
+	require.NoError(t, err)
+	return request
+}
+
+func requireBodyMatch[T any](t *testing.T, body *bytes.Buffer, expected T) {
+	var got T
+	err := json.Unmarshal(body.Bytes(), &got)
+	require.NoError(t, err)
+	require.Equal(t, expected, got)
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTransferFlowOnMemoryStore drives signup, login, account creation and a
+// transfer through the router with real queries and transactions, no database needed.
+func TestTransferFlowOnMemoryStore(t *testing.T) {
+	store := db.NewMemoryStore()
+	server := newTestServer(t, store)
+
+	serve := func(request *http.Request, accessToken string) *httptest.ResponseRecorder {
+		if accessToken != "" {
+			request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+		}
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	signUp := func(t *testing.T) (string, accountResponse) {
+		username := util.RandomOwner()
+		password := util.RandomString(8)
+
+		recorder := serve(newJSONRequest(t, http.MethodPost, "/users", createUserRequest{
+			Username: username,
+			Password: password,
+			FullName: util.RandomOwner(),
+			Email:    util.RandomEmail(),
+		}), "")
+		require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+
+		recorder = serve(newJSONRequest(t, http.MethodPost, "/users/login", loginUserRequest{
+			Username: username,
+			Password: password,
+		}), "")
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+		var login loginUserResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &login))
+
+		recorder = serve(newJSONRequest(t, http.MethodPost, "/accounts", createAccountRequest{Currency: util.USD}), login.AccessToken)
+		require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+
+		var account accountResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &account))
+		require.Equal(t, username, account.Owner)
+
+		return login.AccessToken, account
+	}
+
+	accessToken, account1 := signUp(t)
+	_, account2 := signUp(t)
+
+	_, err := store.AdjustBalanceTx(t.Context(), db.AdjustBalanceTxParams{
+		AccountID: account1.ID,
+		Amount:    100,
+		Reason:    "opening deposit",
+		Operator:  "test",
+	})
+	require.NoError(t, err)
+
+	recorder := serve(newJSONRequest(t, http.MethodPost, "/transfer", createTransferRequest{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        "0.60",
+		Currency:      util.USD,
+	}), accessToken)
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+
+	var result transferTxResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	require.Equal(t, util.NewMoney(60, util.USD), result.Transfer.Amount)
+	require.Equal(t, util.NewMoney(40, util.USD), result.FromAccount.Balance)
+	require.Equal(t, util.NewMoney(60, util.USD), result.ToAccount.Balance)
+
+	// The second transfer would overdraw the account and changes nothing
+	recorder = serve(newJSONRequest(t, http.MethodPost, "/transfer", createTransferRequest{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        "0.60",
+		Currency:      util.USD,
+	}), accessToken)
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+	updated1, err := store.GetAccount(t.Context(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(40), updated1.Balance)
+
+	updated2, err := store.GetAccount(t.Context(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(60), updated2.Balance)
+}
+
+// TestLogoutRotatedTokenOnMemoryStore logs out with a refresh token that was
+// already exchanged, which has to end the session it was rotated into as well.
+func TestLogoutRotatedTokenOnMemoryStore(t *testing.T) {
+	store := db.NewMemoryStore()
+	server := newTestServer(t, store)
+
+	serve := func(request *http.Request, accessToken string) *httptest.ResponseRecorder {
+		if accessToken != "" {
+			request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+		}
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	username := util.RandomOwner()
+	password := util.RandomString(8)
+
+	recorder := serve(newJSONRequest(t, http.MethodPost, "/users", createUserRequest{
+		Username: username,
+		Password: password,
+		FullName: util.RandomOwner(),
+		Email:    util.RandomEmail(),
+	}), "")
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+
+	recorder = serve(newJSONRequest(t, http.MethodPost, "/users/login", loginUserRequest{Username: username, Password: password}), "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var login loginUserResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &login))
+
+	recorder = serve(newJSONRequest(t, http.MethodPost, "/users/token/refresh", renewAccessTokenRequest{RefreshToken: login.RefreshToken}), "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var renewed renewAccessTokenResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &renewed))
+
+	recorder = serve(newJSONRequest(t, http.MethodPost, "/users/logout", logoutUserRequest{RefreshToken: login.RefreshToken}), login.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	// Neither the renewed refresh token nor the renewed access token work anymore
+	recorder = serve(newJSONRequest(t, http.MethodPost, "/users/token/refresh", renewAccessTokenRequest{RefreshToken: renewed.RefreshToken}), "")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = serve(newJSONRequest(t, http.MethodGet, "/users/sessions", nil), renewed.AccessToken)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
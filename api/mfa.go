@@ -0,0 +1,278 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/metrics"
+	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// recoveryCodeCount is the amount of recovery codes handed out when TOTP is enabled
+const recoveryCodeCount = 10
+
+var errInvalidMFACode = errors.New("invalid two-factor authentication code")
+
+// verifySecondFactor checks a TOTP code, or consumes a recovery code, of the user. Until
+// TOTP is enabled only codes of the secret being confirmed count, recovery codes come later.
+func (server *Server) verifySecondFactor(ctx *gin.Context, user db.User, code string) (bool, error) {
+	if !user.TotpSecret.Valid {
+		return false, nil
+	}
+
+	valid, err := server.useTOTPCode(ctx, user, code)
+	if err != nil || valid || !user.TotpEnabled {
+		return valid, err
+	}
+
+	_, err = server.store.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: util.HashSecret(util.NormalizeRecoveryCode(code)),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// useTOTPCode accepts a TOTP code once. Its time step must be newer than the last one
+// accepted for the user, and becomes the last one, so an observed code cannot be replayed.
+func (server *Server) useTOTPCode(ctx *gin.Context, user db.User, code string) (bool, error) {
+	step, valid := util.MatchTOTPCode(user.TotpSecret.String, code, time.Now())
+	if !valid || step <= user.TotpLastStep {
+		return false, nil
+	}
+
+	_, err := server.store.UseTOTPStep(ctx, db.UseTOTPStepParams{
+		Username: user.Username,
+		Step:     step,
+	})
+	if err != nil {
+		// Another request accepted a code of this step first
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// checkSecondFactor answers the request unless the code is valid. Locked users get 429 and
+// wrong codes count towards the same lockout as wrong passwords, so a stolen session is not
+// enough to guess the code.
+func (server *Server) checkSecondFactor(ctx *gin.Context, user db.User, code string) bool {
+	if time.Now().Before(user.LockedUntil.Time) {
+		metrics.LoginAttempt(metrics.LoginLocked)
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(errTooManyLoginAttempts))
+		return false
+	}
+
+	valid, err := server.verifySecondFactor(ctx, user, code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	if valid {
+		return true
+	}
+
+	metrics.LoginAttempt(metrics.LoginFailed)
+	err = server.store.RecordFailedLoginTx(ctx, db.RecordFailedLoginTxParams{
+		Username:        user.Username,
+		ClientIP:        ctx.ClientIP(),
+		LockoutDuration: util.LockoutDuration,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFACode))
+	return false
+}
+
+type loginUserMFARequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// loginUserMFA completes the second step of a login for users with TOTP enabled.
+// It exchanges the challenge token from loginUser and a TOTP or recovery code for the session tokens.
+func (server *Server) loginUserMFA(ctx *gin.Context) {
+	var req loginUserMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	challengePayload, err := server.tokenMaker.VerifyToken(req.ChallengeToken, token.TokenTypeMFAChallenge)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, challengePayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !server.checkSecondFactor(ctx, user, req.Code) {
+		return
+	}
+
+	rsp, err := server.createLoginSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type setupTOTPResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// setupTOTP generates a new TOTP secret for the authenticated user.
+// Two-factor authentication stays off until the secret is confirmed with enableTOTP.
+func (server *Server) setupTOTP(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.TotpEnabled {
+		ctx.JSON(http.StatusConflict, errorResponse(errors.New("two-factor authentication is already enabled")))
+		return
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.SetUserTOTPSecret(ctx, db.SetUserTOTPSecretParams{
+		Username:   user.Username,
+		TotpSecret: pgtype.Text{String: secret, Valid: true},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, setupTOTPResponse{
+		Secret:     secret,
+		OtpauthURI: util.TOTPURI(util.TOTPIssuer, user.Username, secret),
+	})
+}
+
+type totpCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type enableTOTPResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// enableTOTP confirms the secret from setupTOTP with a code from the authenticator
+// and returns the recovery codes, which are only ever shown once.
+func (server *Server) enableTOTP(ctx *gin.Context) {
+	var req totpCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.TotpEnabled {
+		ctx.JSON(http.StatusConflict, errorResponse(errors.New("two-factor authentication is already enabled")))
+		return
+	}
+
+	if !user.TotpSecret.Valid {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("two-factor authentication has not been set up")))
+		return
+	}
+
+	if !server.checkSecondFactor(ctx, user, req.Code) {
+		return
+	}
+
+	recoveryCodes, err := util.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	recoveryCodeHashes := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		recoveryCodeHashes[i] = util.HashSecret(util.NormalizeRecoveryCode(code))
+	}
+
+	_, err = server.store.EnableTOTPTx(ctx, db.EnableTOTPTxParams{
+		Username:           user.Username,
+		RecoveryCodeHashes: recoveryCodeHashes,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enableTOTPResponse{RecoveryCodes: recoveryCodes})
+}
+
+// disableTOTP turns off two-factor authentication after checking a TOTP or recovery code
+func (server *Server) disableTOTP(ctx *gin.Context) {
+	var req totpCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !user.TotpEnabled {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("two-factor authentication is not enabled")))
+		return
+	}
+
+	if !server.checkSecondFactor(ctx, user, req.Code) {
+		return
+	}
+
+	user, err = server.store.DisableTOTPTx(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
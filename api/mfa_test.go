@@ -0,0 +1,145 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// randomTOTPUser returns a user with TOTP enabled and a code of the current time step
+func randomTOTPUser(t *testing.T) (db.User, string, int64) {
+	user, _ := randomUser(t)
+
+	secret, err := util.GenerateTOTPSecret()
+	require.NoError(t, err)
+	user.TotpSecret = pgtype.Text{String: secret, Valid: true}
+	user.TotpEnabled = true
+
+	now := time.Now()
+	code, err := util.GenerateTOTPCode(secret, now)
+	require.NoError(t, err)
+	step, ok := util.MatchTOTPCode(secret, code, now)
+	require.True(t, ok)
+
+	return user, code, step
+}
+
+func TestDisableTOTPAPI(t *testing.T) {
+	user, code, step := randomTOTPUser(t)
+
+	testCases := []struct {
+		name          string
+		user          func() db.User
+		code          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: func() db.User { return user },
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UseTOTPStepParams{Username: user.Username, Step: step}
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Eq(arg)).Times(1).Return(user, nil)
+				store.EXPECT().RecordFailedLoginTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DisableTOTPTx(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "WrongCode",
+			user: func() db.User { return user },
+			code: "wrong-code",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(db.RecoveryCode{}, pgx.ErrNoRows)
+				store.EXPECT().RecordFailedLoginTx(gomock.Any(), gomock.Any()).Times(1).
+					Do(func(_ any, arg db.RecordFailedLoginTxParams) {
+						require.Equal(t, user.Username, arg.Username)
+					}).
+					Return(nil)
+				store.EXPECT().DisableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ReplayedCode",
+			user: func() db.User {
+				used := user
+				used.TotpLastStep = step
+				return used
+			},
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(db.RecoveryCode{}, pgx.ErrNoRows)
+				store.EXPECT().RecordFailedLoginTx(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().DisableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "CodeUsedConcurrently",
+			user: func() db.User { return user },
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, pgx.ErrNoRows)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(db.RecoveryCode{}, pgx.ErrNoRows)
+				store.EXPECT().RecordFailedLoginTx(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().DisableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Locked",
+			user: func() db.User {
+				locked := user
+				locked.LockedUntil = pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true}
+				return locked
+			},
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RecordFailedLoginTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DisableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(tc.user(), nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request := newJSONRequest(t, http.MethodPost, "/users/totp/disable", map[string]any{"code": tc.code})
+			addAuthorization(t, request, store, server.tokenMaker, user.Username, util.DepositorRole)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
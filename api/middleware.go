@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/Aadityaa2606/Bank-API/revocation"
 	"github.com/Aadityaa2606/Bank-API/token"
 	"github.com/gin-gonic/gin"
 )
@@ -15,7 +16,8 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+// authMiddleware verifies the access token and makes sure its session is still valid
+func authMiddleware(tokenMaker token.Maker, revocationChecker *revocation.Checker) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
@@ -42,13 +44,44 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		}
 
 		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
+		payload, err := tokenMaker.VerifyToken(accessToken, token.TokenTypeAccessToken)
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
 			return
 		}
 
+		err = revocationChecker.Check(ctx, payload)
+		if err != nil {
+			if errors.Is(err, revocation.ErrNoSession) ||
+				errors.Is(err, revocation.ErrSessionRevoked) ||
+				errors.Is(err, revocation.ErrPasswordChanged) {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
 		ctx.Set(authorizationPayloadKey, payload)
 		ctx.Next()
 	}
 }
+
+// roleMiddleware only lets through users whose access token carries one of the roles.
+// It must run after authMiddleware.
+func roleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+		for _, role := range roles {
+			if payload.Role == role {
+				ctx.Next()
+				return
+			}
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errors.New(
+			"permission denied",
+		)))
+	}
+}
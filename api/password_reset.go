@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/mail"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// passwordResetTokenBytes is the amount of random bytes in a password reset token
+const passwordResetTokenBytes = 32
+
+type requestPasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// requestPasswordReset emails a single-use reset link to the owner of the email address.
+// The response is the same whether or not the email is registered so it cannot be used
+// to find out which emails have an account. The token and the email are made in the
+// background, so registered emails are not answered any slower either.
+func (server *Server) requestPasswordReset(ctx *gin.Context) {
+	var req requestPasswordResetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rsp := gin.H{
+		"message": "if the email is registered, a password reset link has been sent",
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusAccepted, rsp)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// A full queue is only logged, answering differently would reveal that the email exists
+	if !server.mailer.Enqueue(server.passwordResetTask(user)) {
+		log.Error().Str("username", user.Username).Msg("email queue is full, password reset email dropped")
+	}
+
+	ctx.JSON(http.StatusAccepted, rsp)
+}
+
+// passwordResetTask creates a reset token for the user and emails its link
+func (server *Server) passwordResetTask(user db.User) mail.Task {
+	return func(ctx context.Context, sender mail.EmailSender) error {
+		resetToken, err := util.RandomSecret(passwordResetTokenBytes)
+		if err != nil {
+			return fmt.Errorf("cannot create reset token: %w", err)
+		}
+
+		_, err = server.store.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+			Username:  user.Username,
+			TokenHash: util.HashSecret(resetToken),
+			ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(server.config.PasswordResetTokenDuration), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("cannot save reset token of %s: %w", user.Username, err)
+		}
+
+		resetLink := server.config.PasswordResetURL + "?token=" + url.QueryEscape(resetToken)
+		subject, content := mail.PasswordResetEmail(user.FullName, resetLink, server.config.PasswordResetTokenDuration)
+
+		err = sender.SendEmail(ctx, subject, content, []string{user.Email})
+		if err != nil {
+			return fmt.Errorf("cannot send password reset email to %s: %w", user.Username, err)
+		}
+		return nil
+	}
+}
+
+type confirmPasswordResetRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// confirmPasswordReset sets a new password using a token from requestPasswordReset
+// and signs the user out of every existing session.
+func (server *Server) confirmPasswordReset(ctx *gin.Context) {
+	var req confirmPasswordResetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenHash:      util.HashSecret(req.Token),
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidResetToken) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	server.revocationChecker.ForgetUser(result.User.Username)
+
+	ctx.JSON(http.StatusOK, newUserResponse(result.User))
+}
@@ -0,0 +1,318 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/mail"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type sentEmail struct {
+	content string
+	to      []string
+}
+
+type recordingSender struct {
+	emails chan sentEmail
+}
+
+func (sender recordingSender) SendEmail(ctx context.Context, subject string, content string, to []string) error {
+	sender.emails <- sentEmail{content: content, to: to}
+	return nil
+}
+
+// drainMailer performs the queued email tasks and returns the emails they sent
+func drainMailer(t *testing.T, queue *mail.Queue, sender recordingSender) []sentEmail {
+	go queue.Run()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, queue.Shutdown(ctx))
+
+	emails := []sentEmail{}
+	for len(sender.emails) > 0 {
+		emails = append(emails, <-sender.emails)
+	}
+	return emails
+}
+
+var resetTokenPattern = regexp.MustCompile(`\?token=(\S+)`)
+
+// resetTokenFromEmail returns the token of the reset link in a password reset email
+func resetTokenFromEmail(t *testing.T, email sentEmail) string {
+	match := resetTokenPattern.FindStringSubmatch(email.content)
+	require.Len(t, match, 2, email.content)
+
+	resetToken, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return resetToken
+}
+
+func TestRequestPasswordResetAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	accepted := map[string]string{"message": "if the email is registered, a password reset link has been sent"}
+
+	testCases := []struct {
+		name          string
+		body          map[string]any
+		fullQueue     bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, emails []sentEmail)
+	}{
+		{
+			name: "KnownEmail",
+			body: map[string]any{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Cond(func(arg db.CreatePasswordResetTokenParams) bool {
+					return arg.Username == user.Username && arg.TokenHash != ""
+				})).Times(1).Return(db.PasswordResetToken{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, emails []sentEmail) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				requireBodyMatch(t, recorder.Body, accepted)
+				require.Len(t, emails, 1)
+				require.Equal(t, []string{user.Email}, emails[0].to)
+			},
+		},
+		{
+			name: "UnknownEmail",
+			body: map[string]any{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(db.User{}, pgx.ErrNoRows)
+				store.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, emails []sentEmail) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				requireBodyMatch(t, recorder.Body, accepted)
+				require.Empty(t, emails)
+			},
+		},
+		{
+			name:      "FullQueue",
+			body:      map[string]any{"email": user.Email},
+			fullQueue: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, emails []sentEmail) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				requireBodyMatch(t, recorder.Body, accepted)
+				require.Empty(t, emails)
+			},
+		},
+		{
+			name: "InternalError",
+			body: map[string]any{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(db.User{}, pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, emails []sentEmail) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Empty(t, emails)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: map[string]any{"email": "not-an-email"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, emails []sentEmail) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Empty(t, emails)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			sender := recordingSender{emails: make(chan sentEmail, 2)}
+			queue := mail.NewQueue(sender, 1)
+			if tc.fullQueue {
+				require.True(t, queue.Enqueue(func(ctx context.Context, sender mail.EmailSender) error { return nil }))
+			}
+
+			server := newTestServer(t, store)
+			server.mailer = queue
+			recorder := httptest.NewRecorder()
+
+			request := newJSONRequest(t, http.MethodPost, "/users/password/reset", tc.body)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, drainMailer(t, queue, sender))
+		})
+	}
+}
+
+func TestConfirmPasswordResetAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	resetToken := util.RandomString(32)
+	newPassword := util.RandomString(8)
+
+	testCases := []struct {
+		name          string
+		body          map[string]any
+		buildStubs    func(store *mockdb.MockStore)
+		forgotten     bool
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: map[string]any{"token": resetToken, "new_password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Cond(func(arg db.ResetPasswordTxParams) bool {
+					return arg.TokenHash == util.HashSecret(resetToken) &&
+						util.CheckPassword(arg.HashedPassword, newPassword) == nil
+				})).Times(1).Return(db.ResetPasswordTxResult{User: user}, nil)
+			},
+			forgotten: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatch(t, recorder.Body, newUserResponse(user))
+			},
+		},
+		{
+			name: "InvalidToken",
+			body: map[string]any{"token": resetToken, "new_password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ResetPasswordTxResult{}, db.ErrInvalidResetToken)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: map[string]any{"token": resetToken, "new_password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ResetPasswordTxResult{}, pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "ShortPassword",
+			body: map[string]any{"token": resetToken, "new_password": "123"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			forgotten := cacheSessionState(t, server.revocationChecker, store, user.Username, util.RandomString(16))
+			recorder := httptest.NewRecorder()
+
+			request := newJSONRequest(t, http.MethodPost, "/users/password/reset/confirm", tc.body)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+			require.Equal(t, tc.forgotten, forgotten())
+		})
+	}
+}
+
+// TestPasswordResetFlowOnMemoryStore resets a password with the link of the email,
+// which signs the user out everywhere, and makes sure bad tokens are refused.
+func TestPasswordResetFlowOnMemoryStore(t *testing.T) {
+	store := db.NewMemoryStore()
+	server := newTestServer(t, store)
+	server.config.PasswordResetTokenDuration = time.Minute
+
+	sender := recordingSender{emails: make(chan sentEmail, 1)}
+	server.mailer = mail.NewQueue(sender, 1)
+
+	serve := func(request *http.Request, accessToken string) *httptest.ResponseRecorder {
+		if accessToken != "" {
+			request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+		}
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	password := util.RandomString(8)
+	newPassword := util.RandomString(8)
+	createUser := createUserRequest{
+		Username: util.RandomOwner(),
+		Password: password,
+		FullName: util.RandomOwner(),
+		Email:    util.RandomEmail(),
+	}
+
+	recorder := serve(newJSONRequest(t, http.MethodPost, "/users", createUser), "")
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+
+	recorder = serve(newJSONRequest(t, http.MethodPost, "/users/login", loginUserRequest{Username: createUser.Username, Password: password}), "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var login loginUserResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &login))
+
+	// The session is cached as active before the reset
+	recorder = serve(newJSONRequest(t, http.MethodGet, "/users/sessions", nil), login.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	expiredToken := util.RandomString(32)
+	_, err := store.CreatePasswordResetToken(context.Background(), db.CreatePasswordResetTokenParams{
+		Username:  createUser.Username,
+		TokenHash: util.HashSecret(expiredToken),
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true},
+	})
+	require.NoError(t, err)
+
+	confirm := func(resetToken string) int {
+		body := confirmPasswordResetRequest{Token: resetToken, NewPassword: newPassword}
+		return serve(newJSONRequest(t, http.MethodPost, "/users/password/reset/confirm", body), "").Code
+	}
+	require.Equal(t, http.StatusBadRequest, confirm(expiredToken))
+	require.Equal(t, http.StatusBadRequest, confirm(util.RandomString(32)))
+
+	recorder = serve(newJSONRequest(t, http.MethodPost, "/users/password/reset", requestPasswordResetRequest{Email: createUser.Email}), "")
+	require.Equal(t, http.StatusAccepted, recorder.Code, recorder.Body.String())
+
+	emails := drainMailer(t, server.mailer, sender)
+	require.Len(t, emails, 1)
+	resetToken := resetTokenFromEmail(t, emails[0])
+
+	require.Equal(t, http.StatusOK, confirm(resetToken))
+	require.Equal(t, http.StatusBadRequest, confirm(resetToken))
+
+	// Every session of the user is gone, cached or not
+	recorder = serve(newJSONRequest(t, http.MethodGet, "/users/sessions", nil), login.AccessToken)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = serve(newJSONRequest(t, http.MethodPost, "/users/token/refresh", renewAccessTokenRequest{RefreshToken: login.RefreshToken}), "")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = serve(newJSONRequest(t, http.MethodPost, "/users/login", loginUserRequest{Username: createUser.Username, Password: newPassword}), "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+}
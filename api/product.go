@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// productResponse holds amounts in minor units of the currency of each account,
+// since a product applies to accounts in different currencies
+type productResponse struct {
+	Code                       string             `json:"code"`
+	Name                       string             `json:"name"`
+	Enabled                    bool               `json:"enabled"`
+	AllowedCurrencies          []string           `json:"allowed_currencies"`
+	MaxAccountsPerUser         int32              `json:"max_accounts_per_user"`
+	MinBalance                 int64              `json:"min_balance"`
+	MaxMonthlyWithdrawals      int32              `json:"max_monthly_withdrawals"`
+	MaxMonthlyWithdrawalAmount int64              `json:"max_monthly_withdrawal_amount"`
+	InterestRateBps            int32              `json:"interest_rate_bps"`
+	MonthlyFee                 int64              `json:"monthly_fee"`
+	CreatedAt                  pgtype.Timestamptz `json:"created_at"`
+}
+
+func newProductResponse(product db.AccountProduct) productResponse {
+	return productResponse{
+		Code:                       product.Code,
+		Name:                       product.Name,
+		Enabled:                    product.Enabled,
+		AllowedCurrencies:          product.AllowedCurrencies,
+		MaxAccountsPerUser:         product.MaxAccountsPerUser,
+		MinBalance:                 product.MinBalance,
+		MaxMonthlyWithdrawals:      product.MaxMonthlyWithdrawals,
+		MaxMonthlyWithdrawalAmount: product.MaxMonthlyWithdrawalAmount,
+		InterestRateBps:            product.InterestRateBps,
+		MonthlyFee:                 product.MonthlyFee,
+		CreatedAt:                  product.CreatedAt,
+	}
+}
+
+// listProducts returns the products new accounts can be opened with.
+func (server *Server) listProducts(ctx *gin.Context) {
+	products, err := server.store.ListAccountProducts(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := []productResponse{}
+	for _, product := range products {
+		if product.Enabled {
+			rsp = append(rsp, newProductResponse(product))
+		}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
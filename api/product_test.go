@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestListProductsAPI(t *testing.T) {
+	store := mockdb.NewMockStore(gomock.NewController(t))
+	store.EXPECT().ListAccountProducts(gomock.Any()).Times(1).Return([]db.AccountProduct{
+		{Code: "checking", Name: "Checking", Enabled: true, AllowedCurrencies: []string{}},
+		{Code: "legacy", Name: "Legacy", Enabled: false, AllowedCurrencies: []string{}},
+		{Code: "savings", Name: "Savings", Enabled: true, AllowedCurrencies: []string{}, MaxAccountsPerUser: 2},
+	}, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request := newJSONRequest(t, http.MethodGet, "/products", nil)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var products []productResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &products))
+
+	// legacy is disabled
+	var codes []string
+	for _, product := range products {
+		codes = append(codes, product.Code)
+	}
+	require.Equal(t, []string{"checking", "savings"}, codes)
+}
+
+func TestCreateProductAPI(t *testing.T) {
+	admin := util.RandomOwner()
+
+	testCases := []struct {
+		name          string
+		body          map[string]any
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: map[string]any{
+				"code":                    "youth",
+				"name":                    "Youth Savings",
+				"allowed_currencies":      []string{util.USD},
+				"max_accounts_per_user":   1,
+				"min_balance":             1000,
+				"max_monthly_withdrawals": 3,
+			},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateAccountProductParams{
+					Code:                  "youth",
+					Name:                  "Youth Savings",
+					AllowedCurrencies:     []string{util.USD},
+					MaxAccountsPerUser:    1,
+					MinBalance:            1000,
+					MaxMonthlyWithdrawals: 3,
+				}
+				product := db.AccountProduct{
+					Code:                  arg.Code,
+					Name:                  arg.Name,
+					Enabled:               true,
+					AllowedCurrencies:     arg.AllowedCurrencies,
+					MaxAccountsPerUser:    arg.MaxAccountsPerUser,
+					MinBalance:            arg.MinBalance,
+					MaxMonthlyWithdrawals: arg.MaxMonthlyWithdrawals,
+				}
+				store.EXPECT().CreateAccountProduct(gomock.Any(), gomock.Eq(arg)).Times(1).Return(product, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var product productResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &product))
+				require.Equal(t, "youth", product.Code)
+				require.Equal(t, []string{util.USD}, product.AllowedCurrencies)
+			},
+		},
+		{
+			name: "DuplicateCode",
+			body: map[string]any{"code": "savings", "name": "Savings"},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountProduct(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.AccountProduct{}, &pgconn.PgError{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "DisabledCurrency",
+			body: map[string]any{"code": "loonie", "name": "Loonie", "allowed_currencies": []string{"CAD"}},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NegativeLimit",
+			body: map[string]any{"code": "youth", "name": "Youth", "min_balance": -1},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			body: map[string]any{"code": "youth", "name": "Youth"},
+			role: util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request := newJSONRequest(t, http.MethodPost, "/admin/products", tc.body)
+			addAuthorization(t, request, store, server.tokenMaker, admin, tc.role)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
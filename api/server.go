@@ -3,69 +3,108 @@ package api
 import (
 	"fmt"
 	"net/http"
-	"os"
-	"time"
 
+	"github.com/Aadityaa2606/Bank-API/currency"
 	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/health"
+	"github.com/Aadityaa2606/Bank-API/mail"
+	"github.com/Aadityaa2606/Bank-API/metrics"
+	"github.com/Aadityaa2606/Bank-API/revocation"
+	"github.com/Aadityaa2606/Bank-API/telemetry"
 	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/Aadityaa2606/Bank-API/util"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Server struct {
-	store                *db.Store
-	tokenMaker           token.Maker
-	router               *gin.Engine
-	accessTokenDuration  time.Duration
-	refreshTokenDuration time.Duration
+	config            util.Config
+	store             db.Store
+	tokenMaker        token.Maker
+	revocationChecker *revocation.Checker
+	currencies        *currency.Registry
+	healthChecker     *health.Checker
+	mailer            *mail.Queue
+	router            *gin.Engine
 }
 
-func NewServer(store *db.Store) (*Server, error) {
-	tokenMaker, err := token.NewJWTMaker(os.Getenv("TOKEN_SYMMETRIC_KEY"))
+func NewServer(config util.Config, store db.Store, mailer *mail.Queue, revocationChecker *revocation.Checker, currencies *currency.Registry, healthChecker *health.Checker) (*Server, error) {
+	tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
-	accessTokenDuration, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_DURATION"))
-	if err != nil {
-		return nil, fmt.Errorf("cannot parse access token duration: %w", err)
-	}
-
-	refreshTokenDuration, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_DURATION"))
-	if err != nil {
-		return nil, fmt.Errorf("cannot parse refresh token duration: %w", err)
-	}
-
 	server := &Server{
-		store:                store,
-		tokenMaker:           tokenMaker,
-		accessTokenDuration:  accessTokenDuration,
-		refreshTokenDuration: refreshTokenDuration,
+		config:            config,
+		store:             store,
+		tokenMaker:        tokenMaker,
+		revocationChecker: revocationChecker,
+		currencies:        currencies,
+		healthChecker:     healthChecker,
+		mailer:            mailer,
 	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("currency", validCurrency(currencies))
 	}
 
-	server.setupRouter()
+	if err := server.setupRouter(); err != nil {
+		return nil, err
+	}
 	return server, nil
 }
 
-func (server *Server) setupRouter() {
-	router := gin.Default()
+func (server *Server) setupRouter() error {
+	router := gin.New()
+	// Lets handlers pass the gin context on and still carry the request ID
+	router.ContextWithFallback = true
 
-	router.GET("/", func(ctx *gin.Context) {
-		ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
-	})
+	// ClientIP only believes X-Forwarded-For from the configured proxies, anyone
+	// else could reset the login lockout of their IP by sending another one
+	proxies, err := util.ParseTrustedProxies(server.config.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	var trustedProxies []string
+	for _, proxy := range proxies {
+		trustedProxies = append(trustedProxies, proxy.String())
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	router.Use(
+		otelgin.Middleware(telemetry.ServiceName),
+		requestID(),
+		httpLogger(),
+		metrics.GinMiddleware(),
+		gin.Recovery(),
+	)
+
+	router.GET("/", gin.WrapH(server.healthChecker.LivenessHandler()))
+	router.GET("/healthz", gin.WrapH(server.healthChecker.LivenessHandler()))
+	router.GET("/readyz", gin.WrapH(server.healthChecker.ReadinessHandler()))
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 	router.POST("/users", server.createUser)
 	router.POST("/users/login", server.loginUser)
+	router.POST("/users/login/mfa", server.loginUserMFA)
+	router.POST("/users/token/refresh", server.renewAccessToken)
+	router.POST("/users/password/reset", server.requestPasswordReset)
+	router.POST("/users/password/reset/confirm", server.confirmPasswordReset)
+	router.GET("/currencies", server.listCurrencies)
+	router.GET("/products", server.listProducts)
 
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.revocationChecker))
 
 	authRoutes.POST("/users/logout", server.logoutUser)
-	authRoutes.POST("/users/token/refresh", server.renewAccessToken)
 	authRoutes.POST("/users/revoke", server.revokeSession)
+	authRoutes.GET("/users/sessions", server.listSessions)
+	authRoutes.DELETE("/users/sessions", server.deleteAllSessions)
+	authRoutes.DELETE("/users/sessions/:id", server.deleteSession)
+	authRoutes.POST("/users/totp/setup", server.setupTOTP)
+	authRoutes.POST("/users/totp/enable", server.enableTOTP)
+	authRoutes.POST("/users/totp/disable", server.disableTOTP)
 
 	authRoutes.POST("/accounts", server.createAccount)
 	authRoutes.GET("/accounts/:id", server.getAccountById)
@@ -73,16 +112,44 @@ func (server *Server) setupRouter() {
 	authRoutes.GET("/accounts", server.getAccounts)
 	authRoutes.DELETE("/accounts/:id", server.deleteAccount)
 	authRoutes.PATCH("/accounts/:id", server.updateAccount)
+	authRoutes.GET("/accounts/:id/members", server.listAccountMembers)
+	authRoutes.POST("/accounts/:id/members", server.inviteAccountMember)
+	authRoutes.PATCH("/accounts/:id/members/:username", server.updateAccountMember)
+	authRoutes.DELETE("/accounts/:id/members/:username", server.removeAccountMember)
+	authRoutes.GET("/invitations", server.listInvitations)
+	authRoutes.POST("/invitations/:account_id/accept", server.acceptInvitation)
+	authRoutes.POST("/invitations/:account_id/decline", server.declineInvitation)
 
 	authRoutes.POST("/transfer", server.createTransfer)
+	authRoutes.POST("/transfers/batch", server.createBatchTransfer)
+
+	adminRoutes := router.Group("/admin").Use(
+		authMiddleware(server.tokenMaker, server.revocationChecker),
+		roleMiddleware(util.AdminRole),
+	)
+
+	adminRoutes.POST("/users/:username/unlock", server.unlockUser)
+	adminRoutes.GET("/currencies", server.listAllCurrencies)
+	adminRoutes.POST("/currencies/:code/enable", server.enableCurrency)
+	adminRoutes.POST("/currencies/:code/disable", server.disableCurrency)
+	adminRoutes.GET("/products", server.listAllProducts)
+	adminRoutes.POST("/products", server.createProduct)
+	adminRoutes.POST("/products/:code/enable", server.enableProduct)
+	adminRoutes.POST("/products/:code/disable", server.disableProduct)
 
 	server.router = router
+	return nil
 }
 
 func (server *Server) Start(address string) error {
 	return server.router.Run(address)
 }
 
+// Handler lets the caller run the router in its own http.Server, to control shutdown
+func (server *Server) Handler() http.Handler {
+	return server.router.Handler()
+}
+
 func errorResponse(err error) gin.H {
 	return gin.H{"error": err.Error()}
 }
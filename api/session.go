@@ -0,0 +1,119 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type sessionResponse struct {
+	ID        string             `json:"id"`
+	UserAgent string             `json:"user_agent"`
+	ClientIP  string             `json:"client_ip"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+func newSessionResponse(session db.Session) sessionResponse {
+	return sessionResponse{
+		ID:        session.ID,
+		UserAgent: session.UserAgent,
+		ClientIP:  session.ClientIp,
+		CreatedAt: session.CreatedAt,
+		ExpiresAt: session.ExpiresAt,
+	}
+}
+
+// listSessions returns the active sessions of the authenticated user, one per signed in device.
+func (server *Server) listSessions(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	sessions, err := server.store.ListActiveSessions(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]sessionResponse, len(sessions))
+	for i, session := range sessions {
+		rsp[i] = newSessionResponse(session)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// getOwnSession fetches a session and makes sure it belongs to the authenticated user.
+// It writes the error response itself.
+func (server *Server) getOwnSession(ctx *gin.Context, sessionID string) (db.Session, bool) {
+	session, err := server.store.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errors.New("session not found")))
+			return session, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return session, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if session.Username != authPayload.Username {
+		ctx.JSON(
+			http.StatusUnauthorized,
+			errorResponse(errors.New("session doesn't belong to the authenticated user")),
+		)
+		return session, false
+	}
+
+	return session, true
+}
+
+type deleteSessionRequest struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+// deleteSession signs out one device of the authenticated user.
+// Every refresh token the session was rotated from or into stops working.
+func (server *Server) deleteSession(ctx *gin.Context) {
+	var req deleteSessionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	session, ok := server.getOwnSession(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	err := server.store.RevokeSessionFamily(ctx, session.FamilyID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	server.revocationChecker.ForgetSession(session.FamilyID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "session revoked",
+	})
+}
+
+// deleteAllSessions signs the authenticated user out everywhere.
+func (server *Server) deleteAllSessions(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	err := server.store.RevokeUserSessions(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	server.revocationChecker.ForgetUser(authPayload.Username)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "all sessions revoked",
+	})
+}
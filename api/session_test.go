@@ -0,0 +1,202 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func randomSession(username string) db.Session {
+	return db.Session{
+		ID:        util.RandomString(16),
+		Username:  username,
+		UserAgent: "test-agent",
+		ClientIp:  "192.0.2.1",
+		FamilyID:  util.RandomString(16),
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(time.Hour).UTC().Truncate(time.Second), Valid: true},
+	}
+}
+
+func TestListSessionsAPI(t *testing.T) {
+	username := util.RandomOwner()
+	sessions := []db.Session{randomSession(username), randomSession(username)}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListActiveSessions(gomock.Any(), gomock.Eq(username)).Times(1).Return(sessions, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatch(t, recorder.Body, []sessionResponse{newSessionResponse(sessions[0]), newSessionResponse(sessions[1])})
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListActiveSessions(gomock.Any(), gomock.Eq(username)).Times(1).Return(nil, pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request := newJSONRequest(t, http.MethodGet, "/users/sessions", nil)
+			addAuthorization(t, request, store, server.tokenMaker, username, util.DepositorRole)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDeleteSessionAPI(t *testing.T) {
+	owner := util.RandomOwner()
+	session := randomSession(owner)
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		forgotten     bool
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).Times(1).Return(nil)
+			},
+			forgotten: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "OtherUsersSession",
+			username: util.RandomOwner(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(db.Session{}, pgx.ErrNoRows)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).Times(1).Return(pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			forgotten := cacheSessionState(t, server.revocationChecker, store, owner, session.FamilyID)
+			recorder := httptest.NewRecorder()
+
+			request := newJSONRequest(t, http.MethodDelete, fmt.Sprintf("/users/sessions/%s", session.ID), nil)
+			addAuthorization(t, request, store, server.tokenMaker, tc.username, util.DepositorRole)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+			require.Equal(t, tc.forgotten, forgotten())
+		})
+	}
+}
+
+func TestDeleteAllSessionsAPI(t *testing.T) {
+	username := util.RandomOwner()
+	session := randomSession(username)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		forgotten     bool
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserSessions(gomock.Any(), gomock.Eq(username)).Times(1).Return(nil)
+			},
+			forgotten: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserSessions(gomock.Any(), gomock.Eq(username)).Times(1).Return(pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			forgotten := cacheSessionState(t, server.revocationChecker, store, username, session.FamilyID)
+			recorder := httptest.NewRecorder()
+
+			request := newJSONRequest(t, http.MethodDelete, "/users/sessions", nil)
+			addAuthorization(t, request, store, server.tokenMaker, username, util.DepositorRole)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+			require.Equal(t, tc.forgotten, forgotten())
+		})
+	}
+}
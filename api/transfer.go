@@ -1,14 +1,16 @@
 package api
 
 import (
-	// "errors"
+	"errors"
 	"fmt"
 	"net/http"
 
 	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
-	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/Aadityaa2606/Bank-API/metrics"
+	"github.com/Aadityaa2606/Bank-API/util"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func (server *Server) validateAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
@@ -33,10 +35,105 @@ func (server *Server) validateAccount(ctx *gin.Context, accountID int64, currenc
 }
 
 type createTransferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required"`
-	Amount        int64  `json:"amount" binding:"required"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID int64 `json:"from_account_id" binding:"required"`
+	ToAccountID   int64 `json:"to_account_id" binding:"required"`
+	// Amount is a decimal amount in the major unit of the currency, e.g. "12.34"
+	Amount   string `json:"amount" binding:"required"`
+	Currency string `json:"currency" binding:"required,currency"`
+	// TOTPCode is only needed for transfers above the step-up threshold
+	TOTPCode string `json:"totp_code"`
+}
+
+var errNonPositiveAmount = errors.New("amount must be positive")
+
+// parseTransferAmount reads a decimal amount of a transfer in the minor unit of its currency
+func parseTransferAmount(value string, currency string) (int64, error) {
+	amount, err := util.ParseMoney(value, currency)
+	if err != nil {
+		return 0, err
+	}
+	if amount.Amount <= 0 {
+		return 0, errNonPositiveAmount
+	}
+	return amount.Amount, nil
+}
+
+type transferResponse struct {
+	ID            int64              `json:"id"`
+	FromAccountID int64              `json:"from_account_id"`
+	ToAccountID   int64              `json:"to_account_id"`
+	Amount        util.Money         `json:"amount"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+func newTransferResponse(transfer db.Transfer, currency string) transferResponse {
+	return transferResponse{
+		ID:            transfer.ID,
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+		Amount:        util.NewMoney(transfer.Amount, currency),
+		CreatedAt:     transfer.CreatedAt,
+	}
+}
+
+type entryResponse struct {
+	ID        int64              `json:"id"`
+	AccountID int64              `json:"account_id"`
+	Amount    util.Money         `json:"amount"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+func newEntryResponse(entry db.Entry, currency string) entryResponse {
+	return entryResponse{
+		ID:        entry.ID,
+		AccountID: entry.AccountID,
+		Amount:    util.NewMoney(entry.Amount, currency),
+		CreatedAt: entry.CreatedAt,
+	}
+}
+
+type transferTxResponse struct {
+	Transfer    transferResponse `json:"transfer"`
+	FromAccount accountResponse  `json:"from_account"`
+	ToAccount   accountResponse  `json:"to_account"`
+	FromEntry   entryResponse    `json:"from_entry"`
+	ToEntry     entryResponse    `json:"to_entry"`
+}
+
+func newTransferTxResponse(result db.TransferTxResult, currency string) transferTxResponse {
+	return transferTxResponse{
+		Transfer:    newTransferResponse(result.Transfer, currency),
+		FromAccount: newAccountResponse(result.FromAccount),
+		ToAccount:   newAccountResponse(result.ToAccount),
+		FromEntry:   newEntryResponse(result.FromEntry, currency),
+		ToEntry:     newEntryResponse(result.ToEntry, currency),
+	}
+}
+
+// requireTransferStepUp asks users with TOTP enabled for a second factor
+// before transfers at or above the configured threshold.
+func (server *Server) requireTransferStepUp(ctx *gin.Context, username string, amount int64, code string) bool {
+	if server.config.TransferMFAThreshold <= 0 || amount < server.config.TransferMFAThreshold {
+		return true
+	}
+
+	user, err := server.store.GetUser(ctx, username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	if !user.TotpEnabled {
+		return true
+	}
+
+	if code == "" {
+		err := fmt.Errorf("two-factor authentication code is required for transfers of %d or more", server.config.TransferMFAThreshold)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return false
+	}
+
+	return server.checkSecondFactor(ctx, user, code)
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) {
@@ -46,15 +143,27 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
+	amount, err := parseTransferAmount(req.Amount, req.Currency)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
 	fromAccount, valid := server.validateAccount(ctx, req.FromAccountID, req.Currency)
 	if !valid {
 		return
 	}
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if fromAccount.Owner != authPayload.Username {
-		err := fmt.Errorf("account %d does not belong to the authenticated user", req.FromAccountID)
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	member, valid := server.authorizeAccount(ctx, fromAccount.ID)
+	if !valid {
+		return
+	}
+	if !member.CanTransfer(amount) {
+		err := errMemberNotAllowed
+		if member.Role == db.MemberSpender {
+			err = db.ErrSpendLimit
+		}
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
 		return
 	}
 
@@ -63,18 +172,38 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
+	if !server.requireTransferStepUp(ctx, member.Username, amount, req.TOTPCode) {
+		return
+	}
+
 	arg := db.TransferTxParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+		Amount:        amount,
+		InitiatedBy:   member.Username,
 	}
 
 	result, err := server.store.TransferTx(ctx, arg)
 
 	if err != nil {
+		if errors.Is(err, db.ErrInsufficientBalance) {
+			metrics.InsufficientBalance()
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrMinimumBalance) || errors.Is(err, db.ErrWithdrawalLimit) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		// The membership may have changed since it was checked above
+		if errors.Is(err, db.ErrSpendLimit) || errors.Is(err, db.ErrTransferNotAllowed) {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	metrics.TransferCompleted(req.Currency, amount)
 
-	ctx.JSON(http.StatusCreated, result)
+	ctx.JSON(http.StatusCreated, newTransferTxResponse(result, req.Currency))
 }
@@ -1,11 +1,14 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/metrics"
+	"github.com/Aadityaa2606/Bank-API/token"
 	"github.com/Aadityaa2606/Bank-API/util"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
@@ -78,12 +81,18 @@ func (server *Server) createUser(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, rsp)
 }
 
+var (
+	errInvalidCredentials   = errors.New("invalid username or password")
+	errTooManyLoginAttempts = errors.New("too many failed login attempts, try again later")
+)
+
 type loginUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
 	Password string `json:"password" binding:"required"`
 }
 
 type loginUserResponse struct {
+	MFARequired           bool         `json:"mfa_required"`
 	SessionID             string       `json:"session_id"`
 	AccessToken           string       `json:"access_token"`
 	RefreshToken          string       `json:"refresh_token"`
@@ -92,6 +101,12 @@ type loginUserResponse struct {
 	User                  userResponse `json:"user"`
 }
 
+type mfaChallengeResponse struct {
+	MFARequired        bool      `json:"mfa_required"`
+	ChallengeToken     string    `json:"challenge_token"`
+	ChallengeExpiresAt time.Time `json:"challenge_expires_at"`
+}
+
 func (server *Server) loginUser(ctx *gin.Context) {
 	var req loginUserRequest
 
@@ -101,85 +116,190 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
-	// Get the user from the database
-	user, err := server.store.GetUser(ctx, req.Username)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			ctx.JSON(http.StatusUnauthorized, gin.H{
-				"error": "user not found",
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	user, ok := server.checkCredentials(ctx, req.Username, req.Password)
+	if !ok {
 		return
 	}
 
-	// Check if the password is correct
-	err = util.CheckPassword(user.HashedPassword, req.Password)
-	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "invalid password",
+	// Users with two-factor authentication get a challenge instead of the tokens
+	if user.TotpEnabled {
+		challengeToken, challengePayload, err := server.tokenMaker.CreateToken(
+			user.Username,
+			user.Role,
+			"",
+			server.config.MFAChallengeDuration,
+			token.TokenTypeMFAChallenge,
+		)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, mfaChallengeResponse{
+			MFARequired:        true,
+			ChallengeToken:     challengeToken,
+			ChallengeExpiresAt: challengePayload.ExpiresAt.Time,
 		})
 		return
 	}
 
-	// Create the access token
-	accessToken, err := server.tokenMaker.CreateToken(user.Username, server.accessTokenDuration)
+	rsp, err := server.createLoginSession(ctx, user)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	// Create the refresh token
-	refreshToken, err := server.tokenMaker.CreateToken(user.Username, server.refreshTokenDuration)
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// checkCredentials verifies a username and password while enforcing the failed login limits
+// per client IP and per user. Every kind of bad credentials gets the same response so it
+// cannot be used to find out which usernames exist. It writes the error response itself.
+func (server *Server) checkCredentials(ctx *gin.Context, username string, password string) (db.User, bool) {
+	clientIP := ctx.ClientIP()
+	since := pgtype.Timestamptz{Time: time.Now().Add(-util.FailedLoginWindow), Valid: true}
+
+	ipFailures, err := server.store.CountRecentFailedLoginsByIP(ctx, db.CountRecentFailedLoginsByIPParams{
+		ClientIp: clientIP,
+		Since:    since,
+	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+		return db.User{}, false
+	}
+
+	if ipFailures >= util.MaxFailedLoginsPerIP {
+		metrics.LoginAttempt(metrics.LoginLocked)
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(errTooManyLoginAttempts))
+		return db.User{}, false
 	}
 
-	// Get the payload from the access token
-	accessPayload, err := server.tokenMaker.VerifyToken(accessToken)
+	user, err := server.store.GetUser(ctx, username)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+		if !errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return db.User{}, false
+		}
+
+		// Unknown usernames take as long and get locked on the same schedule as real ones,
+		// going by their failed logins and when the last one happened
+		util.SimulatePasswordCheck(password)
+
+		failures, err := server.store.GetFailedLoginsByUsername(ctx, username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return db.User{}, false
+		}
+
+		if time.Now().Before(util.LockedUntil(failures.FailedAttempts, failures.LastFailedAt.Time)) {
+			metrics.LoginAttempt(metrics.LoginLocked)
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(errTooManyLoginAttempts))
+			return db.User{}, false
+		}
+
+		server.rejectLogin(ctx, username)
+		return db.User{}, false
+	}
+
+	if time.Now().Before(user.LockedUntil.Time) {
+		metrics.LoginAttempt(metrics.LoginLocked)
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(errTooManyLoginAttempts))
+		return db.User{}, false
 	}
 
-	// Get the payload from the refresh token
-	refreshPayload, err := server.tokenMaker.VerifyToken(refreshToken)
+	err = util.CheckPassword(user.HashedPassword, password)
+	if err != nil {
+		server.rejectLogin(ctx, username)
+		return db.User{}, false
+	}
+
+	return user, true
+}
+
+// rejectLogin records a failed login, which may lock the account, and answers with the uniform error
+func (server *Server) rejectLogin(ctx *gin.Context, username string) {
+	metrics.LoginAttempt(metrics.LoginFailed)
+
+	err := server.store.RecordFailedLoginTx(ctx, db.RecordFailedLoginTxParams{
+		Username:        username,
+		ClientIP:        ctx.ClientIP(),
+		LockoutDuration: util.LockoutDuration,
+	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	// Create a new session in the database
+	ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+}
+
+// createLoginSession issues the access and refresh tokens of a fully authenticated user
+// and records the refresh token in a new session.
+func (server *Server) createLoginSession(ctx *gin.Context, user db.User) (loginUserResponse, error) {
+	// A successful login starts the consecutive failure count over
+	if user.FailedLoginAttempts > 0 {
+		_, err := server.store.ResetUserFailedLogins(ctx, user.Username)
+		if err != nil {
+			return loginUserResponse{}, err
+		}
+	}
+
+	// Create the refresh token
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		"",
+		server.config.RefreshTokenDuration,
+		token.TokenTypeRefreshToken,
+	)
+	if err != nil {
+		return loginUserResponse{}, err
+	}
+
+	// Create the access token, linked to the session of the refresh token
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		refreshPayload.RegisteredClaims.ID,
+		server.config.AccessTokenDuration,
+		token.TokenTypeAccessToken,
+	)
+	if err != nil {
+		return loginUserResponse{}, err
+	}
+
+	// Create a new session in the database, remembering the device it belongs to
 	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
 		ID:           refreshPayload.RegisteredClaims.ID,
+		FamilyID:     refreshPayload.RegisteredClaims.ID,
 		Username:     refreshPayload.Username,
 		RefreshToken: refreshToken,
+		UserAgent:    ctx.Request.UserAgent(),
+		ClientIp:     ctx.ClientIP(),
 		IsRevoked:    false,
 		ExpiresAt:    pgtype.Timestamptz{Time: refreshPayload.RegisteredClaims.ExpiresAt.Time, Valid: true},
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("unable to create session: %v", err),
-		})
-		return
+		return loginUserResponse{}, fmt.Errorf("unable to create session: %w", err)
 	}
 
-	ctx.JSON(http.StatusOK, loginUserResponse{
+	metrics.LoginAttempt(metrics.LoginSucceeded)
+	return loginUserResponse{
 		SessionID:             session.ID,
 		AccessToken:           accessToken,
 		RefreshToken:          refreshToken,
 		AccessTokenExpiresAt:  accessPayload.ExpiresAt.Time,
 		RefreshTokenExpiresAt: refreshPayload.ExpiresAt.Time,
 		User:                  newUserResponse(user),
-	})
+	}, nil
 }
 
 type logoutUserRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// logoutUser signs out the device the refresh token was issued to. The whole session
+// family is revoked, so an older token of the device logs out its current session too.
 func (server *Server) logoutUser(ctx *gin.Context) {
 	var req logoutUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -187,7 +307,7 @@ func (server *Server) logoutUser(ctx *gin.Context) {
 		return
 	}
 
-	refreshTokenPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+	refreshTokenPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken, token.TokenTypeRefreshToken)
 	if err != nil {
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
@@ -195,15 +315,20 @@ func (server *Server) logoutUser(ctx *gin.Context) {
 
 	session, err := server.store.GetSession(ctx, refreshTokenPayload.ID.String())
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("session not found")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	err = server.store.DeleteSession(ctx, session.ID)
+	err = server.store.RevokeSessionFamily(ctx, session.FamilyID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	server.revocationChecker.ForgetSession(session.FamilyID)
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "logged out",
@@ -215,10 +340,15 @@ type renewAccessTokenRequest struct {
 }
 
 type renewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	SessionID             string    `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	RefreshToken          string    `json:"refresh_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
+// renewAccessToken exchanges a refresh token for a new access token and a new refresh token.
+// The old refresh token stops working, and using it again revokes the whole session family.
 func (server *Server) renewAccessToken(ctx *gin.Context) {
 	var req renewAccessTokenRequest
 
@@ -229,45 +359,84 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 	}
 
 	// Verify the refresh token
-	payload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+	payload, err := server.tokenMaker.VerifyToken(req.RefreshToken, token.TokenTypeRefreshToken)
 	if err != nil {
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	// Get the session from the database
-	session, err := server.store.GetSession(ctx, payload.ID.String())
+	// Rotate the session, this fails if the refresh token was revoked or already used.
+	// The new tokens carry the role the user has now, not the one of the old token.
+	var refreshToken string
+	var refreshPayload *token.Payload
+	result, err := server.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		SessionID: payload.ID.String(),
+		Username:  payload.Username,
+		IssuedAt:  payload.IssuedAt.Time,
+		NewSession: func(user db.User) (db.CreateSessionParams, error) {
+			var err error
+			refreshToken, refreshPayload, err = server.tokenMaker.CreateToken(
+				user.Username,
+				user.Role,
+				"",
+				server.config.RefreshTokenDuration,
+				token.TokenTypeRefreshToken,
+			)
+			if err != nil {
+				return db.CreateSessionParams{}, err
+			}
+
+			return db.CreateSessionParams{
+				ID:           refreshPayload.RegisteredClaims.ID,
+				Username:     refreshPayload.Username,
+				RefreshToken: refreshToken,
+				UserAgent:    ctx.Request.UserAgent(),
+				ClientIp:     ctx.ClientIP(),
+				IsRevoked:    false,
+				ExpiresAt:    pgtype.Timestamptz{Time: refreshPayload.RegisteredClaims.ExpiresAt.Time, Valid: true},
+			}, nil
+		},
+	})
 	if err != nil {
+		if errors.Is(err, db.ErrRefreshTokenReused) {
+			// The access tokens of the stolen family must stop working right away
+			server.revocationChecker.ForgetSession(result.FamilyID)
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrSessionRevoked) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("session not found")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("unable to get session: %v", err),
-		})
-		return
-	}
-
-	// Check if the session is revoked
-	if session.IsRevoked {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "session has been revoked",
+			"error": fmt.Sprintf("unable to rotate session: %v", err),
 		})
 		return
 	}
 
-	// Check if the username in the session is the same as the username in the payload
-	if session.Username != payload.Username {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
-		return
-	}
-
 	// Create a new access token
-	accessToken, err := server.tokenMaker.CreateToken(session.Username, server.accessTokenDuration)
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
+		result.User.Username,
+		result.User.Role,
+		result.Session.FamilyID,
+		server.config.AccessTokenDuration,
+		token.TokenTypeAccessToken,
+	)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
 	ctx.JSON(http.StatusOK, renewAccessTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: payload.ExpiresAt.Time,
+		SessionID:             result.Session.ID,
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiresAt.Time,
+		RefreshTokenExpiresAt: refreshPayload.ExpiresAt.Time,
 	})
 }
 
@@ -284,10 +453,9 @@ func (server *Server) revokeSession(ctx *gin.Context) {
 		return
 	}
 
-	// Get the session from the database
-	session, err := server.store.GetSession(ctx, req.SessionID)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	// Get the session from the database, it has to belong to the authenticated user
+	session, ok := server.getOwnSession(ctx, req.SessionID)
+	if !ok {
 		return
 	}
 
@@ -299,12 +467,13 @@ func (server *Server) revokeSession(ctx *gin.Context) {
 		return
 	}
 
-	// Revoke the session
-	err = server.store.RevokeSession(ctx, req.SessionID)
+	// Revoke the session along with the refresh tokens it was rotated from
+	err := server.store.RevokeSessionFamily(ctx, session.FamilyID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	server.revocationChecker.ForgetSession(session.FamilyID)
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "session revoked",
@@ -0,0 +1,600 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// eqCreateUserParamsMatcher compares everything but the hashed password, which
+// is salted, and checks the hash against the plain password instead.
+type eqCreateUserParamsMatcher struct {
+	arg      db.CreateUserParams
+	password string
+}
+
+func (expected eqCreateUserParamsMatcher) Matches(x any) bool {
+	arg, ok := x.(db.CreateUserParams)
+	if !ok {
+		return false
+	}
+
+	if err := util.CheckPassword(arg.HashedPassword, expected.password); err != nil {
+		return false
+	}
+
+	expected.arg.HashedPassword = arg.HashedPassword
+	return expected.arg == arg
+}
+
+func (expected eqCreateUserParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v and password %v", expected.arg, expected.password)
+}
+
+func EqCreateUserParams(arg db.CreateUserParams, password string) gomock.Matcher {
+	return eqCreateUserParamsMatcher{arg, password}
+}
+
+func randomUser(t *testing.T) (db.User, string) {
+	password := util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	user := db.User{
+		Username:       util.RandomOwner(),
+		Role:           util.DepositorRole,
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+	return user, password
+}
+
+func TestCreateUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	validBody := map[string]any{
+		"username":  user.Username,
+		"password":  password,
+		"full_name": user.FullName,
+		"email":     user.Email,
+	}
+
+	testCases := []struct {
+		name          string
+		body          map[string]any
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateUserParams{
+					Username: user.Username,
+					FullName: user.FullName,
+					Email:    user.Email,
+				}
+				store.EXPECT().CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				requireBodyMatch(t, recorder.Body, newUserResponse(user))
+				require.NotContains(t, recorder.Body.String(), user.HashedPassword)
+			},
+		},
+		{
+			name: "DuplicateUsername",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.User{}, &pgconn.PgError{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: map[string]any{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     "not-an-email",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ShortPassword",
+			body: map[string]any{
+				"username":  user.Username,
+				"password":  "123",
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request := newJSONRequest(t, http.MethodPost, "/users", tc.body)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoginUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          map[string]any
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: map[string]any{"username": user.Username, "password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CountRecentFailedLoginsByIP(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "WrongPassword",
+			body: map[string]any{"username": user.Username, "password": "wrong-password"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CountRecentFailedLoginsByIP(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().RecordFailedLoginTx(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownUser",
+			body: map[string]any{"username": user.Username, "password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CountRecentFailedLoginsByIP(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, pgx.ErrNoRows)
+				store.EXPECT().GetFailedLoginsByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).
+					Return(db.GetFailedLoginsByUsernameRow{}, nil)
+				store.EXPECT().RecordFailedLoginTx(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "TooManyFailuresFromIP",
+			body: map[string]any{"username": user.Username, "password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CountRecentFailedLoginsByIP(gomock.Any(), gomock.Any()).Times(1).
+					Return(int64(util.MaxFailedLoginsPerIP), nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidUsername",
+			body: map[string]any{"username": "invalid-user#1", "password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CountRecentFailedLoginsByIP(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request := newJSONRequest(t, http.MethodPost, "/users/login", tc.body)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoginUserLockoutUnknownUser(t *testing.T) {
+	testCases := []struct {
+		name     string
+		failures int32
+		elapsed  time.Duration
+		code     int
+	}{
+		{name: "BelowLimit", failures: util.MaxFailedLoginsPerUser - 1, code: http.StatusUnauthorized},
+		{name: "Locked", failures: util.MaxFailedLoginsPerUser, elapsed: 30 * time.Second, code: http.StatusTooManyRequests},
+		{name: "LockExpired", failures: util.MaxFailedLoginsPerUser, elapsed: 90 * time.Second, code: http.StatusUnauthorized},
+		{name: "LockDoubled", failures: util.MaxFailedLoginsPerUser + 1, elapsed: 90 * time.Second, code: http.StatusTooManyRequests},
+		{name: "DoubledLockExpired", failures: util.MaxFailedLoginsPerUser + 1, elapsed: 3 * time.Minute, code: http.StatusUnauthorized},
+		{name: "LongLock", failures: util.MaxFailedLoginsPerUser + 7, elapsed: 2 * time.Hour, code: http.StatusTooManyRequests},
+	}
+
+	login := func(t *testing.T, username string, buildStubs func(store *mockdb.MockStore)) int {
+		store := mockdb.NewMockStore(gomock.NewController(t))
+		store.EXPECT().CountRecentFailedLoginsByIP(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+		buildStubs(store)
+
+		server := newTestServer(t, store)
+		recorder := httptest.NewRecorder()
+
+		request := newJSONRequest(t, http.MethodPost, "/users/login", map[string]any{"username": username, "password": "wrong-password"})
+		server.router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lastFailure := time.Now().Add(-tc.elapsed)
+			recorded := 0
+			if tc.code == http.StatusUnauthorized {
+				recorded = 1
+			}
+
+			// A real user locked by RecordFailedLoginTx at its last failure
+			user, _ := randomUser(t)
+			user.FailedLoginAttempts = tc.failures
+			if lockout := util.LockoutDuration(tc.failures); lockout > 0 {
+				user.LockedUntil = pgtype.Timestamptz{Time: lastFailure.Add(lockout), Valid: true}
+			}
+
+			realCode := login(t, user.Username, func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().RecordFailedLoginTx(gomock.Any(), gomock.Any()).Times(recorded).Return(nil)
+			})
+
+			// An unknown username with the same failures
+			username := util.RandomOwner()
+			unknownCode := login(t, username, func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(username)).Times(1).Return(db.User{}, pgx.ErrNoRows)
+				store.EXPECT().GetFailedLoginsByUsername(gomock.Any(), gomock.Eq(username)).Times(1).
+					Return(db.GetFailedLoginsByUsernameRow{
+						FailedAttempts: tc.failures,
+						LastFailedAt:   pgtype.Timestamptz{Time: lastFailure, Valid: true},
+					}, nil)
+				store.EXPECT().RecordFailedLoginTx(gomock.Any(), gomock.Any()).Times(recorded).Return(nil)
+			})
+
+			require.Equal(t, tc.code, realCode)
+			require.Equal(t, realCode, unknownCode)
+		})
+	}
+}
+
+func TestLoginUserIgnoresForwardedFor(t *testing.T) {
+	user, _ := randomUser(t)
+
+	store := mockdb.NewMockStore(gomock.NewController(t))
+	store.EXPECT().CountRecentFailedLoginsByIP(gomock.Any(), gomock.Any()).Times(1).
+		Do(func(_ any, arg db.CountRecentFailedLoginsByIPParams) {
+			require.Equal(t, "192.0.2.1", arg.ClientIp)
+		}).
+		Return(int64(0), nil)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().RecordFailedLoginTx(gomock.Any(), gomock.Any()).Times(1).
+		Do(func(_ any, arg db.RecordFailedLoginTxParams) {
+			require.Equal(t, "192.0.2.1", arg.ClientIP)
+		}).
+		Return(nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	// No proxy is trusted, so a client cannot pick the IP its failures count against
+	request := newJSONRequest(t, http.MethodPost, "/users/login", map[string]any{"username": user.Username, "password": "wrong-password"})
+	request.RemoteAddr = "192.0.2.1:1234"
+	request.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestRenewAccessTokenAfterRoleChange(t *testing.T) {
+	user, _ := randomUser(t)
+
+	store := mockdb.NewMockStore(gomock.NewController(t))
+	server := newTestServer(t, store)
+
+	// The refresh token was issued while the user was still an admin
+	refreshToken, _, err := server.tokenMaker.CreateToken(user.Username, util.AdminRole, "", time.Hour, token.TokenTypeRefreshToken)
+	require.NoError(t, err)
+
+	demoted := user
+	demoted.Role = util.DepositorRole
+	store.EXPECT().RotateSessionTx(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ any, arg db.RotateSessionTxParams) (db.RotateSessionTxResult, error) {
+			newSession, err := arg.NewSession(demoted)
+			require.NoError(t, err)
+
+			session := db.Session{ID: newSession.ID, FamilyID: util.RandomString(16)}
+			return db.RotateSessionTxResult{Session: session, User: demoted}, nil
+		})
+
+	recorder := httptest.NewRecorder()
+	request := newJSONRequest(t, http.MethodPost, "/users/token/refresh", map[string]any{"refresh_token": refreshToken})
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp renewAccessTokenResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+
+	accessPayload, err := server.tokenMaker.VerifyToken(rsp.AccessToken, token.TokenTypeAccessToken)
+	require.NoError(t, err)
+	require.Equal(t, util.DepositorRole, accessPayload.Role)
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(rsp.RefreshToken, token.TokenTypeRefreshToken)
+	require.NoError(t, err)
+	require.Equal(t, util.DepositorRole, refreshPayload.Role)
+}
+
+func TestRenewAccessTokenReuseRevokesAccessTokens(t *testing.T) {
+	user, _ := randomUser(t)
+	familyID := util.RandomString(16)
+
+	store := mockdb.NewMockStore(gomock.NewController(t))
+	server := newTestServer(t, store)
+
+	accessToken, _, err := server.tokenMaker.CreateToken(user.Username, user.Role, familyID, time.Minute, token.TokenTypeAccessToken)
+	require.NoError(t, err)
+	refreshToken, _, err := server.tokenMaker.CreateToken(user.Username, user.Role, "", time.Hour, token.TokenTypeRefreshToken)
+	require.NoError(t, err)
+
+	// The family is active until the reused refresh token revokes it
+	stateArg := db.GetSessionFamilyStateParams{FamilyID: familyID, Username: user.Username}
+	gomock.InOrder(
+		store.EXPECT().GetSessionFamilyState(gomock.Any(), gomock.Eq(stateArg)).Times(1).
+			Return(db.GetSessionFamilyStateRow{SessionActive: true}, nil),
+		store.EXPECT().GetSessionFamilyState(gomock.Any(), gomock.Eq(stateArg)).Times(1).
+			Return(db.GetSessionFamilyStateRow{SessionActive: false}, nil),
+	)
+	store.EXPECT().ListActiveSessions(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return([]db.Session{}, nil)
+	store.EXPECT().RotateSessionTx(gomock.Any(), gomock.Any()).Times(1).
+		Return(db.RotateSessionTxResult{FamilyID: familyID}, db.ErrRefreshTokenReused)
+
+	listSessions := func() int {
+		recorder := httptest.NewRecorder()
+		request := newJSONRequest(t, http.MethodGet, "/users/sessions", nil)
+		request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+		server.router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	// The first request caches the family as active
+	require.Equal(t, http.StatusOK, listSessions())
+
+	recorder := httptest.NewRecorder()
+	request := newJSONRequest(t, http.MethodPost, "/users/token/refresh", map[string]any{"refresh_token": refreshToken})
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// The cached state is gone, so the access token is rejected before the cache expires
+	require.Equal(t, http.StatusUnauthorized, listSessions())
+}
+
+func TestRevokeSessionAPI(t *testing.T) {
+	owner := util.RandomOwner()
+	session := randomSession(owner)
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		forgotten     bool
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).Times(1).Return(nil)
+			},
+			forgotten: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "OtherUsersSession",
+			username: util.RandomOwner(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "AlreadyRevoked",
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				revoked := session
+				revoked.IsRevoked = true
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(revoked, nil)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(db.Session{}, pgx.ErrNoRows)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).Times(1).Return(pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			forgotten := cacheSessionState(t, server.revocationChecker, store, owner, session.FamilyID)
+			recorder := httptest.NewRecorder()
+
+			request := newJSONRequest(t, http.MethodPost, "/users/revoke", map[string]any{"session_id": session.ID})
+			addAuthorization(t, request, store, server.tokenMaker, tc.username, util.DepositorRole)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+			require.Equal(t, tc.forgotten, forgotten())
+		})
+	}
+}
+
+func TestLogoutUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore, session db.Session)
+		forgotten     bool
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).Times(1).Return(nil)
+			},
+			forgotten: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "RotatedToken",
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				// The token was exchanged already, the live session of the family goes too
+				rotated := session
+				rotated.IsRevoked = true
+				rotated.ReplacedBy = pgtype.Text{String: util.RandomString(16), Valid: true}
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(rotated, nil)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).Times(1).Return(nil)
+			},
+			forgotten: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(db.Session{}, pgx.ErrNoRows)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).Times(1).Return(pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			server := newTestServer(t, store)
+
+			refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, "", time.Hour, token.TokenTypeRefreshToken)
+			require.NoError(t, err)
+
+			session := randomSession(user.Username)
+			session.ID = refreshPayload.ID.String()
+			tc.buildStubs(store, session)
+
+			forgotten := cacheSessionState(t, server.revocationChecker, store, user.Username, session.FamilyID)
+			recorder := httptest.NewRecorder()
+
+			request := newJSONRequest(t, http.MethodPost, "/users/logout", map[string]any{"refresh_token": refreshToken})
+			addAuthorization(t, request, store, server.tokenMaker, user.Username, user.Role)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+			require.Equal(t, tc.forgotten, forgotten())
+		})
+	}
+}
@@ -1,13 +1,19 @@
 package api
 
 import (
-	"github.com/Aadityaa2606/Bank-API/util"
+	"context"
+
+	"github.com/Aadityaa2606/Bank-API/currency"
 	"github.com/go-playground/validator/v10"
 )
 
-var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
-	if currency, ok := fieldLevel.Field().Interface().(string); ok {
-		return util.IsSupportedCurrency(currency)
+// validCurrency accepts the enabled currencies of the registry. Validators get no
+// request context, which only matters when the cached currencies have to be reloaded.
+func validCurrency(currencies *currency.Registry) validator.Func {
+	return func(fieldLevel validator.FieldLevel) bool {
+		if code, ok := fieldLevel.Field().Interface().(string); ok {
+			return currencies.Validate(context.Background(), code) == nil
+		}
+		return false
 	}
-	return false
 }
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/jackc/pgx/v5"
+)
+
+type accountResult struct {
+	ID        int64     `json:"id"`
+	Owner     string    `json:"owner"`
+	Currency  string    `json:"currency"`
+	Product   string    `json:"product"`
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newAccountResult(account db.Account) accountResult {
+	return accountResult{
+		ID:        account.ID,
+		Owner:     account.Owner,
+		Currency:  account.Currency,
+		Product:   account.Product,
+		Balance:   account.Balance,
+		CreatedAt: account.CreatedAt.Time,
+	}
+}
+
+func (account accountResult) table() table {
+	return table{
+		headers: []string{"ID", "OWNER", "CURRENCY", "PRODUCT", "BALANCE", "CREATED AT"},
+		rows: [][]string{{
+			strconv.FormatInt(account.ID, 10),
+			account.Owner,
+			account.Currency,
+			account.Product,
+			strconv.FormatInt(account.Balance, 10),
+			account.CreatedAt.Format(time.RFC3339),
+		}},
+	}
+}
+
+func runAccountCreate(ctx context.Context, store db.Store, args []string) error {
+	flags := newCommandFlags("account create")
+	owner := flags.String("owner", "", "username of the owner")
+	currency := flags.String("currency", "", "currency of the account")
+	product := flags.String("product", db.DefaultAccountProduct, "product of the account, e.g. checking or savings")
+
+	out, err := flags.parse(args)
+	if err != nil {
+		return err
+	}
+	if err := requireFlags(flags, "owner", "currency"); err != nil {
+		return err
+	}
+
+	// Read straight from the table, bankctl runs too briefly to need the cached registry
+	accountCurrency, err := store.GetCurrency(ctx, *currency)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("unknown currency %q", *currency)
+		}
+		return fmt.Errorf("cannot get currency: %w", err)
+	}
+	if !accountCurrency.Enabled {
+		return fmt.Errorf("currency %q is disabled", *currency)
+	}
+
+	// Accounts start empty, money only comes in through the ledger
+	account, err := store.CreateAccountTx(ctx, db.CreateAccountTxParams{
+		Owner:    *owner,
+		Currency: *currency,
+		Product:  *product,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create account: %w", err)
+	}
+
+	result := newAccountResult(account)
+	return out.print(result, result.table())
+}
+
+type adjustmentResult struct {
+	ID        int64  `json:"id"`
+	AccountID int64  `json:"account_id"`
+	EntryID   int64  `json:"entry_id"`
+	Amount    int64  `json:"amount"`
+	Balance   int64  `json:"balance"`
+	Reason    string `json:"reason"`
+	Operator  string `json:"operator"`
+}
+
+func runAccountAdjust(ctx context.Context, store db.Store, args []string) error {
+	flags := newCommandFlags("account adjust")
+	accountID := flags.Int64("id", 0, "account to adjust")
+	amount := flags.Int64("amount", 0, "amount to add, negative to remove money")
+	reason := flags.String("reason", "", "why the adjustment is needed, kept with it")
+	operator := flags.String("operator", os.Getenv("USER"), "who makes the adjustment")
+
+	out, err := flags.parse(args)
+	if err != nil {
+		return err
+	}
+	if err := requireFlags(flags, "id", "amount", "reason", "operator"); err != nil {
+		return err
+	}
+
+	txResult, err := store.AdjustBalanceTx(ctx, db.AdjustBalanceTxParams{
+		AccountID: *accountID,
+		Amount:    *amount,
+		Reason:    *reason,
+		Operator:  *operator,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot adjust account %d: %w", *accountID, err)
+	}
+
+	result := adjustmentResult{
+		ID:        txResult.Adjustment.ID,
+		AccountID: txResult.Account.ID,
+		EntryID:   txResult.Entry.ID,
+		Amount:    txResult.Adjustment.Amount,
+		Balance:   txResult.Account.Balance,
+		Reason:    txResult.Adjustment.Reason,
+		Operator:  txResult.Adjustment.Operator,
+	}
+
+	return out.print(result, table{
+		headers: []string{"ADJUSTMENT", "ACCOUNT", "ENTRY", "AMOUNT", "BALANCE", "REASON", "OPERATOR"},
+		rows: [][]string{{
+			strconv.FormatInt(result.ID, 10),
+			strconv.FormatInt(result.AccountID, 10),
+			strconv.FormatInt(result.EntryID, 10),
+			strconv.FormatInt(result.Amount, 10),
+			strconv.FormatInt(result.Balance, 10),
+			result.Reason,
+			result.Operator,
+		}},
+	})
+}
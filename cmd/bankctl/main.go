@@ -0,0 +1,113 @@
+// Command bankctl runs administrative tasks directly against the database,
+// through the same store as the servers so every change follows their rules.
+//
+// It reads the configuration like the server, so only DB_SOURCE is needed:
+//
+//	bankctl [config flags] <command> [flags]
+//
+// Run bankctl without a command to list them, and a command with -h for its flags.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, store db.Store, args []string) error
+}
+
+var commands = []command{
+	{name: "user create", usage: "create a user, optionally with the admin role", run: runUserCreate},
+	{name: "account create", usage: "open an empty account for a user", run: runAccountCreate},
+	{name: "account adjust", usage: "post a balance adjustment through the ledger", run: runAccountAdjust},
+	{name: "session revoke", usage: "revoke one session family or every session of a user", run: runSessionRevoke},
+	{name: "products post", usage: "post the monthly interest and fees of account products", run: runProductsPost},
+	{name: "reconcile", usage: "list accounts whose balance differs from their ledger entries", run: runReconcile},
+	{name: "seed", usage: "fill the database with random users, accounts and transfers", run: runSeed},
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "bankctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	config, err := util.LoadConfig(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	cmd, cmdArgs, found := findCommand(config.Args)
+	if !found {
+		printUsage(os.Stderr)
+		if len(config.Args) == 0 {
+			return errors.New("no command given")
+		}
+		return fmt.Errorf("unknown command %q", strings.Join(config.Args, " "))
+	}
+
+	if err := config.ValidateDatabase(); err != nil {
+		return err
+	}
+	txConfig, err := db.NewTxConfig(config)
+	if err != nil {
+		return fmt.Errorf("invalid TX_ISOLATION_LEVELS: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := pgxpool.New(ctx, config.DBSource)
+	if err != nil {
+		return fmt.Errorf("cannot connect to db: %w", err)
+	}
+	defer pool.Close()
+
+	err = cmd.run(ctx, db.NewStore(pool, txConfig), cmdArgs)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+// findCommand matches the leading words of args against the command names
+func findCommand(args []string) (command, []string, bool) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) < len(words) {
+			continue
+		}
+		if strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], true
+		}
+	}
+	return command{}, nil, false
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: bankctl [config flags] <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.usage)
+	}
+}
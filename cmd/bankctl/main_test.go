@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCommand(t *testing.T) {
+	cmd, args, found := findCommand([]string{"account", "adjust", "-id", "1"})
+	require.True(t, found)
+	require.Equal(t, "account adjust", cmd.name)
+	require.Equal(t, []string{"-id", "1"}, args)
+
+	cmd, args, found = findCommand([]string{"reconcile"})
+	require.True(t, found)
+	require.Equal(t, "reconcile", cmd.name)
+	require.Empty(t, args)
+
+	_, _, found = findCommand([]string{"account"})
+	require.False(t, found)
+
+	_, _, found = findCommand(nil)
+	require.False(t, found)
+}
+
+func TestPrinter(t *testing.T) {
+	value := revokeResult{Username: "alice", FamilyID: "family"}
+	result := table{
+		headers: []string{"USERNAME", "REVOKED FAMILY"},
+		rows:    [][]string{{"alice", "family"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printer{format: formatTable, w: &buf}.print(value, result))
+	require.Equal(t, "USERNAME  REVOKED FAMILY\nalice     family\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, printer{format: formatJSON, w: &buf}.print(value, result))
+	require.JSONEq(t, `{"username": "alice", "family_id": "family"}`, buf.String())
+}
+
+func TestCommandFlags(t *testing.T) {
+	flags := newCommandFlags("account create")
+	owner := flags.String("owner", "", "")
+	flags.String("currency", "", "")
+
+	out, err := flags.parse([]string{"-owner", "alice", "-output", "json"})
+	require.NoError(t, err)
+	require.Equal(t, formatJSON, out.format)
+	require.Equal(t, "alice", *owner)
+	require.EqualError(t, requireFlags(flags, "owner", "currency"), "missing required flags: -currency")
+
+	_, err = newCommandFlags("reconcile").parse([]string{"-output", "xml"})
+	require.ErrorContains(t, err, `unknown output format "xml"`)
+
+	_, err = newCommandFlags("reconcile").parse([]string{"extra"})
+	require.ErrorContains(t, err, "unexpected arguments")
+}
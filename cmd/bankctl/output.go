@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// printer writes command results either as an aligned table for people
+// or as JSON for scripts
+type printer struct {
+	format string
+	w      io.Writer
+}
+
+// table is the human readable form of a result
+type table struct {
+	headers []string
+	rows    [][]string
+}
+
+func (p printer) print(value any, t table) error {
+	if p.format == formatJSON {
+		encoder := json.NewEncoder(p.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	writer := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(t.headers, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
+
+// commandFlags are the flags of a command, plus -output which every command has
+type commandFlags struct {
+	*flag.FlagSet
+	output *string
+}
+
+func newCommandFlags(name string) commandFlags {
+	flagSet := flag.NewFlagSet("bankctl "+name, flag.ContinueOnError)
+	output := flagSet.String("output", formatTable, "output format, table or json")
+	return commandFlags{FlagSet: flagSet, output: output}
+}
+
+// parse reads the flags and returns the printer for the chosen output format
+func (flags commandFlags) parse(args []string) (printer, error) {
+	if err := flags.Parse(args); err != nil {
+		return printer{}, err
+	}
+
+	if flags.NArg() > 0 {
+		return printer{}, fmt.Errorf("unexpected arguments %q", flags.Args())
+	}
+
+	if *flags.output != formatTable && *flags.output != formatJSON {
+		return printer{}, fmt.Errorf("unknown output format %q, use %s or %s", *flags.output, formatTable, formatJSON)
+	}
+
+	return printer{format: *flags.output, w: os.Stdout}, nil
+}
+
+// requireFlags reports the required flags left empty
+func requireFlags(flags commandFlags, names ...string) error {
+	var missing []string
+	for _, name := range names {
+		if f := flags.Lookup(name); f != nil && (f.Value.String() == "" || f.Value.String() == "0") {
+			missing = append(missing, "-"+name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required flags: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// postingPageSize is how many accounts are listed at a time
+const postingPageSize = 100
+
+type postingResult struct {
+	AccountID int64  `json:"account_id"`
+	Product   string `json:"product"`
+	Interest  int64  `json:"interest"`
+	Fee       int64  `json:"fee"`
+	Balance   int64  `json:"balance"`
+}
+
+// runProductsPost posts the interest and fees of the account products for a month.
+// Accounts already posted for the month are skipped, so a run that failed halfway
+// can simply be started again.
+func runProductsPost(ctx context.Context, store db.Store, args []string) error {
+	flags := newCommandFlags("products post")
+	month := flags.String("month", time.Now().UTC().AddDate(0, -1, 0).Format("2006-01"), "month to post for as YYYY-MM, the previous one by default")
+	operator := flags.String("operator", os.Getenv("USER"), "who posts, kept with the balance adjustments")
+
+	out, err := flags.parse(args)
+	if err != nil {
+		return err
+	}
+	if err := requireFlags(flags, "month", "operator"); err != nil {
+		return err
+	}
+
+	start, err := time.Parse("2006-01", *month)
+	if err != nil {
+		return fmt.Errorf("invalid -month %q, use YYYY-MM", *month)
+	}
+
+	results, err := postProductPlans(ctx, store, start, *operator)
+	if err != nil {
+		return err
+	}
+
+	t := table{headers: []string{"ACCOUNT", "PRODUCT", "INTEREST", "FEE", "BALANCE"}}
+	for _, result := range results {
+		t.rows = append(t.rows, []string{
+			strconv.FormatInt(result.AccountID, 10),
+			result.Product,
+			strconv.FormatInt(result.Interest, 10),
+			strconv.FormatInt(result.Fee, 10),
+			strconv.FormatInt(result.Balance, 10),
+		})
+	}
+	return out.print(results, t)
+}
+
+// postProductPlans posts the month starting at start for every account opened
+// before it ended whose product has interest or a fee
+func postProductPlans(ctx context.Context, store db.Store, start time.Time, operator string) ([]postingResult, error) {
+	results := []postingResult{}
+	arg := db.ListAccountsWithProductPlansParams{
+		OpenedBefore: pgtype.Timestamptz{Time: start.AddDate(0, 1, 0), Valid: true},
+		Limit:        postingPageSize,
+	}
+
+	for {
+		accounts, err := store.ListAccountsWithProductPlans(ctx, arg)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list accounts: %w", err)
+		}
+
+		for _, account := range accounts {
+			txResult, err := store.PostProductPlanTx(ctx, db.PostProductPlanTxParams{
+				AccountID: account.ID,
+				Month:     start,
+				Operator:  operator,
+			})
+			if errors.Is(err, db.ErrProductPlanPosted) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("cannot post account %d: %w", account.ID, err)
+			}
+
+			results = append(results, postingResult{
+				AccountID: account.ID,
+				Product:   account.Product,
+				Interest:  txResult.Posting.Interest,
+				Fee:       txResult.Posting.Fee,
+				Balance:   txResult.Account.Balance,
+			})
+		}
+
+		if len(accounts) < postingPageSize {
+			return results, nil
+		}
+		arg.AfterID = accounts[len(accounts)-1].ID
+	}
+}
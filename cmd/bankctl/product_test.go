@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostProductPlans(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+
+	_, err := store.CreateAccountProduct(ctx, db.CreateAccountProductParams{
+		Code:              "premium",
+		Name:              "Premium",
+		AllowedCurrencies: []string{},
+		InterestRateBps:   1200,
+		MonthlyFee:        30,
+	})
+	require.NoError(t, err)
+
+	user, err := store.CreateUser(ctx, db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "hash",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	balances := map[string]int64{"premium": 10000, "savings": 120000, db.DefaultAccountProduct: 5000}
+	accounts := make(map[string]db.Account)
+	for product, balance := range balances {
+		account, err := store.CreateAccountTx(ctx, db.CreateAccountTxParams{Owner: user.Username, Currency: util.USD, Product: product})
+		require.NoError(t, err)
+		_, err = store.AdjustBalanceTx(ctx, db.AdjustBalanceTxParams{AccountID: account.ID, Amount: balance, Reason: "opening deposit", Operator: "test"})
+		require.NoError(t, err)
+		accounts[product] = account
+	}
+
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	results, err := postProductPlans(ctx, store, month, "test")
+	require.NoError(t, err)
+
+	// The checking account has no plan, savings earns 1.5% a year
+	require.ElementsMatch(t, []postingResult{
+		{AccountID: accounts["premium"].ID, Product: "premium", Interest: 100, Fee: 30, Balance: 10070},
+		{AccountID: accounts["savings"].ID, Product: "savings", Interest: 150, Fee: 0, Balance: 120150},
+	}, results)
+
+	// Posting is recorded through the ledger, so the accounts stay reconciled
+	unreconciled, err := store.ListUnreconciledAccounts(ctx)
+	require.NoError(t, err)
+	require.Empty(t, unreconciled)
+
+	// A second run finds nothing left to post for the month
+	results, err = postProductPlans(ctx, store, month, "test")
+	require.NoError(t, err)
+	require.Empty(t, results)
+
+	// Accounts opened after a month are not posted for it
+	results, err = postProductPlans(ctx, store, month.AddDate(0, -1, 0), "test")
+	require.NoError(t, err)
+	require.Empty(t, results)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+)
+
+var errUnreconciled = errors.New("some accounts do not match their ledger entries")
+
+// runReconcile lists the accounts whose balance is not the sum of their entries
+// and fails when there is any, so it can run from cron or CI
+func runReconcile(ctx context.Context, store db.Store, args []string) error {
+	flags := newCommandFlags("reconcile")
+
+	out, err := flags.parse(args)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := store.ListUnreconciledAccounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{headers: []string{"ACCOUNT", "OWNER", "CURRENCY", "BALANCE", "ENTRIES", "DIFFERENCE"}}
+	for _, account := range accounts {
+		t.rows = append(t.rows, []string{
+			strconv.FormatInt(account.ID, 10),
+			account.Owner,
+			account.Currency,
+			strconv.FormatInt(account.Balance, 10),
+			strconv.FormatInt(account.EntriesTotal, 10),
+			strconv.FormatInt(account.Balance-account.EntriesTotal, 10),
+		})
+	}
+
+	if err := out.print(accounts, t); err != nil {
+		return err
+	}
+
+	if len(accounts) > 0 {
+		return errUnreconciled
+	}
+	return nil
+}
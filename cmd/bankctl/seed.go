@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/util"
+)
+
+const seedOperator = "bankctl seed"
+
+type seedResult struct {
+	Users     []string `json:"users"`
+	Accounts  []int64  `json:"accounts"`
+	Transfers int      `json:"transfers"`
+	Password  string   `json:"password"`
+}
+
+// runSeed creates users with a few funded accounts each, then moves money
+// between accounts of the same currency. Everything goes through the store
+// transactions, so the seeded data reconciles.
+func runSeed(ctx context.Context, store db.Store, args []string) error {
+	flags := newCommandFlags("seed")
+	users := flags.Int("users", 10, "users to create")
+	accountsPerUser := flags.Int("accounts", 2, "accounts per user")
+	transfers := flags.Int("transfers", 50, "transfers to attempt between the new accounts")
+	password := flags.String("password", "secret", "password of every seeded user")
+
+	out, err := flags.parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *users < 1 || *accountsPerUser < 0 || *transfers < 0 {
+		return errors.New("-users must be positive, -accounts and -transfers cannot be negative")
+	}
+
+	// Hashing is slow on purpose, every user gets the same hash
+	hashedPassword, err := util.HashPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	result := seedResult{Password: *password}
+	accountsByCurrency := map[string][]int64{}
+
+	for i := 0; i < *users; i++ {
+		user, err := store.CreateUser(ctx, db.CreateUserParams{
+			Username:       util.RandomOwner(),
+			HashedPassword: hashedPassword,
+			FullName:       util.RandomOwner() + " " + util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		})
+		if err != nil {
+			return fmt.Errorf("cannot create user: %w", err)
+		}
+		result.Users = append(result.Users, user.Username)
+
+		for j := 0; j < *accountsPerUser; j++ {
+			account, err := store.CreateAccountTx(ctx, db.CreateAccountTxParams{
+				Owner:    user.Username,
+				Currency: util.RandomCurrency(),
+				Product:  db.DefaultAccountProduct,
+			})
+			if err != nil {
+				return fmt.Errorf("cannot create account: %w", err)
+			}
+
+			_, err = store.AdjustBalanceTx(ctx, db.AdjustBalanceTxParams{
+				AccountID: account.ID,
+				Amount:    util.RandomInt(100, 10000),
+				Reason:    "seed data",
+				Operator:  seedOperator,
+			})
+			if err != nil {
+				return fmt.Errorf("cannot fund account %d: %w", account.ID, err)
+			}
+
+			result.Accounts = append(result.Accounts, account.ID)
+			accountsByCurrency[account.Currency] = append(accountsByCurrency[account.Currency], account.ID)
+		}
+	}
+
+	var currencies []string
+	for currency, accounts := range accountsByCurrency {
+		if len(accounts) > 1 {
+			currencies = append(currencies, currency)
+		}
+	}
+
+	for i := 0; i < *transfers && len(currencies) > 0; i++ {
+		accounts := accountsByCurrency[currencies[rand.Intn(len(currencies))]]
+		from := rand.Intn(len(accounts))
+		to := (from + 1 + rand.Intn(len(accounts)-1)) % len(accounts)
+
+		_, err := store.TransferTx(ctx, db.TransferTxParams{
+			FromAccountID: accounts[from],
+			ToAccountID:   accounts[to],
+			Amount:        util.RandomMoney() + 1,
+		})
+		if errors.Is(err, db.ErrInsufficientBalance) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("cannot create transfer: %w", err)
+		}
+		result.Transfers++
+	}
+
+	return out.print(result, table{
+		headers: []string{"USERS", "ACCOUNTS", "TRANSFERS", "PASSWORD"},
+		rows: [][]string{{
+			strconv.Itoa(len(result.Users)),
+			strconv.Itoa(len(result.Accounts)),
+			strconv.Itoa(result.Transfers),
+			result.Password,
+		}},
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+)
+
+type revokeResult struct {
+	Username string `json:"username"`
+	FamilyID string `json:"family_id,omitempty"`
+}
+
+// runSessionRevoke revokes refresh tokens in the database. Servers cache session
+// checks for REVOCATION_CACHE_TTL, so access tokens may keep working that long.
+func runSessionRevoke(ctx context.Context, store db.Store, args []string) error {
+	flags := newCommandFlags("session revoke")
+	username := flags.String("username", "", "revoke every session of this user")
+	sessionID := flags.String("session", "", "revoke the session family of this session ID")
+
+	out, err := flags.parse(args)
+	if err != nil {
+		return err
+	}
+
+	if (*username == "") == (*sessionID == "") {
+		return errors.New("either -username or -session is required")
+	}
+
+	var result revokeResult
+	if *username != "" {
+		if _, err := store.GetUser(ctx, *username); err != nil {
+			return fmt.Errorf("cannot find user %s: %w", *username, err)
+		}
+		if err := store.RevokeUserSessions(ctx, *username); err != nil {
+			return fmt.Errorf("cannot revoke sessions: %w", err)
+		}
+		result.Username = *username
+	} else {
+		session, err := store.GetSession(ctx, *sessionID)
+		if err != nil {
+			return fmt.Errorf("cannot find session %s: %w", *sessionID, err)
+		}
+		if err := store.RevokeSessionFamily(ctx, session.FamilyID); err != nil {
+			return fmt.Errorf("cannot revoke session: %w", err)
+		}
+		result.Username = session.Username
+		result.FamilyID = session.FamilyID
+	}
+
+	familyID := result.FamilyID
+	if familyID == "" {
+		familyID = "all"
+	}
+	return out.print(result, table{
+		headers: []string{"USERNAME", "REVOKED FAMILY"},
+		rows:    [][]string{{result.Username, familyID}},
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/util"
+)
+
+type userResult struct {
+	Username  string    `json:"username"`
+	FullName  string    `json:"full_name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newUserResult(user db.User) userResult {
+	return userResult{
+		Username:  user.Username,
+		FullName:  user.FullName,
+		Email:     user.Email,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt.Time,
+	}
+}
+
+func (user userResult) table() table {
+	return table{
+		headers: []string{"USERNAME", "FULL NAME", "EMAIL", "ROLE", "CREATED AT"},
+		rows:    [][]string{{user.Username, user.FullName, user.Email, user.Role, user.CreatedAt.Format(time.RFC3339)}},
+	}
+}
+
+func runUserCreate(ctx context.Context, store db.Store, args []string) error {
+	flags := newCommandFlags("user create")
+	username := flags.String("username", "", "username, letters and digits only")
+	password := flags.String("password", "", "initial password, at least 6 characters")
+	fullName := flags.String("full-name", "", "full name")
+	email := flags.String("email", "", "email address")
+	role := flags.String("role", util.DepositorRole, "depositor or admin")
+
+	out, err := flags.parse(args)
+	if err != nil {
+		return err
+	}
+	if err := requireFlags(flags, "username", "password", "full-name", "email"); err != nil {
+		return err
+	}
+
+	if *role != util.DepositorRole && *role != util.AdminRole {
+		return fmt.Errorf("unknown role %q, use %s or %s", *role, util.DepositorRole, util.AdminRole)
+	}
+	if len(*password) < 6 {
+		return fmt.Errorf("password must be at least 6 characters")
+	}
+
+	hashedPassword, err := util.HashPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	user, err := store.CreateUser(ctx, db.CreateUserParams{
+		Username:       *username,
+		HashedPassword: hashedPassword,
+		FullName:       *fullName,
+		Email:          *email,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create user: %w", err)
+	}
+
+	// New users are depositors, the database default
+	if *role != user.Role {
+		user, err = store.UpdateUserRole(ctx, db.UpdateUserRoleParams{
+			Username: user.Username,
+			Role:     *role,
+		})
+		if err != nil {
+			return fmt.Errorf("cannot set role of user %s: %w", *username, err)
+		}
+	}
+
+	result := newUserResult(user)
+	return out.print(result, result.table())
+}
@@ -0,0 +1,175 @@
+// Package currency tells which currencies accounts and transfers may use.
+// The currencies table is the source of truth. It changes rarely and is read on
+// every request that names a currency, so the registry keeps a copy in memory.
+package currency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyDisabled = errors.New("currency is disabled")
+)
+
+// currencyLister is the part of the store the registry reads from
+type currencyLister interface {
+	ListCurrencies(ctx context.Context) ([]db.Currency, error)
+}
+
+// Registry caches the currencies table for ttl. Other instances disabling a
+// currency are noticed within ttl, changes made through this instance at once.
+type Registry struct {
+	store currencyLister
+	ttl   time.Duration
+	loads singleflight.Group
+
+	mu         sync.Mutex
+	currencies []db.Currency
+	byCode     map[string]db.Currency
+	expiresAt  time.Time
+	// generation counts the calls to Forget, loadedGeneration is the one the
+	// cached currencies were read in
+	generation       uint64
+	loadedGeneration uint64
+}
+
+func NewRegistry(store currencyLister, ttl time.Duration) *Registry {
+	return &Registry{
+		store: store,
+		ttl:   ttl,
+	}
+}
+
+// Get returns a currency by its ISO 4217 code, enabled or not
+func (registry *Registry) Get(ctx context.Context, code string) (db.Currency, error) {
+	if err := registry.load(ctx); err != nil {
+		return db.Currency{}, err
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	currency, ok := registry.byCode[code]
+	if !ok {
+		return db.Currency{}, fmt.Errorf("%w %q", ErrUnknownCurrency, code)
+	}
+	return currency, nil
+}
+
+// Validate returns an error unless new accounts and transfers may use the currency
+func (registry *Registry) Validate(ctx context.Context, code string) error {
+	currency, err := registry.Get(ctx, code)
+	if err != nil {
+		return err
+	}
+	if !currency.Enabled {
+		return fmt.Errorf("%w %q", ErrCurrencyDisabled, code)
+	}
+	return nil
+}
+
+// List returns every currency ordered by code
+func (registry *Registry) List(ctx context.Context) ([]db.Currency, error) {
+	if err := registry.load(ctx); err != nil {
+		return nil, err
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	return registry.currencies, nil
+}
+
+// Forget drops the cached currencies, to be called after changing one
+func (registry *Registry) Forget() {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.generation++
+	registry.expiresAt = time.Time{}
+}
+
+// load reads the currencies table once the cache has expired. Concurrent callers
+// share one query, which runs without holding the lock, and each of them stops
+// waiting for it when its own context is done.
+func (registry *Registry) load(ctx context.Context) error {
+	registry.mu.Lock()
+	fresh := time.Now().Before(registry.expiresAt)
+	generation := registry.generation
+	registry.mu.Unlock()
+
+	if fresh {
+		return nil
+	}
+
+	// Loads started before a Forget are not joined, they may have read the old table
+	result := registry.loads.DoChan(strconv.FormatUint(generation, 10), func() (any, error) {
+		return nil, registry.reload(context.WithoutCancel(ctx), generation)
+	})
+
+	select {
+	case res := <-result:
+		return res.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reload swaps in the currencies table. When reading it fails the previous copy
+// is kept, so a database hiccup does not reject every currency.
+func (registry *Registry) reload(ctx context.Context, generation uint64) error {
+	currencies, err := registry.store.ListCurrencies(ctx)
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if err != nil {
+		if registry.byCode == nil {
+			return fmt.Errorf("cannot load currencies: %w", err)
+		}
+		log.Warn().
+			Err(err).
+			Str("request_id", util.RequestIDFromContext(ctx)).
+			Msg("cannot reload currencies, keeping the cached ones")
+		registry.renew(generation)
+		return nil
+	}
+
+	// A load that started later has already swapped in a newer copy
+	if generation < registry.loadedGeneration {
+		return nil
+	}
+
+	byCode := make(map[string]db.Currency, len(currencies))
+	exponents := make(map[string]int, len(currencies))
+	for _, currency := range currencies {
+		byCode[currency.Code] = currency
+		exponents[currency.Code] = int(currency.Exponent)
+	}
+	util.SetCurrencyExponents(exponents)
+
+	registry.currencies = currencies
+	registry.byCode = byCode
+	registry.loadedGeneration = generation
+	registry.renew(generation)
+	return nil
+}
+
+// renew keeps the cache for another ttl, unless Forget was called since the load
+// of the given generation started
+func (registry *Registry) renew(generation uint64) {
+	if generation == registry.generation {
+		registry.expiresAt = time.Now().Add(registry.ttl)
+	}
+}
@@ -0,0 +1,181 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	calls      int
+	err        error
+	currencies []db.Currency
+}
+
+func (store *fakeStore) ListCurrencies(ctx context.Context) ([]db.Currency, error) {
+	store.calls++
+	return store.currencies, store.err
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{currencies: []db.Currency{
+		{Code: "CAD", Exponent: 2, Enabled: false},
+		{Code: "JPY", Exponent: 0, Enabled: true},
+		{Code: util.USD, Exponent: 2, Enabled: true},
+	}}
+}
+
+func TestRegistryValidate(t *testing.T) {
+	registry := NewRegistry(newFakeStore(), time.Minute)
+
+	require.NoError(t, registry.Validate(context.Background(), util.USD))
+	require.ErrorIs(t, registry.Validate(context.Background(), "CAD"), ErrCurrencyDisabled)
+	require.ErrorIs(t, registry.Validate(context.Background(), "XYZ"), ErrUnknownCurrency)
+
+	// Disabled currencies are still known
+	currency, err := registry.Get(context.Background(), "CAD")
+	require.NoError(t, err)
+	require.False(t, currency.Enabled)
+}
+
+func TestRegistryCaches(t *testing.T) {
+	store := newFakeStore()
+	registry := NewRegistry(store, time.Minute)
+
+	require.NoError(t, registry.Validate(context.Background(), util.USD))
+	require.NoError(t, registry.Validate(context.Background(), "JPY"))
+	require.Equal(t, 1, store.calls)
+
+	// Once forgotten, a change is seen right away
+	store.currencies[2].Enabled = false
+	registry.Forget()
+	require.ErrorIs(t, registry.Validate(context.Background(), util.USD), ErrCurrencyDisabled)
+	require.Equal(t, 2, store.calls)
+}
+
+func TestRegistryCacheExpires(t *testing.T) {
+	store := newFakeStore()
+	registry := NewRegistry(store, time.Millisecond)
+
+	require.NoError(t, registry.Validate(context.Background(), util.USD))
+	time.Sleep(5 * time.Millisecond)
+
+	store.currencies[0].Enabled = true
+	require.NoError(t, registry.Validate(context.Background(), "CAD"))
+	require.Equal(t, 2, store.calls)
+}
+
+func TestRegistryKeepsCurrenciesWhenReloadFails(t *testing.T) {
+	store := newFakeStore()
+	store.err = errors.New("connection refused")
+	registry := NewRegistry(store, time.Millisecond)
+
+	// Nothing to fall back to yet
+	require.ErrorContains(t, registry.Validate(context.Background(), util.USD), "connection refused")
+
+	store.err = nil
+	require.NoError(t, registry.Validate(context.Background(), util.USD))
+	time.Sleep(5 * time.Millisecond)
+
+	store.err = errors.New("connection refused")
+	require.NoError(t, registry.Validate(context.Background(), util.USD))
+}
+
+func TestRegistrySetsExponents(t *testing.T) {
+	registry := NewRegistry(newFakeStore(), time.Minute)
+
+	currencies, err := registry.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, currencies, 3)
+
+	money, err := util.ParseMoney("1500", "JPY")
+	require.NoError(t, err)
+	require.Equal(t, int64(1500), money.Amount)
+
+	_, err = util.ParseMoney("1.5", "JPY")
+	require.ErrorIs(t, err, util.ErrAmountPrecision)
+}
+
+// blockingStore holds every query until it is released
+type blockingStore struct {
+	calls      atomic.Int32
+	entered    chan struct{}
+	release    chan struct{}
+	currencies []db.Currency
+}
+
+func (store *blockingStore) ListCurrencies(ctx context.Context) ([]db.Currency, error) {
+	store.calls.Add(1)
+	store.entered <- struct{}{}
+	<-store.release
+	return store.currencies, nil
+}
+
+func newBlockingStore() *blockingStore {
+	return &blockingStore{
+		entered:    make(chan struct{}, 10),
+		release:    make(chan struct{}),
+		currencies: newFakeStore().currencies,
+	}
+}
+
+func TestRegistryCollapsesConcurrentLoads(t *testing.T) {
+	store := newBlockingStore()
+	registry := NewRegistry(store, time.Minute)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, registry.Validate(context.Background(), util.USD))
+		}()
+	}
+
+	<-store.entered
+	close(store.release)
+	wg.Wait()
+	require.Equal(t, int32(1), store.calls.Load())
+}
+
+func TestRegistryForgetDuringLoad(t *testing.T) {
+	store := newBlockingStore()
+	registry := NewRegistry(store, time.Minute)
+
+	loaded := make(chan error, 1)
+	go func() {
+		loaded <- registry.Validate(context.Background(), util.USD)
+	}()
+	<-store.entered
+
+	// A caller joining the load stops waiting when its context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, registry.Validate(ctx, util.USD), context.DeadlineExceeded)
+
+	// The query runs without the lock, so Forget does not wait for it
+	forgotten := make(chan struct{})
+	go func() {
+		registry.Forget()
+		close(forgotten)
+	}()
+	select {
+	case <-forgotten:
+	case <-time.After(time.Second):
+		t.Fatal("Forget waited for the query")
+	}
+
+	close(store.release)
+	require.NoError(t, <-loaded)
+
+	// The load that started before Forget may have read the old table, so it is not kept
+	require.NoError(t, registry.Validate(context.Background(), util.USD))
+	require.Equal(t, int32(2), store.calls.Load())
+}
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "password_reset_tokens" (
+    "id" bigserial PRIMARY KEY,
+    "username" varchar NOT NULL,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "expires_at" timestamptz NOT NULL
+);
+
+CREATE INDEX ON "password_reset_tokens" ("username");
+
+CREATE INDEX ON "sessions" ("username");
+
+COMMENT ON COLUMN "password_reset_tokens"."token_hash" IS 'sha256 of the token sent to the user';
+
+ALTER TABLE "password_reset_tokens" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS "sessions_username_idx";
+DROP TABLE IF EXISTS "password_reset_tokens";
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'depositor';
+ALTER TABLE "users" ADD COLUMN "failed_login_attempts" integer NOT NULL DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN "locked_until" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z';
+
+CREATE TABLE "failed_logins" (
+    "id" bigserial PRIMARY KEY,
+    "username" varchar NOT NULL,
+    "client_ip" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "failed_logins" ("username", "created_at");
+
+CREATE INDEX ON "failed_logins" ("client_ip", "created_at");
+
+COMMENT ON COLUMN "users"."failed_login_attempts" IS 'consecutive failures, reset by a successful login';
+
+COMMENT ON COLUMN "failed_logins"."username" IS 'not a foreign key, attempts against unknown usernames are tracked too';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "failed_logins";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "locked_until";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "failed_login_attempts";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "role";
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "sessions" ADD COLUMN "family_id" varchar;
+UPDATE "sessions" SET "family_id" = "id";
+ALTER TABLE "sessions" ALTER COLUMN "family_id" SET NOT NULL;
+
+ALTER TABLE "sessions" ADD COLUMN "replaced_by" varchar;
+
+CREATE INDEX ON "sessions" ("family_id");
+
+COMMENT ON COLUMN "sessions"."family_id" IS 'id of the first session of a chain of rotated refresh tokens';
+
+COMMENT ON COLUMN "sessions"."replaced_by" IS 'set once the refresh token has been exchanged for a new one';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "replaced_by";
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "family_id";
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "sessions" ADD COLUMN "user_agent" varchar NOT NULL DEFAULT '';
+ALTER TABLE "sessions" ADD COLUMN "client_ip" varchar NOT NULL DEFAULT '';
+
+CREATE INDEX ON "sessions" ("username", "expires_at");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS "sessions_username_expires_at_idx";
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "client_ip";
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "user_agent";
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "transfer_batches" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "mode" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "legs" int NOT NULL,
+  "succeeded" int NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "transfer_batches" ("owner");
+
+COMMENT ON TABLE "transfer_batches" IS 'transfers submitted together, e.g. a payroll run';
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "transfers" ADD COLUMN "batch_id" bigint;
+
+CREATE INDEX ON "transfers" ("batch_id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("batch_id") REFERENCES "transfer_batches" ("id");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "batch_id";
+
+DROP TABLE IF EXISTS "transfer_batches";
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "currencies" (
+  "code" varchar PRIMARY KEY,
+  "exponent" smallint NOT NULL,
+  "enabled" boolean NOT NULL DEFAULT true,
+  "display_name" varchar NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "currencies_exponent_check" CHECK ("exponent" BETWEEN 0 AND 4)
+);
+
+COMMENT ON TABLE "currencies" IS 'ISO 4217 currencies accounts can be held in';
+
+COMMENT ON COLUMN "currencies"."exponent" IS 'decimals of the minor unit, 2 for cents';
+
+COMMENT ON COLUMN "currencies"."enabled" IS 'disabled currencies take no new accounts or transfers';
+
+INSERT INTO "currencies" ("code", "exponent", "display_name") VALUES
+  ('USD', 2, 'US Dollar'),
+  ('EUR', 2, 'Euro'),
+  ('INR', 2, 'Indian Rupee');
+
+-- Accounts created outside of the API, e.g. by seeding, may use other codes.
+-- They are kept, in a disabled currency, until an operator reviews them.
+INSERT INTO "currencies" ("code", "exponent", "enabled", "display_name")
+SELECT DISTINCT "currency", 2, false, "currency" FROM "accounts"
+ON CONFLICT ("code") DO NOTHING;
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "accounts_currency_fkey";
+
+DROP TABLE IF EXISTS "currencies";
+-- +goose StatementEnd
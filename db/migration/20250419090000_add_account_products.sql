@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "account_products" (
+  "code" varchar PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "enabled" boolean NOT NULL DEFAULT true,
+  "allowed_currencies" varchar[] NOT NULL DEFAULT '{}',
+  "max_accounts_per_user" int NOT NULL DEFAULT 0,
+  "min_balance" bigint NOT NULL DEFAULT 0,
+  "max_monthly_withdrawals" int NOT NULL DEFAULT 0,
+  "max_monthly_withdrawal_amount" bigint NOT NULL DEFAULT 0,
+  "interest_rate_bps" int NOT NULL DEFAULT 0,
+  "monthly_fee" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "account_products_limits_check" CHECK (
+    "max_accounts_per_user" >= 0 AND "min_balance" >= 0 AND
+    "max_monthly_withdrawals" >= 0 AND "max_monthly_withdrawal_amount" >= 0 AND
+    "interest_rate_bps" >= 0 AND "monthly_fee" >= 0
+  )
+);
+
+COMMENT ON TABLE "account_products" IS 'kinds of account, e.g. checking or savings, and their rules';
+
+COMMENT ON COLUMN "account_products"."allowed_currencies" IS 'empty allows every enabled currency';
+
+COMMENT ON COLUMN "account_products"."max_accounts_per_user" IS '0 is unlimited';
+
+COMMENT ON COLUMN "account_products"."min_balance" IS 'in minor units, outgoing transfers cannot go below it';
+
+COMMENT ON COLUMN "account_products"."max_monthly_withdrawals" IS 'outgoing transfers per calendar month in UTC, 0 is unlimited';
+
+COMMENT ON COLUMN "account_products"."max_monthly_withdrawal_amount" IS 'in minor units per calendar month in UTC, 0 is unlimited';
+
+COMMENT ON COLUMN "account_products"."interest_rate_bps" IS 'yearly interest in basis points';
+
+COMMENT ON COLUMN "account_products"."monthly_fee" IS 'in minor units';
+
+INSERT INTO "account_products" ("code", "name", "max_accounts_per_user", "max_monthly_withdrawals", "interest_rate_bps") VALUES
+  ('checking', 'Checking', 0, 0, 0),
+  ('savings', 'Savings', 2, 6, 150);
+
+ALTER TABLE "accounts" ADD COLUMN "product" varchar NOT NULL DEFAULT 'checking';
+
+ALTER TABLE "accounts" ALTER COLUMN "product" DROP DEFAULT;
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("product") REFERENCES "account_products" ("code");
+
+CREATE INDEX ON "accounts" ("owner", "product");
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS "transfers_from_account_id_created_at_idx";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "product";
+
+DROP TABLE IF EXISTS "account_products";
+-- +goose StatementEnd
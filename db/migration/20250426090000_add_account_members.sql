@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "account_members" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "role" varchar NOT NULL,
+  "spend_limit" bigint NOT NULL DEFAULT 0,
+  "invited_by" varchar NOT NULL,
+  "accepted_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username"),
+  CONSTRAINT "account_members_role_check" CHECK ("role" IN ('owner', 'co_owner', 'viewer', 'spender')),
+  CONSTRAINT "account_members_spend_limit_check" CHECK ("spend_limit" >= 0 AND ("role" = 'spender' OR "spend_limit" = 0))
+);
+
+CREATE INDEX ON "account_members" ("username");
+
+COMMENT ON TABLE "account_members" IS 'users who can act on an account, and what they can do';
+
+COMMENT ON COLUMN "account_members"."spend_limit" IS 'largest transfer a spender may make, in minor units';
+
+COMMENT ON COLUMN "account_members"."accepted_at" IS 'null until the invited user accepts';
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("invited_by") REFERENCES "users" ("username");
+
+-- The owner of every existing account becomes its first member
+INSERT INTO "account_members" ("account_id", "username", "role", "invited_by", "accepted_at")
+SELECT "id", "owner", 'owner', "owner", "created_at" FROM "accounts";
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "account_members";
+-- +goose StatementEnd
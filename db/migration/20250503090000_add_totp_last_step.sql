@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "users" ADD COLUMN "totp_last_step" bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "users"."totp_last_step" IS 'newest TOTP time step accepted, codes of this step or older are replays';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "users" DROP COLUMN IF EXISTS "totp_last_step";
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "product_postings" (
+  "account_id" bigint NOT NULL,
+  "period" date NOT NULL,
+  "interest" bigint NOT NULL,
+  "fee" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "period"),
+  CONSTRAINT "product_postings_amounts_check" CHECK ("interest" >= 0 AND "fee" >= 0)
+);
+
+COMMENT ON TABLE "product_postings" IS 'interest and fees of the account product posted for a month, at most once per account';
+
+COMMENT ON COLUMN "product_postings"."period" IS 'first day of the month the posting is for';
+
+COMMENT ON COLUMN "product_postings"."fee" IS 'in minor units, less than the product fee when the balance could not cover it';
+
+ALTER TABLE "product_postings" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "product_postings";
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "transfers" ADD COLUMN "initiated_by" varchar;
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("initiated_by") REFERENCES "users" ("username");
+
+CREATE INDEX ON "transfers" ("from_account_id", "initiated_by", "created_at");
+
+COMMENT ON COLUMN "transfers"."initiated_by" IS 'member who made the transfer, null for transfers made outside of a member, e.g. by bankctl';
+
+COMMENT ON COLUMN "account_members"."spend_limit" IS 'most a spender may transfer out of the account per calendar month in UTC, in minor units';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+COMMENT ON COLUMN "account_members"."spend_limit" IS 'largest transfer a spender may make, in minor units';
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "initiated_by";
+-- +goose StatementEnd
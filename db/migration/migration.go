@@ -0,0 +1,55 @@
+// Package migration embeds the goose migrations, so the binary knows which
+// schema version it was built for and can apply them itself.
+package migration
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// LatestVersion returns the version of the newest migration, taken from the
+// YYYYMMDDhhmmss prefix of its file name.
+func LatestVersion() (int64, error) {
+	files, err := fs.Glob(FS, "*.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+	for _, file := range files {
+		prefix, _, found := strings.Cut(file, "_")
+		if !found {
+			return 0, fmt.Errorf("migration %s has no version prefix", file)
+		}
+
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s has an invalid version: %w", file, err)
+		}
+
+		latest = max(latest, version)
+	}
+
+	return latest, nil
+}
+
+// NewProvider runs the embedded migrations against db. Migrations hold a Postgres
+// advisory lock, so replicas starting together apply them one at a time.
+func NewProvider(db *sql.DB) (*goose.Provider, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, fmt.Errorf("cannot create migration lock: %w", err)
+	}
+
+	return goose.NewProvider(goose.DialectPostgres, db, FS, goose.WithSessionLocker(locker))
+}
@@ -0,0 +1,32 @@
+package migration
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestVersion(t *testing.T) {
+	version, err := LatestVersion()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, version, int64(20250218175641))
+}
+
+func TestNewProvider(t *testing.T) {
+	// sql.Open does not connect, the provider only parses the migrations here
+	db, err := sql.Open("pgx", "postgresql://localhost/bankdb")
+	require.NoError(t, err)
+	defer db.Close()
+
+	provider, err := NewProvider(db)
+	require.NoError(t, err)
+
+	latest, err := LatestVersion()
+	require.NoError(t, err)
+
+	sources := provider.ListSources()
+	require.NotEmpty(t, sources)
+	require.Equal(t, latest, sources[len(sources)-1].Version)
+}
@@ -1,8 +1,8 @@
 -- name: CreateAccount :one
 INSERT INTO accounts (
-  owner, balance, currency 
+  owner, balance, currency, product
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4
 )
 RETURNING *;
 
@@ -22,18 +22,21 @@ ORDER BY id
 LIMIT $2
 OFFSET $3;
 
+-- name: ListMemberAccounts :many
+-- Accounts the user is a member of, once the invitation is accepted
+SELECT accounts.* FROM accounts
+JOIN account_members ON account_members.account_id = accounts.id
+WHERE account_members.username = $1 AND account_members.accepted_at IS NOT NULL
+ORDER BY accounts.id
+LIMIT $2
+OFFSET $3;
+
 -- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
 RETURNING *;
 
--- name: CheckAccountOwnership :one
-SELECT EXISTS (
-  SELECT 1 FROM accounts
-  WHERE id = $1 AND owner = $2
-) AS is_owner;
-
 -- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + sqlc.arg(amount)
@@ -43,4 +46,28 @@ RETURNING *;
 -- name: DeleteAccount :exec
 DELETE FROM accounts
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: ListUnreconciledAccounts :many
+-- Accounts whose balance differs from the sum of their ledger entries
+SELECT
+  accounts.id,
+  accounts.owner,
+  accounts.currency,
+  accounts.balance,
+  COALESCE(SUM(entries.amount), 0)::bigint AS entries_total
+FROM accounts
+LEFT JOIN entries ON entries.account_id = accounts.id
+GROUP BY accounts.id
+HAVING accounts.balance <> COALESCE(SUM(entries.amount), 0)
+ORDER BY accounts.id;
+
+-- name: GetAccountsForUpdate :many
+SELECT * FROM accounts
+WHERE id = ANY(@ids::bigint[])
+ORDER BY id
+FOR NO KEY UPDATE;
+
+-- name: CountAccountsByProduct :one
+SELECT COUNT(*) FROM accounts
+WHERE owner = $1 AND product = $2;
@@ -0,0 +1,47 @@
+-- name: CreateAccountMember :one
+INSERT INTO account_members (
+  account_id,
+  username,
+  role,
+  spend_limit,
+  invited_by,
+  accepted_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetAccountMember :one
+SELECT * FROM account_members
+WHERE account_id = $1 AND username = $2 LIMIT 1;
+
+-- name: ListAccountMembers :many
+SELECT * FROM account_members
+WHERE account_id = $1
+ORDER BY username;
+
+-- name: ListPendingInvitations :many
+SELECT * FROM account_members
+WHERE username = $1 AND accepted_at IS NULL
+ORDER BY account_id;
+
+-- name: AcceptAccountInvitation :one
+UPDATE account_members
+SET accepted_at = now()
+WHERE account_id = $1 AND username = $2 AND accepted_at IS NULL
+RETURNING *;
+
+-- name: UpdateAccountMemberRole :one
+UPDATE account_members
+SET role = $3, spend_limit = $4
+WHERE account_id = $1 AND username = $2
+RETURNING *;
+
+-- name: DeleteAccountMember :exec
+DELETE FROM account_members
+WHERE account_id = $1 AND username = $2;
+
+-- name: CountAccountOwners :one
+-- Owners who accepted, the ones who can still manage the account
+SELECT COUNT(*) FROM account_members
+WHERE account_id = $1 AND role = 'owner' AND accepted_at IS NOT NULL;
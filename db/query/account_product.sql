@@ -0,0 +1,29 @@
+-- name: CreateAccountProduct :one
+INSERT INTO account_products (
+  code,
+  name,
+  allowed_currencies,
+  max_accounts_per_user,
+  min_balance,
+  max_monthly_withdrawals,
+  max_monthly_withdrawal_amount,
+  interest_rate_bps,
+  monthly_fee
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: GetAccountProduct :one
+SELECT * FROM account_products
+WHERE code = $1 LIMIT 1;
+
+-- name: ListAccountProducts :many
+SELECT * FROM account_products
+ORDER BY code;
+
+-- name: SetAccountProductEnabled :one
+UPDATE account_products
+SET enabled = $2
+WHERE code = $1
+RETURNING *;
@@ -0,0 +1,13 @@
+-- name: GetCurrency :one
+SELECT * FROM currencies
+WHERE code = $1 LIMIT 1;
+
+-- name: ListCurrencies :many
+SELECT * FROM currencies
+ORDER BY code;
+
+-- name: SetCurrencyEnabled :one
+UPDATE currencies
+SET enabled = $2, updated_at = now()
+WHERE code = $1
+RETURNING *;
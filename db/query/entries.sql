@@ -26,4 +26,11 @@ RETURNING *;
 -- name: DeleteEntry :exec
 DELETE FROM entries
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: CreateEntries :copyfrom
+INSERT INTO entries (
+    account_id, amount
+) VALUES (
+    $1, $2
+);
@@ -0,0 +1,18 @@
+-- name: CreateFailedLogin :one
+INSERT INTO failed_logins (
+    username, client_ip
+) VALUES (
+  $1, $2
+)
+RETURNING *;
+
+-- name: CountRecentFailedLoginsByIP :one
+SELECT count(*) FROM failed_logins
+WHERE client_ip = $1 AND created_at > sqlc.arg(since);
+
+-- name: GetFailedLoginsByUsername :one
+SELECT
+  count(*)::integer AS failed_attempts,
+  max(created_at)::timestamptz AS last_failed_at
+FROM failed_logins
+WHERE username = $1;
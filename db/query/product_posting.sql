@@ -0,0 +1,21 @@
+-- name: CreateProductPosting :one
+INSERT INTO product_postings (
+  account_id, period, interest, fee
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetProductPosting :one
+SELECT * FROM product_postings
+WHERE account_id = $1 AND period = $2
+LIMIT 1;
+
+-- name: ListAccountsWithProductPlans :many
+SELECT a.* FROM accounts a
+JOIN account_products p ON p.code = a.product
+WHERE (p.interest_rate_bps > 0 OR p.monthly_fee > 0)
+  AND a.created_at < sqlc.arg(opened_before)
+  AND a.id > sqlc.arg(after_id)
+ORDER BY a.id
+LIMIT sqlc.arg('limit');
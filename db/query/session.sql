@@ -1,8 +1,8 @@
 -- name: CreateSession :one
 INSERT INTO sessions (
-    id, username, refresh_token, is_revoked, expires_at
+    id, family_id, username, refresh_token, user_agent, client_ip, is_revoked, expires_at
 ) VALUES (
-  $1, $2, $3, $4, $5
+  $1, $2, $3, $4, $5, $6, $7, $8
 )
 RETURNING *;
 
@@ -10,6 +10,18 @@ RETURNING *;
 SELECT * FROM sessions
 WHERE id = $1 LIMIT 1;
 
+-- name: GetSessionForUpdate :one
+SELECT * FROM sessions
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListActiveSessions :many
+SELECT * FROM sessions
+WHERE username = $1
+  AND is_revoked = false
+  AND expires_at > now()
+ORDER BY created_at DESC;
+
 -- name: RevokeSession :exec
 UPDATE sessions
 SET is_revoked = true
@@ -17,4 +29,35 @@ WHERE id = $1;
 
 -- name: DeleteSession :exec
 DELETE FROM sessions
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET is_revoked = true
+WHERE username = $1 AND is_revoked = false;
+
+-- name: MarkSessionRotated :one
+UPDATE sessions
+SET
+  is_revoked = true,
+  replaced_by = sqlc.arg('replaced_by')
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: RevokeSessionFamily :exec
+UPDATE sessions
+SET is_revoked = true
+WHERE family_id = $1 AND is_revoked = false;
+
+-- name: GetSessionFamilyState :one
+SELECT
+  users.password_changed_at,
+  EXISTS (
+    SELECT 1 FROM sessions
+    WHERE sessions.family_id = sqlc.arg('family_id')
+      AND sessions.username = users.username
+      AND sessions.is_revoked = false
+      AND sessions.expires_at > now()
+  ) AS session_active
+FROM users
+WHERE users.username = sqlc.arg('username');
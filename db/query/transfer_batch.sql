@@ -0,0 +1,7 @@
+-- name: CreateTransferBatch :one
+INSERT INTO transfer_batches (
+    owner, mode, currency, legs, succeeded
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
@@ -1,8 +1,8 @@
 -- name: CreateTransfer :one
 INSERT INTO transfers (
-    from_account_id, to_account_id, amount
+    from_account_id, to_account_id, amount, initiated_by
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4
 )
 RETURNING *;
 
@@ -39,4 +39,26 @@ RETURNING *;
 -- name: DeleteTransfer :one
 DELETE FROM transfers
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: CreateTransfers :copyfrom
+INSERT INTO transfers (
+    batch_id, from_account_id, to_account_id, amount, initiated_by
+) VALUES (
+    $1, $2, $3, $4, $5
+);
+
+-- name: ListBatchTransfers :many
+SELECT * FROM transfers
+WHERE batch_id = $1
+ORDER BY id;
+
+-- name: GetWithdrawalsSince :one
+SELECT COUNT(*) AS count, COALESCE(SUM(amount), 0)::bigint AS total
+FROM transfers
+WHERE from_account_id = $1 AND created_at >= @since;
+
+-- name: GetMemberSpendingSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM transfers
+WHERE from_account_id = $1 AND initiated_by = $2 AND created_at >= @since;
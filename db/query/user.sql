@@ -10,6 +10,11 @@ RETURNING *;
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;
 
+-- name: GetUserForUpdate :one
+SELECT * FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: UpdateUser :one
 UPDATE users
 SET
@@ -19,4 +24,65 @@ SET
   email = coalesce(sqlc.narg('email'), email)
 WHERE 
   username = sqlc.arg('username')
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+
+-- name: SetUserTOTPSecret :one
+UPDATE users
+SET
+  totp_secret = sqlc.arg('totp_secret'),
+  totp_enabled = false,
+  totp_last_step = 0
+WHERE
+  username = sqlc.arg('username')
+RETURNING *;
+
+-- name: EnableUserTOTP :one
+UPDATE users
+SET totp_enabled = true
+WHERE username = $1 AND totp_secret IS NOT NULL
+RETURNING *;
+
+-- name: DisableUserTOTP :one
+UPDATE users
+SET
+  totp_secret = NULL,
+  totp_enabled = false
+WHERE username = $1
+RETURNING *;
+
+-- name: UseTOTPStep :one
+UPDATE users
+SET totp_last_step = sqlc.arg('step')
+WHERE username = sqlc.arg('username') AND totp_last_step < sqlc.arg('step')
+RETURNING *;
+
+-- name: IncrementUserFailedLogins :one
+UPDATE users
+SET failed_login_attempts = failed_login_attempts + 1
+WHERE username = $1
+RETURNING *;
+
+-- name: LockUser :one
+UPDATE users
+SET locked_until = sqlc.arg('locked_until')
+WHERE username = sqlc.arg('username')
+RETURNING *;
+
+-- name: ResetUserFailedLogins :one
+UPDATE users
+SET
+  failed_login_attempts = 0,
+  locked_until = '0001-01-01 00:00:00Z'
+WHERE username = $1
+RETURNING *;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE username = $1
+RETURNING *;
@@ -13,7 +13,7 @@ const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + $1
 WHERE id = $2
-RETURNING id, owner, currency, created_at, balance
+RETURNING id, owner, currency, created_at, balance, product
 `
 
 type AddAccountBalanceParams struct {
@@ -30,46 +30,51 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Currency,
 		&i.CreatedAt,
 		&i.Balance,
+		&i.Product,
 	)
 	return i, err
 }
 
-const checkAccountOwnership = `-- name: CheckAccountOwnership :one
-SELECT EXISTS (
-  SELECT 1 FROM accounts
-  WHERE id = $1 AND owner = $2
-) AS is_owner
+const countAccountsByProduct = `-- name: CountAccountsByProduct :one
+SELECT COUNT(*) FROM accounts
+WHERE owner = $1 AND product = $2
 `
 
-type CheckAccountOwnershipParams struct {
-	ID    int64  `json:"id"`
-	Owner string `json:"owner"`
+type CountAccountsByProductParams struct {
+	Owner   string `json:"owner"`
+	Product string `json:"product"`
 }
 
-func (q *Queries) CheckAccountOwnership(ctx context.Context, arg CheckAccountOwnershipParams) (bool, error) {
-	row := q.db.QueryRow(ctx, checkAccountOwnership, arg.ID, arg.Owner)
-	var is_owner bool
-	err := row.Scan(&is_owner)
-	return is_owner, err
+func (q *Queries) CountAccountsByProduct(ctx context.Context, arg CountAccountsByProductParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countAccountsByProduct, arg.Owner, arg.Product)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
-  owner, balance, currency 
+  owner, balance, currency, product
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4
 )
-RETURNING id, owner, currency, created_at, balance
+RETURNING id, owner, currency, created_at, balance, product
 `
 
 type CreateAccountParams struct {
 	Owner    string `json:"owner"`
 	Balance  int64  `json:"balance"`
 	Currency string `json:"currency"`
+	Product  string `json:"product"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRow(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.db.QueryRow(ctx, createAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.Product,
+	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -77,6 +82,7 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Currency,
 		&i.CreatedAt,
 		&i.Balance,
+		&i.Product,
 	)
 	return i, err
 }
@@ -84,7 +90,7 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 const deleteAccount = `-- name: DeleteAccount :exec
 DELETE FROM accounts
 WHERE id = $1
-RETURNING id, owner, currency, created_at, balance
+RETURNING id, owner, currency, created_at, balance, product
 `
 
 func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
@@ -93,7 +99,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, currency, created_at, balance FROM accounts
+SELECT id, owner, currency, created_at, balance, product FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -106,12 +112,13 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Currency,
 		&i.CreatedAt,
 		&i.Balance,
+		&i.Product,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, currency, created_at, balance FROM accounts
+SELECT id, owner, currency, created_at, balance, product FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -125,12 +132,47 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Currency,
 		&i.CreatedAt,
 		&i.Balance,
+		&i.Product,
 	)
 	return i, err
 }
 
+const getAccountsForUpdate = `-- name: GetAccountsForUpdate :many
+SELECT id, owner, currency, created_at, balance, product FROM accounts
+WHERE id = ANY($1::bigint[])
+ORDER BY id
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetAccountsForUpdate(ctx context.Context, ids []int64) ([]Account, error) {
+	rows, err := q.db.Query(ctx, getAccountsForUpdate, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Balance,
+			&i.Product,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, currency, created_at, balance FROM accounts
+SELECT id, owner, currency, created_at, balance, product FROM accounts
 WHERE owner = $1
 ORDER BY id
 LIMIT $2
@@ -158,6 +200,99 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Currency,
 			&i.CreatedAt,
 			&i.Balance,
+			&i.Product,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMemberAccounts = `-- name: ListMemberAccounts :many
+SELECT accounts.id, accounts.owner, accounts.currency, accounts.created_at, accounts.balance, accounts.product FROM accounts
+JOIN account_members ON account_members.account_id = accounts.id
+WHERE account_members.username = $1 AND account_members.accepted_at IS NOT NULL
+ORDER BY accounts.id
+LIMIT $2
+OFFSET $3
+`
+
+type ListMemberAccountsParams struct {
+	Username string `json:"username"`
+	Limit    int32  `json:"limit"`
+	Offset   int32  `json:"offset"`
+}
+
+// Accounts the user is a member of, once the invitation is accepted
+func (q *Queries) ListMemberAccounts(ctx context.Context, arg ListMemberAccountsParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listMemberAccounts, arg.Username, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Balance,
+			&i.Product,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnreconciledAccounts = `-- name: ListUnreconciledAccounts :many
+SELECT
+  accounts.id,
+  accounts.owner,
+  accounts.currency,
+  accounts.balance,
+  COALESCE(SUM(entries.amount), 0)::bigint AS entries_total
+FROM accounts
+LEFT JOIN entries ON entries.account_id = accounts.id
+GROUP BY accounts.id
+HAVING accounts.balance <> COALESCE(SUM(entries.amount), 0)
+ORDER BY accounts.id
+`
+
+type ListUnreconciledAccountsRow struct {
+	ID           int64  `json:"id"`
+	Owner        string `json:"owner"`
+	Currency     string `json:"currency"`
+	Balance      int64  `json:"balance"`
+	EntriesTotal int64  `json:"entries_total"`
+}
+
+// Accounts whose balance differs from the sum of their ledger entries
+func (q *Queries) ListUnreconciledAccounts(ctx context.Context) ([]ListUnreconciledAccountsRow, error) {
+	rows, err := q.db.Query(ctx, listUnreconciledAccounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUnreconciledAccountsRow{}
+	for rows.Next() {
+		var i ListUnreconciledAccountsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Currency,
+			&i.Balance,
+			&i.EntriesTotal,
 		); err != nil {
 			return nil, err
 		}
@@ -173,7 +308,7 @@ const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
-RETURNING id, owner, currency, created_at, balance
+RETURNING id, owner, currency, created_at, balance, product
 `
 
 type UpdateAccountParams struct {
@@ -190,6 +325,7 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Currency,
 		&i.CreatedAt,
 		&i.Balance,
+		&i.Product,
 	)
 	return i, err
 }
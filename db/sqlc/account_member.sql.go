@@ -0,0 +1,239 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: account_member.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const acceptAccountInvitation = `-- name: AcceptAccountInvitation :one
+UPDATE account_members
+SET accepted_at = now()
+WHERE account_id = $1 AND username = $2 AND accepted_at IS NULL
+RETURNING account_id, username, role, spend_limit, invited_by, accepted_at, created_at
+`
+
+type AcceptAccountInvitationParams struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+}
+
+func (q *Queries) AcceptAccountInvitation(ctx context.Context, arg AcceptAccountInvitationParams) (AccountMember, error) {
+	row := q.db.QueryRow(ctx, acceptAccountInvitation, arg.AccountID, arg.Username)
+	var i AccountMember
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Role,
+		&i.SpendLimit,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const countAccountOwners = `-- name: CountAccountOwners :one
+SELECT COUNT(*) FROM account_members
+WHERE account_id = $1 AND role = 'owner' AND accepted_at IS NOT NULL
+`
+
+// Owners who accepted, the ones who can still manage the account
+func (q *Queries) CountAccountOwners(ctx context.Context, accountID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, countAccountOwners, accountID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAccountMember = `-- name: CreateAccountMember :one
+INSERT INTO account_members (
+  account_id,
+  username,
+  role,
+  spend_limit,
+  invited_by,
+  accepted_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING account_id, username, role, spend_limit, invited_by, accepted_at, created_at
+`
+
+type CreateAccountMemberParams struct {
+	AccountID  int64              `json:"account_id"`
+	Username   string             `json:"username"`
+	Role       string             `json:"role"`
+	SpendLimit int64              `json:"spend_limit"`
+	InvitedBy  string             `json:"invited_by"`
+	AcceptedAt pgtype.Timestamptz `json:"accepted_at"`
+}
+
+func (q *Queries) CreateAccountMember(ctx context.Context, arg CreateAccountMemberParams) (AccountMember, error) {
+	row := q.db.QueryRow(ctx, createAccountMember,
+		arg.AccountID,
+		arg.Username,
+		arg.Role,
+		arg.SpendLimit,
+		arg.InvitedBy,
+		arg.AcceptedAt,
+	)
+	var i AccountMember
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Role,
+		&i.SpendLimit,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteAccountMember = `-- name: DeleteAccountMember :exec
+DELETE FROM account_members
+WHERE account_id = $1 AND username = $2
+`
+
+type DeleteAccountMemberParams struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+}
+
+func (q *Queries) DeleteAccountMember(ctx context.Context, arg DeleteAccountMemberParams) error {
+	_, err := q.db.Exec(ctx, deleteAccountMember, arg.AccountID, arg.Username)
+	return err
+}
+
+const getAccountMember = `-- name: GetAccountMember :one
+SELECT account_id, username, role, spend_limit, invited_by, accepted_at, created_at FROM account_members
+WHERE account_id = $1 AND username = $2 LIMIT 1
+`
+
+type GetAccountMemberParams struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+}
+
+func (q *Queries) GetAccountMember(ctx context.Context, arg GetAccountMemberParams) (AccountMember, error) {
+	row := q.db.QueryRow(ctx, getAccountMember, arg.AccountID, arg.Username)
+	var i AccountMember
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Role,
+		&i.SpendLimit,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAccountMembers = `-- name: ListAccountMembers :many
+SELECT account_id, username, role, spend_limit, invited_by, accepted_at, created_at FROM account_members
+WHERE account_id = $1
+ORDER BY username
+`
+
+func (q *Queries) ListAccountMembers(ctx context.Context, accountID int64) ([]AccountMember, error) {
+	rows, err := q.db.Query(ctx, listAccountMembers, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountMember{}
+	for rows.Next() {
+		var i AccountMember
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Username,
+			&i.Role,
+			&i.SpendLimit,
+			&i.InvitedBy,
+			&i.AcceptedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingInvitations = `-- name: ListPendingInvitations :many
+SELECT account_id, username, role, spend_limit, invited_by, accepted_at, created_at FROM account_members
+WHERE username = $1 AND accepted_at IS NULL
+ORDER BY account_id
+`
+
+func (q *Queries) ListPendingInvitations(ctx context.Context, username string) ([]AccountMember, error) {
+	rows, err := q.db.Query(ctx, listPendingInvitations, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountMember{}
+	for rows.Next() {
+		var i AccountMember
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Username,
+			&i.Role,
+			&i.SpendLimit,
+			&i.InvitedBy,
+			&i.AcceptedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateAccountMemberRole = `-- name: UpdateAccountMemberRole :one
+UPDATE account_members
+SET role = $3, spend_limit = $4
+WHERE account_id = $1 AND username = $2
+RETURNING account_id, username, role, spend_limit, invited_by, accepted_at, created_at
+`
+
+type UpdateAccountMemberRoleParams struct {
+	AccountID  int64  `json:"account_id"`
+	Username   string `json:"username"`
+	Role       string `json:"role"`
+	SpendLimit int64  `json:"spend_limit"`
+}
+
+func (q *Queries) UpdateAccountMemberRole(ctx context.Context, arg UpdateAccountMemberRoleParams) (AccountMember, error) {
+	row := q.db.QueryRow(ctx, updateAccountMemberRole,
+		arg.AccountID,
+		arg.Username,
+		arg.Role,
+		arg.SpendLimit,
+	)
+	var i AccountMember
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Role,
+		&i.SpendLimit,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
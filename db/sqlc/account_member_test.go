@@ -0,0 +1,373 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// addAccountMember adds a member who has already accepted
+func addAccountMember(t *testing.T, account Account, username string, role string, spendLimit int64) AccountMember {
+	member, err := testQueries.CreateAccountMember(context.Background(), CreateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   username,
+		Role:       role,
+		SpendLimit: spendLimit,
+		InvitedBy:  account.Owner,
+		AcceptedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+	require.NoError(t, err)
+	require.True(t, member.Active())
+	return member
+}
+
+func TestCreateAccountTxAddsOwner(t *testing.T) {
+	user := createRandomUser(t)
+
+	account, err := testStore.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		Owner:    user.Username,
+		Currency: util.USD,
+		Product:  DefaultAccountProduct,
+	})
+	require.NoError(t, err)
+
+	member, err := testQueries.GetAccountMember(context.Background(), GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, MemberOwner, member.Role)
+	require.Equal(t, user.Username, member.InvitedBy)
+	require.True(t, member.Active())
+}
+
+func TestAccountInvitation(t *testing.T) {
+	account := createRandomAccount(t)
+	addAccountMember(t, account, account.Owner, MemberOwner, 0)
+	invitee := createRandomUser(t)
+
+	invitation, err := testQueries.CreateAccountMember(context.Background(), CreateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   invitee.Username,
+		Role:       MemberSpender,
+		SpendLimit: 500,
+		InvitedBy:  account.Owner,
+	})
+	require.NoError(t, err)
+	require.False(t, invitation.Active())
+	require.NotZero(t, invitation.CreatedAt)
+
+	pending, err := testQueries.ListPendingInvitations(context.Background(), invitee.Username)
+	require.NoError(t, err)
+	require.Equal(t, []AccountMember{invitation}, pending)
+
+	// Pending members do not see the account yet
+	listArg := ListMemberAccountsParams{Username: invitee.Username, Limit: 5}
+	accounts, err := testQueries.ListMemberAccounts(context.Background(), listArg)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	member, err := testQueries.AcceptAccountInvitation(context.Background(), AcceptAccountInvitationParams{
+		AccountID: account.ID,
+		Username:  invitee.Username,
+	})
+	require.NoError(t, err)
+	require.True(t, member.Active())
+
+	accounts, err = testQueries.ListMemberAccounts(context.Background(), listArg)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+
+	// An invitation is accepted once
+	_, err = testQueries.AcceptAccountInvitation(context.Background(), AcceptAccountInvitationParams{
+		AccountID: account.ID,
+		Username:  invitee.Username,
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	members, err := testQueries.ListAccountMembers(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+}
+
+func TestCreateAccountMemberConstraints(t *testing.T) {
+	account := createRandomAccount(t)
+	owner := addAccountMember(t, account, account.Owner, MemberOwner, 0)
+	user := createRandomUser(t)
+
+	arg := CreateAccountMemberParams{
+		AccountID: account.ID,
+		Username:  user.Username,
+		Role:      "admin",
+		InvitedBy: account.Owner,
+	}
+	_, err := testQueries.CreateAccountMember(context.Background(), arg)
+	requirePgError(t, err, checkViolationCode, "account_members_role_check")
+
+	arg.Role = MemberViewer
+	arg.SpendLimit = 100
+	_, err = testQueries.CreateAccountMember(context.Background(), arg)
+	requirePgError(t, err, checkViolationCode, "account_members_spend_limit_check")
+
+	arg.Username = owner.Username
+	arg.SpendLimit = 0
+	_, err = testQueries.CreateAccountMember(context.Background(), arg)
+	requirePgError(t, err, "23505", "account_members_pkey")
+
+	arg.Username = util.RandomOwner()
+	_, err = testQueries.CreateAccountMember(context.Background(), arg)
+	requirePgError(t, err, "23503", "account_members_username_fkey")
+}
+
+func TestAccountMemberTxKeepsOwner(t *testing.T) {
+	account := createRandomAccount(t)
+	owner := addAccountMember(t, account, account.Owner, MemberOwner, 0)
+	ownerArg := DeleteAccountMemberParams{AccountID: account.ID, Username: owner.Username}
+
+	err := testStore.RemoveAccountMemberTx(context.Background(), ownerArg)
+	require.ErrorIs(t, err, ErrLastOwner)
+
+	_, err = testStore.UpdateAccountMemberTx(context.Background(), UpdateAccountMemberRoleParams{
+		AccountID: account.ID,
+		Username:  owner.Username,
+		Role:      MemberViewer,
+	})
+	require.ErrorIs(t, err, ErrLastOwner)
+
+	// A pending owner does not count
+	_, err = testQueries.CreateAccountMember(context.Background(), CreateAccountMemberParams{
+		AccountID: account.ID,
+		Username:  createRandomUser(t).Username,
+		Role:      MemberOwner,
+		InvitedBy: owner.Username,
+	})
+	require.NoError(t, err)
+	err = testStore.RemoveAccountMemberTx(context.Background(), ownerArg)
+	require.ErrorIs(t, err, ErrLastOwner)
+
+	addAccountMember(t, account, createRandomUser(t).Username, MemberOwner, 0)
+	member, err := testStore.UpdateAccountMemberTx(context.Background(), UpdateAccountMemberRoleParams{
+		AccountID:  account.ID,
+		Username:   owner.Username,
+		Role:       MemberSpender,
+		SpendLimit: 100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, MemberSpender, member.Role)
+	require.Equal(t, int64(100), member.SpendLimit)
+
+	err = testStore.RemoveAccountMemberTx(context.Background(), ownerArg)
+	require.NoError(t, err)
+	_, err = testQueries.GetAccountMember(context.Background(), GetAccountMemberParams(ownerArg))
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	count, err := testQueries.CountAccountOwners(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+}
+
+func TestDeleteAccountRemovesMembers(t *testing.T) {
+	account := createRandomAccount(t)
+	owner := addAccountMember(t, account, account.Owner, MemberOwner, 0)
+
+	err := testQueries.DeleteAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetAccountMember(context.Background(), GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  owner.Username,
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestBatchTransferTxMembers(t *testing.T) {
+	from := createBatchAccount(t, createRandomUser(t).Username, 1000)
+	to := createBatchAccount(t, createRandomUser(t).Username, 0)
+	spender := addAccountMember(t, from, createRandomUser(t).Username, MemberSpender, 50)
+	viewer := addAccountMember(t, from, createRandomUser(t).Username, MemberViewer, 0)
+
+	result, err := testStore.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Owner:    spender.Username,
+		Currency: util.USD,
+		Mode:     BatchBestEffort,
+		Legs: []BatchTransferLeg{
+			{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 50},
+			{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 51},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, result.Legs[0].Err)
+	require.ErrorIs(t, result.Legs[1].Err, ErrSpendLimit)
+	requireBalance(t, from, 950)
+
+	_, err = testStore.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Owner:    viewer.Username,
+		Currency: util.USD,
+		Mode:     BatchAllOrNothing,
+		Legs:     []BatchTransferLeg{{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10}},
+	})
+	require.ErrorIs(t, err, ErrAccountNotOwned)
+}
+
+func TestBatchTransferTxSpendLimitTotal(t *testing.T) {
+	from := createBatchAccount(t, createRandomUser(t).Username, 1000)
+	other := createBatchAccount(t, from.Owner, 1000)
+	to := createBatchAccount(t, createRandomUser(t).Username, 0)
+	spender := addAccountMember(t, from, createRandomUser(t).Username, MemberSpender, 100)
+	addAccountMember(t, other, spender.Username, MemberSpender, 100)
+
+	// Every leg is under the limit, the third takes the total of the account over it
+	result, err := testStore.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Owner:    spender.Username,
+		Currency: util.USD,
+		Mode:     BatchBestEffort,
+		Legs: []BatchTransferLeg{
+			{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 40},
+			{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 40},
+			{FromAccountID: other.ID, ToAccountID: to.ID, Amount: 60},
+			{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 40},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, result.Legs[0].Err)
+	require.NoError(t, result.Legs[1].Err)
+	require.NoError(t, result.Legs[2].Err)
+	require.ErrorIs(t, result.Legs[3].Err, ErrSpendLimit)
+	requireBalance(t, from, 920)
+	requireBalance(t, other, 940)
+	require.Equal(t, pgtype.Text{String: spender.Username, Valid: true}, result.Legs[0].Transfer.InitiatedBy)
+
+	// The transfers of earlier batches count as well
+	_, err = testStore.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Owner:    spender.Username,
+		Currency: util.USD,
+		Mode:     BatchAllOrNothing,
+		Legs: []BatchTransferLeg{
+			{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10},
+			{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 11},
+		},
+	})
+	var legErr *BatchLegError
+	require.ErrorAs(t, err, &legErr)
+	require.Equal(t, 1, legErr.Index)
+	require.ErrorIs(t, err, ErrSpendLimit)
+	requireBalance(t, from, 920)
+
+	// And so do they for single transfers
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        21,
+		InitiatedBy:   spender.Username,
+	})
+	require.ErrorIs(t, err, ErrSpendLimit)
+}
+
+func TestTransferTxSpendLimit(t *testing.T) {
+	from := createBatchAccount(t, createRandomUser(t).Username, 1000)
+	to := createBatchAccount(t, createRandomUser(t).Username, 0)
+	spender := addAccountMember(t, from, createRandomUser(t).Username, MemberSpender, 100)
+	viewer := addAccountMember(t, from, createRandomUser(t).Username, MemberViewer, 0)
+
+	arg := TransferTxParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 60, InitiatedBy: spender.Username}
+	result, err := testStore.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, pgtype.Text{String: spender.Username, Valid: true}, result.Transfer.InitiatedBy)
+
+	_, err = testStore.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrSpendLimit)
+
+	arg.Amount = 40
+	_, err = testStore.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	requireBalance(t, from, 900)
+
+	// The limit is the spender's own, owners and transfers outside of a member are not held to it
+	for _, initiatedBy := range []string{from.Owner, ""} {
+		_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        200,
+			InitiatedBy:   initiatedBy,
+		})
+		require.NoError(t, err)
+	}
+
+	for _, username := range []string{viewer.Username, createRandomUser(t).Username} {
+		_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        1,
+			InitiatedBy:   username,
+		})
+		require.ErrorIs(t, err, ErrTransferNotAllowed)
+	}
+}
+
+func TestTransferTxSpendLimitConcurrent(t *testing.T) {
+	from := createBatchAccount(t, createRandomUser(t).Username, 1000)
+	to := createBatchAccount(t, createRandomUser(t).Username, 0)
+	spender := addAccountMember(t, from, createRandomUser(t).Username, MemberSpender, 100)
+
+	// The account lock makes each transfer see the ones before it, so only three fit
+	n := 5
+	errs := make(chan error, n)
+	for range n {
+		go func() {
+			_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: from.ID,
+				ToAccountID:   to.ID,
+				Amount:        30,
+				InitiatedBy:   spender.Username,
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for range n {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrSpendLimit)
+	}
+	require.Equal(t, 3, succeeded)
+	requireBalance(t, from, 910)
+}
+
+func TestAccountMemberPermissions(t *testing.T) {
+	accepted := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+
+	testCases := []struct {
+		member          AccountMember
+		transfer        bool
+		manageAccount   bool
+		manageSpenders  bool
+		manageCoOwners  bool
+		transferOverCap bool
+	}{
+		{AccountMember{Role: MemberOwner, AcceptedAt: accepted}, true, true, true, true, true},
+		{AccountMember{Role: MemberCoOwner, AcceptedAt: accepted}, true, false, true, false, true},
+		{AccountMember{Role: MemberSpender, SpendLimit: 100, AcceptedAt: accepted}, true, false, false, false, false},
+		{AccountMember{Role: MemberViewer, AcceptedAt: accepted}, false, false, false, false, false},
+		{AccountMember{Role: MemberOwner}, false, false, false, false, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.member.Role, func(t *testing.T) {
+			require.Equal(t, tc.transfer, tc.member.CanTransfer(100))
+			require.Equal(t, tc.transferOverCap, tc.member.CanTransfer(101))
+			require.Equal(t, tc.manageAccount, tc.member.CanManageAccount())
+			require.Equal(t, tc.manageSpenders, tc.member.CanManageRole(MemberSpender))
+			require.Equal(t, tc.manageCoOwners, tc.member.CanManageRole(MemberCoOwner))
+		})
+	}
+}
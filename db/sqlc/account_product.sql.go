@@ -0,0 +1,160 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: account_product.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createAccountProduct = `-- name: CreateAccountProduct :one
+INSERT INTO account_products (
+  code,
+  name,
+  allowed_currencies,
+  max_accounts_per_user,
+  min_balance,
+  max_monthly_withdrawals,
+  max_monthly_withdrawal_amount,
+  interest_rate_bps,
+  monthly_fee
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING code, name, enabled, allowed_currencies, max_accounts_per_user, min_balance, max_monthly_withdrawals, max_monthly_withdrawal_amount, interest_rate_bps, monthly_fee, created_at
+`
+
+type CreateAccountProductParams struct {
+	Code                       string   `json:"code"`
+	Name                       string   `json:"name"`
+	AllowedCurrencies          []string `json:"allowed_currencies"`
+	MaxAccountsPerUser         int32    `json:"max_accounts_per_user"`
+	MinBalance                 int64    `json:"min_balance"`
+	MaxMonthlyWithdrawals      int32    `json:"max_monthly_withdrawals"`
+	MaxMonthlyWithdrawalAmount int64    `json:"max_monthly_withdrawal_amount"`
+	InterestRateBps            int32    `json:"interest_rate_bps"`
+	MonthlyFee                 int64    `json:"monthly_fee"`
+}
+
+func (q *Queries) CreateAccountProduct(ctx context.Context, arg CreateAccountProductParams) (AccountProduct, error) {
+	row := q.db.QueryRow(ctx, createAccountProduct,
+		arg.Code,
+		arg.Name,
+		arg.AllowedCurrencies,
+		arg.MaxAccountsPerUser,
+		arg.MinBalance,
+		arg.MaxMonthlyWithdrawals,
+		arg.MaxMonthlyWithdrawalAmount,
+		arg.InterestRateBps,
+		arg.MonthlyFee,
+	)
+	var i AccountProduct
+	err := row.Scan(
+		&i.Code,
+		&i.Name,
+		&i.Enabled,
+		&i.AllowedCurrencies,
+		&i.MaxAccountsPerUser,
+		&i.MinBalance,
+		&i.MaxMonthlyWithdrawals,
+		&i.MaxMonthlyWithdrawalAmount,
+		&i.InterestRateBps,
+		&i.MonthlyFee,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAccountProduct = `-- name: GetAccountProduct :one
+SELECT code, name, enabled, allowed_currencies, max_accounts_per_user, min_balance, max_monthly_withdrawals, max_monthly_withdrawal_amount, interest_rate_bps, monthly_fee, created_at FROM account_products
+WHERE code = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountProduct(ctx context.Context, code string) (AccountProduct, error) {
+	row := q.db.QueryRow(ctx, getAccountProduct, code)
+	var i AccountProduct
+	err := row.Scan(
+		&i.Code,
+		&i.Name,
+		&i.Enabled,
+		&i.AllowedCurrencies,
+		&i.MaxAccountsPerUser,
+		&i.MinBalance,
+		&i.MaxMonthlyWithdrawals,
+		&i.MaxMonthlyWithdrawalAmount,
+		&i.InterestRateBps,
+		&i.MonthlyFee,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAccountProducts = `-- name: ListAccountProducts :many
+SELECT code, name, enabled, allowed_currencies, max_accounts_per_user, min_balance, max_monthly_withdrawals, max_monthly_withdrawal_amount, interest_rate_bps, monthly_fee, created_at FROM account_products
+ORDER BY code
+`
+
+func (q *Queries) ListAccountProducts(ctx context.Context) ([]AccountProduct, error) {
+	rows, err := q.db.Query(ctx, listAccountProducts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountProduct{}
+	for rows.Next() {
+		var i AccountProduct
+		if err := rows.Scan(
+			&i.Code,
+			&i.Name,
+			&i.Enabled,
+			&i.AllowedCurrencies,
+			&i.MaxAccountsPerUser,
+			&i.MinBalance,
+			&i.MaxMonthlyWithdrawals,
+			&i.MaxMonthlyWithdrawalAmount,
+			&i.InterestRateBps,
+			&i.MonthlyFee,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setAccountProductEnabled = `-- name: SetAccountProductEnabled :one
+UPDATE account_products
+SET enabled = $2
+WHERE code = $1
+RETURNING code, name, enabled, allowed_currencies, max_accounts_per_user, min_balance, max_monthly_withdrawals, max_monthly_withdrawal_amount, interest_rate_bps, monthly_fee, created_at
+`
+
+type SetAccountProductEnabledParams struct {
+	Code    string `json:"code"`
+	Enabled bool   `json:"enabled"`
+}
+
+func (q *Queries) SetAccountProductEnabled(ctx context.Context, arg SetAccountProductEnabledParams) (AccountProduct, error) {
+	row := q.db.QueryRow(ctx, setAccountProductEnabled, arg.Code, arg.Enabled)
+	var i AccountProduct
+	err := row.Scan(
+		&i.Code,
+		&i.Name,
+		&i.Enabled,
+		&i.AllowedCurrencies,
+		&i.MaxAccountsPerUser,
+		&i.MinBalance,
+		&i.MaxMonthlyWithdrawals,
+		&i.MaxMonthlyWithdrawalAmount,
+		&i.InterestRateBps,
+		&i.MonthlyFee,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -14,9 +14,11 @@ func createRandomAccount(t *testing.T) Account {
 	user := createRandomUser(t)
 
 	arg := CreateAccountParams{
-		Owner:    user.Username,
-		Balance:  util.RandomMoney(),
+		Owner: user.Username,
+		// Enough for the concurrent transfers of the store tests, which must not overdraw
+		Balance:  util.RandomInt(100, 1000),
 		Currency: util.RandomCurrency(),
+		Product:  DefaultAccountProduct,
 	}
 
 	account, err := testQueries.CreateAccount(context.Background(), arg)
@@ -0,0 +1,90 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: balance_adjustment.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createBalanceAdjustment = `-- name: CreateBalanceAdjustment :one
+INSERT INTO balance_adjustments (
+  account_id, entry_id, amount, reason, operator
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING id, account_id, entry_id, amount, reason, operator, created_at
+`
+
+type CreateBalanceAdjustmentParams struct {
+	AccountID int64  `json:"account_id"`
+	EntryID   int64  `json:"entry_id"`
+	Amount    int64  `json:"amount"`
+	Reason    string `json:"reason"`
+	Operator  string `json:"operator"`
+}
+
+func (q *Queries) CreateBalanceAdjustment(ctx context.Context, arg CreateBalanceAdjustmentParams) (BalanceAdjustment, error) {
+	row := q.db.QueryRow(ctx, createBalanceAdjustment,
+		arg.AccountID,
+		arg.EntryID,
+		arg.Amount,
+		arg.Reason,
+		arg.Operator,
+	)
+	var i BalanceAdjustment
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.EntryID,
+		&i.Amount,
+		&i.Reason,
+		&i.Operator,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listBalanceAdjustments = `-- name: ListBalanceAdjustments :many
+SELECT id, account_id, entry_id, amount, reason, operator, created_at FROM balance_adjustments
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListBalanceAdjustmentsParams struct {
+	AccountID int64 `json:"account_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (q *Queries) ListBalanceAdjustments(ctx context.Context, arg ListBalanceAdjustmentsParams) ([]BalanceAdjustment, error) {
+	rows, err := q.db.Query(ctx, listBalanceAdjustments, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []BalanceAdjustment{}
+	for rows.Next() {
+		var i BalanceAdjustment
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.EntryID,
+			&i.Amount,
+			&i.Reason,
+			&i.Operator,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createEmptyAccount(t *testing.T) Account {
+	user := createRandomUser(t)
+
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: util.RandomCurrency(),
+		Product:  DefaultAccountProduct,
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func TestAdjustBalanceTx(t *testing.T) {
+	store := testStore
+	account := createEmptyAccount(t)
+
+	arg := AdjustBalanceTxParams{
+		AccountID: account.ID,
+		Amount:    100,
+		Reason:    "opening deposit",
+		Operator:  util.RandomOwner(),
+	}
+
+	result, err := store.AdjustBalanceTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(100), result.Account.Balance)
+	require.Equal(t, account.ID, result.Entry.AccountID)
+	require.Equal(t, arg.Amount, result.Entry.Amount)
+	require.Equal(t, result.Entry.ID, result.Adjustment.EntryID)
+	require.Equal(t, arg.Reason, result.Adjustment.Reason)
+	require.Equal(t, arg.Operator, result.Adjustment.Operator)
+
+	// Debits are fine as long as the balance stays positive
+	arg.Amount = -40
+	result, err = store.AdjustBalanceTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(60), result.Account.Balance)
+
+	arg.Amount = -61
+	_, err = store.AdjustBalanceTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInsufficientBalance)
+
+	arg.Amount = 10
+	arg.Reason = ""
+	_, err = store.AdjustBalanceTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrEmptyAdjustmentReason)
+
+	adjustments, err := testQueries.ListBalanceAdjustments(context.Background(), ListBalanceAdjustmentsParams{
+		AccountID: account.ID,
+		Limit:     10,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, adjustments, 2)
+}
+
+func TestListUnreconciledAccounts(t *testing.T) {
+	store := testStore
+	account := createEmptyAccount(t)
+
+	_, err := store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID: account.ID,
+		Amount:    100,
+		Reason:    "opening deposit",
+		Operator:  util.RandomOwner(),
+	})
+	require.NoError(t, err)
+
+	findAccount := func() (ListUnreconciledAccountsRow, bool) {
+		rows, err := testQueries.ListUnreconciledAccounts(context.Background())
+		require.NoError(t, err)
+		for _, row := range rows {
+			if row.ID == account.ID {
+				return row, true
+			}
+		}
+		return ListUnreconciledAccountsRow{}, false
+	}
+
+	_, found := findAccount()
+	require.False(t, found)
+
+	// A balance changed without a ledger entry shows up
+	_, err = testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: 50,
+	})
+	require.NoError(t, err)
+
+	row, found := findAccount()
+	require.True(t, found)
+	require.Equal(t, int64(50), row.Balance)
+	require.Equal(t, int64(100), row.EntriesTotal)
+}
@@ -0,0 +1,79 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: copyfrom.go
+
+package db
+
+import (
+	"context"
+)
+
+// iteratorForCreateEntries implements pgx.CopyFromSource.
+type iteratorForCreateEntries struct {
+	rows                 []CreateEntriesParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCreateEntries) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCreateEntries) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].AccountID,
+		r.rows[0].Amount,
+	}, nil
+}
+
+func (r iteratorForCreateEntries) Err() error {
+	return nil
+}
+
+func (q *Queries) CreateEntries(ctx context.Context, arg []CreateEntriesParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"entries"}, []string{"account_id", "amount"}, &iteratorForCreateEntries{rows: arg})
+}
+
+// iteratorForCreateTransfers implements pgx.CopyFromSource.
+type iteratorForCreateTransfers struct {
+	rows                 []CreateTransfersParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCreateTransfers) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCreateTransfers) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].BatchID,
+		r.rows[0].FromAccountID,
+		r.rows[0].ToAccountID,
+		r.rows[0].Amount,
+		r.rows[0].InitiatedBy,
+	}, nil
+}
+
+func (r iteratorForCreateTransfers) Err() error {
+	return nil
+}
+
+func (q *Queries) CreateTransfers(ctx context.Context, arg []CreateTransfersParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"transfers"}, []string{"batch_id", "from_account_id", "to_account_id", "amount", "initiated_by"}, &iteratorForCreateTransfers{rows: arg})
+}
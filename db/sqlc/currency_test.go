@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCurrencies(t *testing.T) {
+	currencies, err := testQueries.ListCurrencies(context.Background())
+	require.NoError(t, err)
+
+	codes := make(map[string]Currency)
+	for _, currency := range currencies {
+		codes[currency.Code] = currency
+	}
+	for _, code := range []string{util.USD, util.EUR, util.INR} {
+		require.Contains(t, codes, code)
+		require.Equal(t, int16(2), codes[code].Exponent)
+		require.NotEmpty(t, codes[code].DisplayName)
+	}
+}
+
+func TestSetCurrencyEnabled(t *testing.T) {
+	currency, err := testQueries.SetCurrencyEnabled(context.Background(), SetCurrencyEnabledParams{Code: util.INR, Enabled: false})
+	require.NoError(t, err)
+	require.False(t, currency.Enabled)
+
+	currency, err = testQueries.SetCurrencyEnabled(context.Background(), SetCurrencyEnabledParams{Code: util.INR, Enabled: true})
+	require.NoError(t, err)
+	require.True(t, currency.Enabled)
+
+	currency, err = testQueries.GetCurrency(context.Background(), util.INR)
+	require.NoError(t, err)
+	require.True(t, currency.Enabled)
+
+	_, err = testQueries.SetCurrencyEnabled(context.Background(), SetCurrencyEnabledParams{Code: "XYZ", Enabled: true})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestCreateAccountUnknownCurrency(t *testing.T) {
+	user := createRandomUser(t)
+
+	_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Currency: "XYZ",
+		Product:  DefaultAccountProduct,
+	})
+	requirePgError(t, err, "23503", "accounts_currency_fkey")
+}
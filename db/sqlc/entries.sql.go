@@ -9,6 +9,11 @@ import (
 	"context"
 )
 
+type CreateEntriesParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
     account_id, amount
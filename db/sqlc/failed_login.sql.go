@@ -0,0 +1,75 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: failed_login.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countRecentFailedLoginsByIP = `-- name: CountRecentFailedLoginsByIP :one
+SELECT count(*) FROM failed_logins
+WHERE client_ip = $1 AND created_at > $2
+`
+
+type CountRecentFailedLoginsByIPParams struct {
+	ClientIp string             `json:"client_ip"`
+	Since    pgtype.Timestamptz `json:"since"`
+}
+
+func (q *Queries) CountRecentFailedLoginsByIP(ctx context.Context, arg CountRecentFailedLoginsByIPParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countRecentFailedLoginsByIP, arg.ClientIp, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createFailedLogin = `-- name: CreateFailedLogin :one
+INSERT INTO failed_logins (
+    username, client_ip
+) VALUES (
+  $1, $2
+)
+RETURNING id, username, client_ip, created_at
+`
+
+type CreateFailedLoginParams struct {
+	Username string `json:"username"`
+	ClientIp string `json:"client_ip"`
+}
+
+func (q *Queries) CreateFailedLogin(ctx context.Context, arg CreateFailedLoginParams) (FailedLogin, error) {
+	row := q.db.QueryRow(ctx, createFailedLogin, arg.Username, arg.ClientIp)
+	var i FailedLogin
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.ClientIp,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getFailedLoginsByUsername = `-- name: GetFailedLoginsByUsername :one
+SELECT
+  count(*)::integer AS failed_attempts,
+  max(created_at)::timestamptz AS last_failed_at
+FROM failed_logins
+WHERE username = $1
+`
+
+type GetFailedLoginsByUsernameRow struct {
+	FailedAttempts int32              `json:"failed_attempts"`
+	LastFailedAt   pgtype.Timestamptz `json:"last_failed_at"`
+}
+
+func (q *Queries) GetFailedLoginsByUsername(ctx context.Context, username string) (GetFailedLoginsByUsernameRow, error) {
+	row := q.db.QueryRow(ctx, getFailedLoginsByUsername, username)
+	var i GetFailedLoginsByUsernameRow
+	err := row.Scan(&i.FailedAttempts, &i.LastFailedAt)
+	return i, err
+}
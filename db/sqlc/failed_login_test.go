@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordFailedLoginTx(t *testing.T) {
+	store := testStore
+	user := createRandomUser(t)
+	clientIP := "10.0.0." + util.RandomString(3)
+
+	arg := RecordFailedLoginTxParams{
+		Username:        user.Username,
+		ClientIP:        clientIP,
+		LockoutDuration: util.LockoutDuration,
+	}
+
+	for i := 0; i < util.MaxFailedLoginsPerUser-1; i++ {
+		err := store.RecordFailedLoginTx(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	user, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int32(util.MaxFailedLoginsPerUser-1), user.FailedLoginAttempts)
+	require.True(t, user.LockedUntil.Time.Before(time.Now()))
+
+	err = store.RecordFailedLoginTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	user, err = testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(util.LockoutDuration(util.MaxFailedLoginsPerUser)), user.LockedUntil.Time, time.Second)
+
+	since := pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+	count, err := testQueries.CountRecentFailedLoginsByIP(context.Background(), CountRecentFailedLoginsByIPParams{
+		ClientIp: clientIP,
+		Since:    since,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(util.MaxFailedLoginsPerUser), count)
+
+	user, err = testQueries.ResetUserFailedLogins(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Zero(t, user.FailedLoginAttempts)
+	require.True(t, user.LockedUntil.Time.Before(time.Now()))
+}
+
+func TestRecordFailedLoginTxUnknownUser(t *testing.T) {
+	store := testStore
+	username := util.RandomOwner() + util.RandomString(6)
+
+	err := store.RecordFailedLoginTx(context.Background(), RecordFailedLoginTxParams{
+		Username:        username,
+		ClientIP:        "10.0.0.1",
+		LockoutDuration: util.LockoutDuration,
+	})
+	require.NoError(t, err)
+
+	failures, err := testQueries.GetFailedLoginsByUsername(context.Background(), username)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failures.FailedAttempts)
+	require.WithinDuration(t, time.Now(), failures.LastFailedAt.Time, time.Second)
+
+	failures, err = testQueries.GetFailedLoginsByUsername(context.Background(), util.RandomOwner()+util.RandomString(6))
+	require.NoError(t, err)
+	require.Zero(t, failures.FailedAttempts)
+	require.False(t, failures.LastFailedAt.Valid)
+}
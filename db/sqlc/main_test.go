@@ -5,31 +5,38 @@ import (
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 )
 
-var testQueries *Queries
-var testDB *pgxpool.Pool
+var testQueries Querier
+var testStore Store
 
 func TestMain(m *testing.M) {
 	// Try loading .env file, ignore error if file doesn't exist (github actions)
 	_ = godotenv.Load("../../.env")
 
-	// Get DB connection string from environment variable
+	// Get DB connection string from environment variable, without one
+	// the tests run against the in-memory store
 	dbSource := os.Getenv("DB_SOURCE")
 	if dbSource == "" {
-		log.Fatal("DB_SOURCE environment variable is not set")
+		log.Println("DB_SOURCE is not set, testing the in-memory store")
+		testStore = NewMemoryStore()
+	} else {
+		testDB, err := pgxpool.New(context.Background(), dbSource)
+		if err != nil {
+			log.Fatal("cannot connect to db:", err)
+		}
+		testStore = NewStore(testDB, TxConfig{
+			MaxRetries:     3,
+			RetryBaseDelay: 10 * time.Millisecond,
+			RetryMaxDelay:  200 * time.Millisecond,
+		})
 	}
 
-	var err error
-	testDB, err = pgxpool.New(context.Background(), dbSource)
-	if err != nil {
-		log.Fatal("cannot connect to db:", err)
-	}
-
-	testQueries = New(testDB)
+	testQueries = testStore
 
 	os.Exit(m.Run())
 }
@@ -0,0 +1,1394 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// The queries of db/query on the tables of a MemoryStore, in the same order as the SQL files
+
+// selectRows returns the rows matching where, ordered by their primary key
+func selectRows[K cmp.Ordered, V any](table map[K]V, where func(row V) bool) []V {
+	var keys []K
+	for key, row := range table {
+		if where(row) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	rows := make([]V, 0, len(keys))
+	for _, key := range keys {
+		rows = append(rows, table[key])
+	}
+	return rows
+}
+
+// paginate applies LIMIT and OFFSET
+func paginate[V any](rows []V, limit int32, offset int32) ([]V, error) {
+	if limit < 0 {
+		return nil, negativeLimit("2201W", "LIMIT")
+	}
+	if offset < 0 {
+		return nil, negativeLimit("2201X", "OFFSET")
+	}
+
+	if int(offset) >= len(rows) {
+		return []V{}, nil
+	}
+	rows = rows[offset:]
+	if int(limit) < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+// account.sql
+
+func (q *memoryQueries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	var account Account
+	err := q.write(func(tables *memoryTables) error {
+		if _, ok := tables.users[arg.Owner]; !ok {
+			return foreignKeyViolation("accounts", "accounts_owner_fkey")
+		}
+		if _, ok := tables.currencies[arg.Currency]; !ok {
+			return foreignKeyViolation("accounts", "accounts_currency_fkey")
+		}
+		if _, ok := tables.accountProducts[arg.Product]; !ok {
+			return foreignKeyViolation("accounts", "accounts_product_fkey")
+		}
+		if arg.Balance < 0 {
+			return checkViolation("accounts", accountsBalanceCheck)
+		}
+
+		account = Account{
+			ID:        tables.nextID("accounts"),
+			Owner:     arg.Owner,
+			Currency:  arg.Currency,
+			CreatedAt: q.now(),
+			Balance:   arg.Balance,
+			Product:   arg.Product,
+		}
+		putRow(q, tables.accounts, account.ID, account)
+		return nil
+	})
+	return account, err
+}
+
+func (q *memoryQueries) GetAccount(ctx context.Context, id int64) (Account, error) {
+	var account Account
+	err := q.read(func(tables *memoryTables) error {
+		var ok bool
+		if account, ok = tables.accounts[id]; !ok {
+			return pgx.ErrNoRows
+		}
+		return nil
+	})
+	return account, err
+}
+
+// GetAccountForUpdate needs no row lock, transactions already run one at a time
+func (q *memoryQueries) GetAccountForUpdate(ctx context.Context, id int64) (Account, error) {
+	return q.GetAccount(ctx, id)
+}
+
+func (q *memoryQueries) GetAccountsForUpdate(ctx context.Context, ids []int64) ([]Account, error) {
+	var accounts []Account
+	err := q.read(func(tables *memoryTables) error {
+		accounts = selectRows(tables.accounts, func(account Account) bool {
+			return slices.Contains(ids, account.ID)
+		})
+		return nil
+	})
+	return accounts, err
+}
+
+func (q *memoryQueries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	var accounts []Account
+	err := q.read(func(tables *memoryTables) error {
+		var err error
+		accounts, err = paginate(selectRows(tables.accounts, func(account Account) bool {
+			return account.Owner == arg.Owner
+		}), arg.Limit, arg.Offset)
+		return err
+	})
+	return accounts, err
+}
+
+func (q *memoryQueries) ListMemberAccounts(ctx context.Context, arg ListMemberAccountsParams) ([]Account, error) {
+	var accounts []Account
+	err := q.read(func(tables *memoryTables) error {
+		var err error
+		accounts, err = paginate(selectRows(tables.accounts, func(account Account) bool {
+			member, ok := tables.accountMembers[accountMemberKey{account.ID, arg.Username}]
+			return ok && member.AcceptedAt.Valid
+		}), arg.Limit, arg.Offset)
+		return err
+	})
+	return accounts, err
+}
+
+func (q *memoryQueries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
+	var account Account
+	err := q.write(func(tables *memoryTables) error {
+		var ok bool
+		if account, ok = tables.accounts[arg.ID]; !ok {
+			return pgx.ErrNoRows
+		}
+		if arg.Balance < 0 {
+			return checkViolation("accounts", accountsBalanceCheck)
+		}
+
+		account.Balance = arg.Balance
+		putRow(q, tables.accounts, account.ID, account)
+		return nil
+	})
+	return account, err
+}
+
+func (q *memoryQueries) CountAccountsByProduct(ctx context.Context, arg CountAccountsByProductParams) (int64, error) {
+	var count int64
+	err := q.read(func(tables *memoryTables) error {
+		for _, account := range tables.accounts {
+			if account.Owner == arg.Owner && account.Product == arg.Product {
+				count++
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+func (q *memoryQueries) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
+	var account Account
+	err := q.write(func(tables *memoryTables) error {
+		var ok bool
+		if account, ok = tables.accounts[arg.ID]; !ok {
+			return pgx.ErrNoRows
+		}
+		if account.Balance+arg.Amount < 0 {
+			return checkViolation("accounts", accountsBalanceCheck)
+		}
+
+		account.Balance += arg.Amount
+		putRow(q, tables.accounts, account.ID, account)
+		return nil
+	})
+	return account, err
+}
+
+func (q *memoryQueries) DeleteAccount(ctx context.Context, id int64) error {
+	return q.write(func(tables *memoryTables) error {
+		for _, entry := range tables.entries {
+			if entry.AccountID == id {
+				return foreignKeyReferenced("accounts", "entries_account_id_fkey", "entries")
+			}
+		}
+		for _, transfer := range tables.transfers {
+			if transfer.FromAccountID == id {
+				return foreignKeyReferenced("accounts", "transfers_from_account_id_fkey", "transfers")
+			}
+			if transfer.ToAccountID == id {
+				return foreignKeyReferenced("accounts", "transfers_to_account_id_fkey", "transfers")
+			}
+		}
+		for _, adjustment := range tables.balanceAdjustments {
+			if adjustment.AccountID == id {
+				return foreignKeyReferenced("accounts", "balance_adjustments_account_id_fkey", "balance_adjustments")
+			}
+		}
+		for key := range tables.productPostings {
+			if key.accountID == id {
+				return foreignKeyReferenced("accounts", "product_postings_account_id_fkey", "product_postings")
+			}
+		}
+
+		// ON DELETE CASCADE
+		for key := range tables.accountMembers {
+			if key.accountID == id {
+				deleteRow(q, tables.accountMembers, key)
+			}
+		}
+		deleteRow(q, tables.accounts, id)
+		return nil
+	})
+}
+
+func (q *memoryQueries) ListUnreconciledAccounts(ctx context.Context) ([]ListUnreconciledAccountsRow, error) {
+	rows := []ListUnreconciledAccountsRow{}
+	err := q.read(func(tables *memoryTables) error {
+		entriesTotals := make(map[int64]int64)
+		for _, entry := range tables.entries {
+			entriesTotals[entry.AccountID] += entry.Amount
+		}
+
+		for _, account := range selectRows(tables.accounts, func(account Account) bool {
+			return account.Balance != entriesTotals[account.ID]
+		}) {
+			rows = append(rows, ListUnreconciledAccountsRow{
+				ID:           account.ID,
+				Owner:        account.Owner,
+				Currency:     account.Currency,
+				Balance:      account.Balance,
+				EntriesTotal: entriesTotals[account.ID],
+			})
+		}
+		return nil
+	})
+	return rows, err
+}
+
+// account_member.sql
+
+const (
+	accountMembersRoleCheck       = "account_members_role_check"
+	accountMembersSpendLimitCheck = "account_members_spend_limit_check"
+)
+
+// selectAccountMembers returns the members matching where, ordered by account and username
+func selectAccountMembers(tables *memoryTables, where func(member AccountMember) bool) []AccountMember {
+	var members []AccountMember
+	for _, member := range tables.accountMembers {
+		if where(member) {
+			members = append(members, member)
+		}
+	}
+	slices.SortFunc(members, func(a, b AccountMember) int {
+		return cmp.Or(cmp.Compare(a.AccountID, b.AccountID), cmp.Compare(a.Username, b.Username))
+	})
+	return members
+}
+
+func checkAccountMember(role string, spendLimit int64) error {
+	switch role {
+	case MemberOwner, MemberCoOwner, MemberViewer, MemberSpender:
+	default:
+		return checkViolation("account_members", accountMembersRoleCheck)
+	}
+	if spendLimit < 0 || (role != MemberSpender && spendLimit != 0) {
+		return checkViolation("account_members", accountMembersSpendLimitCheck)
+	}
+	return nil
+}
+
+func (q *memoryQueries) CreateAccountMember(ctx context.Context, arg CreateAccountMemberParams) (AccountMember, error) {
+	var member AccountMember
+	err := q.write(func(tables *memoryTables) error {
+		key := accountMemberKey{arg.AccountID, arg.Username}
+		if _, ok := tables.accountMembers[key]; ok {
+			return uniqueViolation("account_members_pkey")
+		}
+		if err := checkAccountMember(arg.Role, arg.SpendLimit); err != nil {
+			return err
+		}
+		if _, ok := tables.accounts[arg.AccountID]; !ok {
+			return foreignKeyViolation("account_members", "account_members_account_id_fkey")
+		}
+		if _, ok := tables.users[arg.Username]; !ok {
+			return foreignKeyViolation("account_members", "account_members_username_fkey")
+		}
+		if _, ok := tables.users[arg.InvitedBy]; !ok {
+			return foreignKeyViolation("account_members", "account_members_invited_by_fkey")
+		}
+
+		member = AccountMember{
+			AccountID:  arg.AccountID,
+			Username:   arg.Username,
+			Role:       arg.Role,
+			SpendLimit: arg.SpendLimit,
+			InvitedBy:  arg.InvitedBy,
+			AcceptedAt: memoryTimestamp(arg.AcceptedAt),
+			CreatedAt:  q.now(),
+		}
+		putRow(q, tables.accountMembers, key, member)
+		return nil
+	})
+	return member, err
+}
+
+func (q *memoryQueries) GetAccountMember(ctx context.Context, arg GetAccountMemberParams) (AccountMember, error) {
+	var member AccountMember
+	err := q.read(func(tables *memoryTables) error {
+		var ok bool
+		if member, ok = tables.accountMembers[accountMemberKey{arg.AccountID, arg.Username}]; !ok {
+			return pgx.ErrNoRows
+		}
+		return nil
+	})
+	return member, err
+}
+
+func (q *memoryQueries) ListAccountMembers(ctx context.Context, accountID int64) ([]AccountMember, error) {
+	var members []AccountMember
+	err := q.read(func(tables *memoryTables) error {
+		members = selectAccountMembers(tables, func(member AccountMember) bool {
+			return member.AccountID == accountID
+		})
+		return nil
+	})
+	return members, err
+}
+
+func (q *memoryQueries) ListPendingInvitations(ctx context.Context, username string) ([]AccountMember, error) {
+	var members []AccountMember
+	err := q.read(func(tables *memoryTables) error {
+		members = selectAccountMembers(tables, func(member AccountMember) bool {
+			return member.Username == username && !member.AcceptedAt.Valid
+		})
+		return nil
+	})
+	return members, err
+}
+
+func (q *memoryQueries) AcceptAccountInvitation(ctx context.Context, arg AcceptAccountInvitationParams) (AccountMember, error) {
+	var member AccountMember
+	err := q.write(func(tables *memoryTables) error {
+		key := accountMemberKey{arg.AccountID, arg.Username}
+		var ok bool
+		if member, ok = tables.accountMembers[key]; !ok || member.AcceptedAt.Valid {
+			return pgx.ErrNoRows
+		}
+
+		member.AcceptedAt = q.now()
+		putRow(q, tables.accountMembers, key, member)
+		return nil
+	})
+	return member, err
+}
+
+func (q *memoryQueries) UpdateAccountMemberRole(ctx context.Context, arg UpdateAccountMemberRoleParams) (AccountMember, error) {
+	var member AccountMember
+	err := q.write(func(tables *memoryTables) error {
+		key := accountMemberKey{arg.AccountID, arg.Username}
+		var ok bool
+		if member, ok = tables.accountMembers[key]; !ok {
+			return pgx.ErrNoRows
+		}
+		if err := checkAccountMember(arg.Role, arg.SpendLimit); err != nil {
+			return err
+		}
+
+		member.Role = arg.Role
+		member.SpendLimit = arg.SpendLimit
+		putRow(q, tables.accountMembers, key, member)
+		return nil
+	})
+	return member, err
+}
+
+func (q *memoryQueries) DeleteAccountMember(ctx context.Context, arg DeleteAccountMemberParams) error {
+	return q.write(func(tables *memoryTables) error {
+		deleteRow(q, tables.accountMembers, accountMemberKey{arg.AccountID, arg.Username})
+		return nil
+	})
+}
+
+func (q *memoryQueries) CountAccountOwners(ctx context.Context, accountID int64) (int64, error) {
+	var count int64
+	err := q.read(func(tables *memoryTables) error {
+		for _, member := range tables.accountMembers {
+			if member.AccountID == accountID && member.Role == MemberOwner && member.AcceptedAt.Valid {
+				count++
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// account_product.sql
+
+const accountProductsLimitsCheck = "account_products_limits_check"
+
+func (q *memoryQueries) CreateAccountProduct(ctx context.Context, arg CreateAccountProductParams) (AccountProduct, error) {
+	var product AccountProduct
+	err := q.write(func(tables *memoryTables) error {
+		if _, ok := tables.accountProducts[arg.Code]; ok {
+			return uniqueViolation("account_products_pkey")
+		}
+		if arg.MaxAccountsPerUser < 0 || arg.MinBalance < 0 || arg.MaxMonthlyWithdrawals < 0 ||
+			arg.MaxMonthlyWithdrawalAmount < 0 || arg.InterestRateBps < 0 || arg.MonthlyFee < 0 {
+			return checkViolation("account_products", accountProductsLimitsCheck)
+		}
+
+		allowedCurrencies := slices.Clone(arg.AllowedCurrencies)
+		if allowedCurrencies == nil {
+			allowedCurrencies = []string{}
+		}
+		product = AccountProduct{
+			Code:                       arg.Code,
+			Name:                       arg.Name,
+			Enabled:                    true,
+			AllowedCurrencies:          allowedCurrencies,
+			MaxAccountsPerUser:         arg.MaxAccountsPerUser,
+			MinBalance:                 arg.MinBalance,
+			MaxMonthlyWithdrawals:      arg.MaxMonthlyWithdrawals,
+			MaxMonthlyWithdrawalAmount: arg.MaxMonthlyWithdrawalAmount,
+			InterestRateBps:            arg.InterestRateBps,
+			MonthlyFee:                 arg.MonthlyFee,
+			CreatedAt:                  q.now(),
+		}
+		putRow(q, tables.accountProducts, product.Code, product)
+		return nil
+	})
+	return product, err
+}
+
+func (q *memoryQueries) GetAccountProduct(ctx context.Context, code string) (AccountProduct, error) {
+	var product AccountProduct
+	err := q.read(func(tables *memoryTables) error {
+		var ok bool
+		if product, ok = tables.accountProducts[code]; !ok {
+			return pgx.ErrNoRows
+		}
+		return nil
+	})
+	return product, err
+}
+
+func (q *memoryQueries) ListAccountProducts(ctx context.Context) ([]AccountProduct, error) {
+	var products []AccountProduct
+	err := q.read(func(tables *memoryTables) error {
+		products = selectRows(tables.accountProducts, func(AccountProduct) bool { return true })
+		return nil
+	})
+	return products, err
+}
+
+func (q *memoryQueries) SetAccountProductEnabled(ctx context.Context, arg SetAccountProductEnabledParams) (AccountProduct, error) {
+	var product AccountProduct
+	err := q.write(func(tables *memoryTables) error {
+		var ok bool
+		if product, ok = tables.accountProducts[arg.Code]; !ok {
+			return pgx.ErrNoRows
+		}
+
+		product.Enabled = arg.Enabled
+		putRow(q, tables.accountProducts, product.Code, product)
+		return nil
+	})
+	return product, err
+}
+
+// balance_adjustment.sql
+
+func (q *memoryQueries) CreateBalanceAdjustment(ctx context.Context, arg CreateBalanceAdjustmentParams) (BalanceAdjustment, error) {
+	var adjustment BalanceAdjustment
+	err := q.write(func(tables *memoryTables) error {
+		if _, ok := tables.accounts[arg.AccountID]; !ok {
+			return foreignKeyViolation("balance_adjustments", "balance_adjustments_account_id_fkey")
+		}
+		if _, ok := tables.entries[arg.EntryID]; !ok {
+			return foreignKeyViolation("balance_adjustments", "balance_adjustments_entry_id_fkey")
+		}
+
+		adjustment = BalanceAdjustment{
+			ID:        tables.nextID("balance_adjustments"),
+			AccountID: arg.AccountID,
+			EntryID:   arg.EntryID,
+			Amount:    arg.Amount,
+			Reason:    arg.Reason,
+			Operator:  arg.Operator,
+			CreatedAt: q.now(),
+		}
+		putRow(q, tables.balanceAdjustments, adjustment.ID, adjustment)
+		return nil
+	})
+	return adjustment, err
+}
+
+func (q *memoryQueries) ListBalanceAdjustments(ctx context.Context, arg ListBalanceAdjustmentsParams) ([]BalanceAdjustment, error) {
+	var adjustments []BalanceAdjustment
+	err := q.read(func(tables *memoryTables) error {
+		var err error
+		adjustments, err = paginate(selectRows(tables.balanceAdjustments, func(adjustment BalanceAdjustment) bool {
+			return adjustment.AccountID == arg.AccountID
+		}), arg.Limit, arg.Offset)
+		return err
+	})
+	return adjustments, err
+}
+
+// currency.sql
+
+func (q *memoryQueries) GetCurrency(ctx context.Context, code string) (Currency, error) {
+	var currency Currency
+	err := q.read(func(tables *memoryTables) error {
+		var ok bool
+		if currency, ok = tables.currencies[code]; !ok {
+			return pgx.ErrNoRows
+		}
+		return nil
+	})
+	return currency, err
+}
+
+func (q *memoryQueries) ListCurrencies(ctx context.Context) ([]Currency, error) {
+	var currencies []Currency
+	err := q.read(func(tables *memoryTables) error {
+		currencies = selectRows(tables.currencies, func(Currency) bool { return true })
+		return nil
+	})
+	return currencies, err
+}
+
+func (q *memoryQueries) SetCurrencyEnabled(ctx context.Context, arg SetCurrencyEnabledParams) (Currency, error) {
+	var currency Currency
+	err := q.write(func(tables *memoryTables) error {
+		var ok bool
+		if currency, ok = tables.currencies[arg.Code]; !ok {
+			return pgx.ErrNoRows
+		}
+
+		currency.Enabled = arg.Enabled
+		currency.UpdatedAt = q.now()
+		putRow(q, tables.currencies, currency.Code, currency)
+		return nil
+	})
+	return currency, err
+}
+
+// entries.sql
+
+func (q *memoryQueries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
+	var entry Entry
+	err := q.write(func(tables *memoryTables) error {
+		if _, ok := tables.accounts[arg.AccountID]; !ok {
+			return foreignKeyViolation("entries", "entries_account_id_fkey")
+		}
+
+		entry = Entry{
+			ID:        tables.nextID("entries"),
+			AccountID: arg.AccountID,
+			Amount:    arg.Amount,
+			CreatedAt: q.now(),
+		}
+		putRow(q, tables.entries, entry.ID, entry)
+		return nil
+	})
+	return entry, err
+}
+
+// CreateEntries inserts every row or, like COPY, none of them
+func (q *memoryQueries) CreateEntries(ctx context.Context, arg []CreateEntriesParams) (int64, error) {
+	err := q.write(func(tables *memoryTables) error {
+		for _, row := range arg {
+			if _, ok := tables.accounts[row.AccountID]; !ok {
+				return foreignKeyViolation("entries", "entries_account_id_fkey")
+			}
+		}
+
+		for _, row := range arg {
+			entry := Entry{
+				ID:        tables.nextID("entries"),
+				AccountID: row.AccountID,
+				Amount:    row.Amount,
+				CreatedAt: q.now(),
+			}
+			putRow(q, tables.entries, entry.ID, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(arg)), nil
+}
+
+func (q *memoryQueries) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	var entry Entry
+	err := q.read(func(tables *memoryTables) error {
+		var ok bool
+		if entry, ok = tables.entries[id]; !ok {
+			return pgx.ErrNoRows
+		}
+		return nil
+	})
+	return entry, err
+}
+
+func (q *memoryQueries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	var entries []Entry
+	err := q.read(func(tables *memoryTables) error {
+		var err error
+		entries, err = paginate(selectRows(tables.entries, func(entry Entry) bool {
+			return entry.AccountID == arg.AccountID
+		}), arg.Limit, arg.Offset)
+		return err
+	})
+	return entries, err
+}
+
+func (q *memoryQueries) UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry, error) {
+	var entry Entry
+	err := q.write(func(tables *memoryTables) error {
+		var ok bool
+		if entry, ok = tables.entries[arg.ID]; !ok {
+			return pgx.ErrNoRows
+		}
+
+		entry.Amount = arg.Amount
+		putRow(q, tables.entries, entry.ID, entry)
+		return nil
+	})
+	return entry, err
+}
+
+func (q *memoryQueries) DeleteEntry(ctx context.Context, id int64) error {
+	return q.write(func(tables *memoryTables) error {
+		for _, adjustment := range tables.balanceAdjustments {
+			if adjustment.EntryID == id {
+				return foreignKeyReferenced("entries", "balance_adjustments_entry_id_fkey", "balance_adjustments")
+			}
+		}
+
+		deleteRow(q, tables.entries, id)
+		return nil
+	})
+}
+
+// failed_login.sql
+
+func (q *memoryQueries) CreateFailedLogin(ctx context.Context, arg CreateFailedLoginParams) (FailedLogin, error) {
+	var failedLogin FailedLogin
+	err := q.write(func(tables *memoryTables) error {
+		failedLogin = FailedLogin{
+			ID:        tables.nextID("failed_logins"),
+			Username:  arg.Username,
+			ClientIp:  arg.ClientIp,
+			CreatedAt: q.now(),
+		}
+		putRow(q, tables.failedLogins, failedLogin.ID, failedLogin)
+		return nil
+	})
+	return failedLogin, err
+}
+
+func (q *memoryQueries) CountRecentFailedLoginsByIP(ctx context.Context, arg CountRecentFailedLoginsByIPParams) (int64, error) {
+	return q.countFailedLogins(func(failedLogin FailedLogin) bool {
+		return failedLogin.ClientIp == arg.ClientIp && failedLogin.CreatedAt.Time.After(arg.Since.Time)
+	})
+}
+
+func (q *memoryQueries) GetFailedLoginsByUsername(ctx context.Context, username string) (GetFailedLoginsByUsernameRow, error) {
+	var row GetFailedLoginsByUsernameRow
+	err := q.read(func(tables *memoryTables) error {
+		for _, failedLogin := range tables.failedLogins {
+			if failedLogin.Username != username {
+				continue
+			}
+			row.FailedAttempts++
+			if failedLogin.CreatedAt.Time.After(row.LastFailedAt.Time) {
+				row.LastFailedAt = failedLogin.CreatedAt
+			}
+		}
+		return nil
+	})
+	return row, err
+}
+
+func (q *memoryQueries) countFailedLogins(where func(failedLogin FailedLogin) bool) (int64, error) {
+	var count int64
+	err := q.read(func(tables *memoryTables) error {
+		for _, failedLogin := range tables.failedLogins {
+			if where(failedLogin) {
+				count++
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// password_reset.sql
+
+func (q *memoryQueries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error) {
+	var resetToken PasswordResetToken
+	err := q.write(func(tables *memoryTables) error {
+		for _, other := range tables.passwordResetTokens {
+			if other.TokenHash == arg.TokenHash {
+				return uniqueViolation("password_reset_tokens_token_hash_key")
+			}
+		}
+		if _, ok := tables.users[arg.Username]; !ok {
+			return foreignKeyViolation("password_reset_tokens", "password_reset_tokens_username_fkey")
+		}
+
+		resetToken = PasswordResetToken{
+			ID:        tables.nextID("password_reset_tokens"),
+			Username:  arg.Username,
+			TokenHash: arg.TokenHash,
+			CreatedAt: q.now(),
+			ExpiresAt: memoryTimestamp(arg.ExpiresAt),
+		}
+		putRow(q, tables.passwordResetTokens, resetToken.ID, resetToken)
+		return nil
+	})
+	return resetToken, err
+}
+
+func (q *memoryQueries) GetPasswordResetTokenForUpdate(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	var resetToken PasswordResetToken
+	err := q.read(func(tables *memoryTables) error {
+		for _, other := range tables.passwordResetTokens {
+			if other.TokenHash == tokenHash {
+				resetToken = other
+				return nil
+			}
+		}
+		return pgx.ErrNoRows
+	})
+	return resetToken, err
+}
+
+func (q *memoryQueries) MarkPasswordResetTokenUsed(ctx context.Context, id int64) (PasswordResetToken, error) {
+	var resetToken PasswordResetToken
+	err := q.write(func(tables *memoryTables) error {
+		var ok bool
+		if resetToken, ok = tables.passwordResetTokens[id]; !ok || resetToken.UsedAt.Valid {
+			return pgx.ErrNoRows
+		}
+
+		resetToken.UsedAt = q.now()
+		putRow(q, tables.passwordResetTokens, resetToken.ID, resetToken)
+		return nil
+	})
+	return resetToken, err
+}
+
+// product_posting.sql
+
+func (q *memoryQueries) CreateProductPosting(ctx context.Context, arg CreateProductPostingParams) (ProductPosting, error) {
+	var posting ProductPosting
+	err := q.write(func(tables *memoryTables) error {
+		key := newProductPostingKey(arg.AccountID, arg.Period)
+		if _, ok := tables.productPostings[key]; ok {
+			return uniqueViolation("product_postings_pkey")
+		}
+		if arg.Interest < 0 || arg.Fee < 0 {
+			return checkViolation("product_postings", "product_postings_amounts_check")
+		}
+		if _, ok := tables.accounts[arg.AccountID]; !ok {
+			return foreignKeyViolation("product_postings", "product_postings_account_id_fkey")
+		}
+
+		posting = ProductPosting{
+			AccountID: arg.AccountID,
+			Period:    arg.Period,
+			Interest:  arg.Interest,
+			Fee:       arg.Fee,
+			CreatedAt: q.now(),
+		}
+		putRow(q, tables.productPostings, key, posting)
+		return nil
+	})
+	return posting, err
+}
+
+func (q *memoryQueries) GetProductPosting(ctx context.Context, arg GetProductPostingParams) (ProductPosting, error) {
+	var posting ProductPosting
+	err := q.read(func(tables *memoryTables) error {
+		var ok bool
+		if posting, ok = tables.productPostings[newProductPostingKey(arg.AccountID, arg.Period)]; !ok {
+			return pgx.ErrNoRows
+		}
+		return nil
+	})
+	return posting, err
+}
+
+func (q *memoryQueries) ListAccountsWithProductPlans(ctx context.Context, arg ListAccountsWithProductPlansParams) ([]Account, error) {
+	var accounts []Account
+	err := q.read(func(tables *memoryTables) error {
+		var err error
+		accounts, err = paginate(selectRows(tables.accounts, func(account Account) bool {
+			product := tables.accountProducts[account.Product]
+			return (product.InterestRateBps > 0 || product.MonthlyFee > 0) &&
+				account.CreatedAt.Time.Before(arg.OpenedBefore.Time) &&
+				account.ID > arg.AfterID
+		}), arg.Limit, 0)
+		return err
+	})
+	return accounts, err
+}
+
+// recovery_code.sql
+
+func (q *memoryQueries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error) {
+	var recoveryCode RecoveryCode
+	err := q.write(func(tables *memoryTables) error {
+		for _, other := range tables.recoveryCodes {
+			if other.Username == arg.Username && other.CodeHash == arg.CodeHash {
+				return uniqueViolation("recovery_codes_username_code_hash_idx")
+			}
+		}
+		if _, ok := tables.users[arg.Username]; !ok {
+			return foreignKeyViolation("recovery_codes", "recovery_codes_username_fkey")
+		}
+
+		recoveryCode = RecoveryCode{
+			ID:        tables.nextID("recovery_codes"),
+			Username:  arg.Username,
+			CodeHash:  arg.CodeHash,
+			CreatedAt: q.now(),
+		}
+		putRow(q, tables.recoveryCodes, recoveryCode.ID, recoveryCode)
+		return nil
+	})
+	return recoveryCode, err
+}
+
+func (q *memoryQueries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error) {
+	var recoveryCode RecoveryCode
+	err := q.write(func(tables *memoryTables) error {
+		for _, other := range tables.recoveryCodes {
+			if other.Username == arg.Username && other.CodeHash == arg.CodeHash && !other.UsedAt.Valid {
+				recoveryCode = other
+				recoveryCode.UsedAt = q.now()
+				putRow(q, tables.recoveryCodes, recoveryCode.ID, recoveryCode)
+				return nil
+			}
+		}
+		return pgx.ErrNoRows
+	})
+	return recoveryCode, err
+}
+
+func (q *memoryQueries) DeleteRecoveryCodes(ctx context.Context, username string) error {
+	return q.write(func(tables *memoryTables) error {
+		for _, recoveryCode := range selectRows(tables.recoveryCodes, func(recoveryCode RecoveryCode) bool {
+			return recoveryCode.Username == username
+		}) {
+			deleteRow(q, tables.recoveryCodes, recoveryCode.ID)
+		}
+		return nil
+	})
+}
+
+// session.sql
+
+// refreshTokenMaxLength is the size of the varchar holding refresh tokens
+const refreshTokenMaxLength = 512
+
+func (q *memoryQueries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	var session Session
+	err := q.write(func(tables *memoryTables) error {
+		if _, ok := tables.sessions[arg.ID]; ok {
+			return uniqueViolation("sessions_pkey")
+		}
+		if len([]rune(arg.RefreshToken)) > refreshTokenMaxLength {
+			return &pgconn.PgError{
+				Severity: "ERROR",
+				Code:     "22001",
+				Message:  "value too long for type character varying(512)",
+			}
+		}
+
+		session = Session{
+			ID:           arg.ID,
+			Username:     arg.Username,
+			RefreshToken: arg.RefreshToken,
+			IsRevoked:    arg.IsRevoked,
+			CreatedAt:    q.now(),
+			ExpiresAt:    memoryTimestamp(arg.ExpiresAt),
+			FamilyID:     arg.FamilyID,
+			UserAgent:    arg.UserAgent,
+			ClientIp:     arg.ClientIp,
+		}
+		putRow(q, tables.sessions, session.ID, session)
+		return nil
+	})
+	return session, err
+}
+
+func (q *memoryQueries) GetSession(ctx context.Context, id string) (Session, error) {
+	var session Session
+	err := q.read(func(tables *memoryTables) error {
+		var ok bool
+		if session, ok = tables.sessions[id]; !ok {
+			return pgx.ErrNoRows
+		}
+		return nil
+	})
+	return session, err
+}
+
+// GetSessionForUpdate needs no row lock, transactions already run one at a time
+func (q *memoryQueries) GetSessionForUpdate(ctx context.Context, id string) (Session, error) {
+	return q.GetSession(ctx, id)
+}
+
+func (q *memoryQueries) ListActiveSessions(ctx context.Context, username string) ([]Session, error) {
+	var sessions []Session
+	err := q.read(func(tables *memoryTables) error {
+		now := q.now().Time
+		sessions = selectRows(tables.sessions, func(session Session) bool {
+			return session.Username == username && !session.IsRevoked && session.ExpiresAt.Time.After(now)
+		})
+		slices.SortStableFunc(sessions, func(a, b Session) int {
+			return b.CreatedAt.Time.Compare(a.CreatedAt.Time)
+		})
+		return nil
+	})
+	return sessions, err
+}
+
+func (q *memoryQueries) RevokeSession(ctx context.Context, id string) error {
+	return q.revokeSessions(func(session Session) bool {
+		return session.ID == id
+	})
+}
+
+func (q *memoryQueries) DeleteSession(ctx context.Context, id string) error {
+	return q.write(func(tables *memoryTables) error {
+		deleteRow(q, tables.sessions, id)
+		return nil
+	})
+}
+
+func (q *memoryQueries) RevokeUserSessions(ctx context.Context, username string) error {
+	return q.revokeSessions(func(session Session) bool {
+		return session.Username == username
+	})
+}
+
+func (q *memoryQueries) MarkSessionRotated(ctx context.Context, arg MarkSessionRotatedParams) (Session, error) {
+	var session Session
+	err := q.write(func(tables *memoryTables) error {
+		var ok bool
+		if session, ok = tables.sessions[arg.ID]; !ok {
+			return pgx.ErrNoRows
+		}
+
+		session.IsRevoked = true
+		session.ReplacedBy = arg.ReplacedBy
+		putRow(q, tables.sessions, session.ID, session)
+		return nil
+	})
+	return session, err
+}
+
+func (q *memoryQueries) RevokeSessionFamily(ctx context.Context, familyID string) error {
+	return q.revokeSessions(func(session Session) bool {
+		return session.FamilyID == familyID
+	})
+}
+
+func (q *memoryQueries) revokeSessions(where func(session Session) bool) error {
+	return q.write(func(tables *memoryTables) error {
+		for _, session := range selectRows(tables.sessions, func(session Session) bool {
+			return !session.IsRevoked && where(session)
+		}) {
+			session.IsRevoked = true
+			putRow(q, tables.sessions, session.ID, session)
+		}
+		return nil
+	})
+}
+
+func (q *memoryQueries) GetSessionFamilyState(ctx context.Context, arg GetSessionFamilyStateParams) (GetSessionFamilyStateRow, error) {
+	var state GetSessionFamilyStateRow
+	err := q.read(func(tables *memoryTables) error {
+		user, ok := tables.users[arg.Username]
+		if !ok {
+			return pgx.ErrNoRows
+		}
+
+		now := q.now().Time
+		state.PasswordChangedAt = user.PasswordChangedAt
+		for _, session := range tables.sessions {
+			if session.FamilyID == arg.FamilyID &&
+				session.Username == user.Username &&
+				!session.IsRevoked &&
+				session.ExpiresAt.Time.After(now) {
+				state.SessionActive = true
+				break
+			}
+		}
+		return nil
+	})
+	return state, err
+}
+
+// transfers.sql
+
+func (q *memoryQueries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
+	var transfer Transfer
+	err := q.write(func(tables *memoryTables) error {
+		if _, ok := tables.accounts[arg.FromAccountID]; !ok {
+			return foreignKeyViolation("transfers", "transfers_from_account_id_fkey")
+		}
+		if _, ok := tables.accounts[arg.ToAccountID]; !ok {
+			return foreignKeyViolation("transfers", "transfers_to_account_id_fkey")
+		}
+		if _, ok := tables.users[arg.InitiatedBy.String]; arg.InitiatedBy.Valid && !ok {
+			return foreignKeyViolation("transfers", "transfers_initiated_by_fkey")
+		}
+
+		transfer = Transfer{
+			ID:            tables.nextID("transfers"),
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+			CreatedAt:     q.now(),
+			InitiatedBy:   arg.InitiatedBy,
+		}
+		putRow(q, tables.transfers, transfer.ID, transfer)
+		return nil
+	})
+	return transfer, err
+}
+
+// CreateTransfers inserts every row or, like COPY, none of them
+func (q *memoryQueries) CreateTransfers(ctx context.Context, arg []CreateTransfersParams) (int64, error) {
+	err := q.write(func(tables *memoryTables) error {
+		for _, row := range arg {
+			if _, ok := tables.transferBatches[row.BatchID.Int64]; row.BatchID.Valid && !ok {
+				return foreignKeyViolation("transfers", "transfers_batch_id_fkey")
+			}
+			if _, ok := tables.accounts[row.FromAccountID]; !ok {
+				return foreignKeyViolation("transfers", "transfers_from_account_id_fkey")
+			}
+			if _, ok := tables.accounts[row.ToAccountID]; !ok {
+				return foreignKeyViolation("transfers", "transfers_to_account_id_fkey")
+			}
+			if _, ok := tables.users[row.InitiatedBy.String]; row.InitiatedBy.Valid && !ok {
+				return foreignKeyViolation("transfers", "transfers_initiated_by_fkey")
+			}
+		}
+
+		for _, row := range arg {
+			transfer := Transfer{
+				ID:            tables.nextID("transfers"),
+				FromAccountID: row.FromAccountID,
+				ToAccountID:   row.ToAccountID,
+				Amount:        row.Amount,
+				CreatedAt:     q.now(),
+				BatchID:       row.BatchID,
+				InitiatedBy:   row.InitiatedBy,
+			}
+			putRow(q, tables.transfers, transfer.ID, transfer)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(arg)), nil
+}
+
+func (q *memoryQueries) GetWithdrawalsSince(ctx context.Context, arg GetWithdrawalsSinceParams) (GetWithdrawalsSinceRow, error) {
+	var row GetWithdrawalsSinceRow
+	err := q.read(func(tables *memoryTables) error {
+		for _, transfer := range tables.transfers {
+			if transfer.FromAccountID == arg.FromAccountID && !transfer.CreatedAt.Time.Before(arg.Since.Time) {
+				row.Count++
+				row.Total += transfer.Amount
+			}
+		}
+		return nil
+	})
+	return row, err
+}
+
+func (q *memoryQueries) GetMemberSpendingSince(ctx context.Context, arg GetMemberSpendingSinceParams) (int64, error) {
+	var total int64
+	err := q.read(func(tables *memoryTables) error {
+		for _, transfer := range tables.transfers {
+			// initiated_by = NULL matches nothing
+			if transfer.FromAccountID == arg.FromAccountID && arg.InitiatedBy.Valid && transfer.InitiatedBy == arg.InitiatedBy &&
+				!transfer.CreatedAt.Time.Before(arg.Since.Time) {
+				total += transfer.Amount
+			}
+		}
+		return nil
+	})
+	return total, err
+}
+
+func (q *memoryQueries) ListBatchTransfers(ctx context.Context, batchID pgtype.Int8) ([]Transfer, error) {
+	var transfers []Transfer
+	err := q.read(func(tables *memoryTables) error {
+		transfers = selectRows(tables.transfers, func(transfer Transfer) bool {
+			return batchID.Valid && transfer.BatchID == batchID
+		})
+		return nil
+	})
+	return transfers, err
+}
+
+func (q *memoryQueries) GetTransferByID(ctx context.Context, id int64) (Transfer, error) {
+	var transfer Transfer
+	err := q.read(func(tables *memoryTables) error {
+		var ok bool
+		if transfer, ok = tables.transfers[id]; !ok {
+			return pgx.ErrNoRows
+		}
+		return nil
+	})
+	return transfer, err
+}
+
+func (q *memoryQueries) GetTransferByFromAccountID(ctx context.Context, arg GetTransferByFromAccountIDParams) ([]Transfer, error) {
+	return q.listTransfers(arg.Limit, arg.Offset, func(transfer Transfer) bool {
+		return transfer.FromAccountID == arg.FromAccountID
+	})
+}
+
+func (q *memoryQueries) GetTransferByToAccountID(ctx context.Context, arg GetTransferByToAccountIDParams) ([]Transfer, error) {
+	return q.listTransfers(arg.Limit, arg.Offset, func(transfer Transfer) bool {
+		return transfer.ToAccountID == arg.ToAccountID
+	})
+}
+
+func (q *memoryQueries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	return q.listTransfers(arg.Limit, arg.Offset, func(transfer Transfer) bool {
+		return true
+	})
+}
+
+func (q *memoryQueries) listTransfers(limit int32, offset int32, where func(transfer Transfer) bool) ([]Transfer, error) {
+	var transfers []Transfer
+	err := q.read(func(tables *memoryTables) error {
+		var err error
+		transfers, err = paginate(selectRows(tables.transfers, where), limit, offset)
+		return err
+	})
+	return transfers, err
+}
+
+func (q *memoryQueries) UpdateTransfer(ctx context.Context, arg UpdateTransferParams) (Transfer, error) {
+	var transfer Transfer
+	err := q.write(func(tables *memoryTables) error {
+		var ok bool
+		if transfer, ok = tables.transfers[arg.ID]; !ok {
+			return pgx.ErrNoRows
+		}
+
+		transfer.Amount = arg.Amount
+		putRow(q, tables.transfers, transfer.ID, transfer)
+		return nil
+	})
+	return transfer, err
+}
+
+func (q *memoryQueries) DeleteTransfer(ctx context.Context, id int64) (Transfer, error) {
+	var transfer Transfer
+	err := q.write(func(tables *memoryTables) error {
+		var ok bool
+		if transfer, ok = tables.transfers[id]; !ok {
+			return pgx.ErrNoRows
+		}
+
+		deleteRow(q, tables.transfers, id)
+		return nil
+	})
+	return transfer, err
+}
+
+// transfer_batch.sql
+
+func (q *memoryQueries) CreateTransferBatch(ctx context.Context, arg CreateTransferBatchParams) (TransferBatch, error) {
+	var batch TransferBatch
+	err := q.write(func(tables *memoryTables) error {
+		if _, ok := tables.users[arg.Owner]; !ok {
+			return foreignKeyViolation("transfer_batches", "transfer_batches_owner_fkey")
+		}
+
+		batch = TransferBatch{
+			ID:        tables.nextID("transfer_batches"),
+			Owner:     arg.Owner,
+			Mode:      arg.Mode,
+			Currency:  arg.Currency,
+			Legs:      arg.Legs,
+			Succeeded: arg.Succeeded,
+			CreatedAt: q.now(),
+		}
+		putRow(q, tables.transferBatches, batch.ID, batch)
+		return nil
+	})
+	return batch, err
+}
+
+// user.sql
+
+// zeroTimestamp is the '0001-01-01 00:00:00Z' default of password_changed_at and locked_until
+var zeroTimestamp = pgtype.Timestamptz{Time: time.Time{}, Valid: true}
+
+func (q *memoryQueries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	var user User
+	err := q.write(func(tables *memoryTables) error {
+		if _, ok := tables.users[arg.Username]; ok {
+			return uniqueViolation("users_pkey")
+		}
+		if q.emailTaken(tables, arg.Email, arg.Username) {
+			return uniqueViolation("users_email_key")
+		}
+
+		user = User{
+			Username:          arg.Username,
+			HashedPassword:    arg.HashedPassword,
+			FullName:          arg.FullName,
+			Email:             arg.Email,
+			PasswordChangedAt: zeroTimestamp,
+			CreatedAt:         q.now(),
+			Role:              "depositor",
+			LockedUntil:       zeroTimestamp,
+		}
+		putRow(q, tables.users, user.Username, user)
+		return nil
+	})
+	return user, err
+}
+
+func (q *memoryQueries) emailTaken(tables *memoryTables, email string, username string) bool {
+	for _, other := range tables.users {
+		if other.Email == email && other.Username != username {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *memoryQueries) GetUser(ctx context.Context, username string) (User, error) {
+	var user User
+	err := q.read(func(tables *memoryTables) error {
+		var ok bool
+		if user, ok = tables.users[username]; !ok {
+			return pgx.ErrNoRows
+		}
+		return nil
+	})
+	return user, err
+}
+
+// GetUserForUpdate needs no row lock, transactions already run one at a time
+func (q *memoryQueries) GetUserForUpdate(ctx context.Context, username string) (User, error) {
+	return q.GetUser(ctx, username)
+}
+
+func (q *memoryQueries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	return q.updateUser(arg.Username, func(tables *memoryTables, user *User) error {
+		if arg.Email.Valid && q.emailTaken(tables, arg.Email.String, user.Username) {
+			return uniqueViolation("users_email_key")
+		}
+
+		if arg.HashedPassword.Valid {
+			user.HashedPassword = arg.HashedPassword.String
+		}
+		if arg.PasswordChangedAt.Valid {
+			user.PasswordChangedAt = memoryTimestamp(arg.PasswordChangedAt)
+		}
+		if arg.FullName.Valid {
+			user.FullName = arg.FullName.String
+		}
+		if arg.Email.Valid {
+			user.Email = arg.Email.String
+		}
+		return nil
+	})
+}
+
+func (q *memoryQueries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	var user User
+	err := q.read(func(tables *memoryTables) error {
+		for _, other := range tables.users {
+			if other.Email == email {
+				user = other
+				return nil
+			}
+		}
+		return pgx.ErrNoRows
+	})
+	return user, err
+}
+
+func (q *memoryQueries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error) {
+	return q.updateUser(arg.Username, func(tables *memoryTables, user *User) error {
+		user.TotpSecret = arg.TotpSecret
+		user.TotpEnabled = false
+		user.TotpLastStep = 0
+		return nil
+	})
+}
+
+func (q *memoryQueries) EnableUserTOTP(ctx context.Context, username string) (User, error) {
+	return q.updateUser(username, func(tables *memoryTables, user *User) error {
+		if !user.TotpSecret.Valid {
+			return pgx.ErrNoRows
+		}
+		user.TotpEnabled = true
+		return nil
+	})
+}
+
+func (q *memoryQueries) DisableUserTOTP(ctx context.Context, username string) (User, error) {
+	return q.updateUser(username, func(tables *memoryTables, user *User) error {
+		user.TotpSecret = pgtype.Text{}
+		user.TotpEnabled = false
+		return nil
+	})
+}
+
+func (q *memoryQueries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (User, error) {
+	return q.updateUser(arg.Username, func(tables *memoryTables, user *User) error {
+		if user.TotpLastStep >= arg.Step {
+			return pgx.ErrNoRows
+		}
+		user.TotpLastStep = arg.Step
+		return nil
+	})
+}
+
+func (q *memoryQueries) IncrementUserFailedLogins(ctx context.Context, username string) (User, error) {
+	return q.updateUser(username, func(tables *memoryTables, user *User) error {
+		user.FailedLoginAttempts++
+		return nil
+	})
+}
+
+func (q *memoryQueries) LockUser(ctx context.Context, arg LockUserParams) (User, error) {
+	return q.updateUser(arg.Username, func(tables *memoryTables, user *User) error {
+		user.LockedUntil = memoryTimestamp(arg.LockedUntil)
+		return nil
+	})
+}
+
+func (q *memoryQueries) ResetUserFailedLogins(ctx context.Context, username string) (User, error) {
+	return q.updateUser(username, func(tables *memoryTables, user *User) error {
+		user.FailedLoginAttempts = 0
+		user.LockedUntil = zeroTimestamp
+		return nil
+	})
+}
+
+func (q *memoryQueries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	return q.updateUser(arg.Username, func(tables *memoryTables, user *User) error {
+		user.Role = arg.Role
+		return nil
+	})
+}
+
+// updateUser applies set to a copy of the user, which is only stored when set succeeds
+func (q *memoryQueries) updateUser(username string, set func(tables *memoryTables, user *User) error) (User, error) {
+	var user User
+	err := q.write(func(tables *memoryTables) error {
+		var ok bool
+		if user, ok = tables.users[username]; !ok {
+			return pgx.ErrNoRows
+		}
+
+		if err := set(tables, &user); err != nil {
+			return err
+		}
+		putRow(q, tables.users, user.Username, user)
+		return nil
+	})
+	return user, err
+}
@@ -0,0 +1,271 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MemoryStore keeps every table in memory, to run the service and its tests without Postgres.
+// Its queries follow the SQL in db/query and the constraints of the migrations, and fail
+// the way Postgres does: pgx.ErrNoRows for missing rows and *pgconn.PgError for violated
+// constraints, so callers cannot tell the two stores apart.
+//
+// Transactions run one at a time holding the store lock, which makes them serializable.
+// Every change they make is journaled and undone when they fail. Statements outside of a
+// transaction take the lock for their own duration.
+type MemoryStore struct {
+	*memoryQueries
+	txStore
+
+	mu     sync.RWMutex
+	tables *memoryTables
+}
+
+func NewMemoryStore() Store {
+	store := &MemoryStore{
+		tables: &memoryTables{
+			users:               make(map[string]User),
+			accounts:            make(map[int64]Account),
+			accountMembers:      make(map[accountMemberKey]AccountMember),
+			accountProducts:     make(map[string]AccountProduct),
+			currencies:          make(map[string]Currency),
+			entries:             make(map[int64]Entry),
+			transfers:           make(map[int64]Transfer),
+			transferBatches:     make(map[int64]TransferBatch),
+			sessions:            make(map[string]Session),
+			passwordResetTokens: make(map[int64]PasswordResetToken),
+			recoveryCodes:       make(map[int64]RecoveryCode),
+			failedLogins:        make(map[int64]FailedLogin),
+			balanceAdjustments:  make(map[int64]BalanceAdjustment),
+			productPostings:     make(map[productPostingKey]ProductPosting),
+			sequences:           make(map[string]int64),
+		},
+	}
+	store.memoryQueries = &memoryQueries{store: store}
+	store.txStore = txStore{execTx: store.execMemoryTx}
+
+	// The currencies the migrations insert
+	for _, currency := range []Currency{
+		{Code: "USD", Exponent: 2, DisplayName: "US Dollar"},
+		{Code: "EUR", Exponent: 2, DisplayName: "Euro"},
+		{Code: "INR", Exponent: 2, DisplayName: "Indian Rupee"},
+	} {
+		currency.Enabled = true
+		currency.UpdatedAt = pgtype.Timestamptz{Time: memoryNow(), Valid: true}
+		store.tables.currencies[currency.Code] = currency
+	}
+
+	// The account products the migrations insert
+	for _, product := range []AccountProduct{
+		{Code: "checking", Name: "Checking"},
+		{Code: "savings", Name: "Savings", MaxAccountsPerUser: 2, MaxMonthlyWithdrawals: 6, InterestRateBps: 150},
+	} {
+		product.Enabled = true
+		product.AllowedCurrencies = []string{}
+		product.CreatedAt = pgtype.Timestamptz{Time: memoryNow(), Valid: true}
+		store.tables.accountProducts[product.Code] = product
+	}
+	return store
+}
+
+// execMemoryTx ignores the isolation level, every transaction is serializable and none conflict
+func (store *MemoryStore) execMemoryTx(ctx context.Context, opts txOptions, fn func(Querier) error) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	// Like BeginTx, a cancelled context does not start a transaction
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx := &memoryTx{startedAt: memoryNow()}
+	err := fn(&memoryQueries{store: store, tx: tx})
+	if err != nil {
+		tx.rollback()
+		return err
+	}
+	return nil
+}
+
+type memoryTables struct {
+	users               map[string]User
+	accounts            map[int64]Account
+	accountMembers      map[accountMemberKey]AccountMember
+	accountProducts     map[string]AccountProduct
+	currencies          map[string]Currency
+	entries             map[int64]Entry
+	transfers           map[int64]Transfer
+	transferBatches     map[int64]TransferBatch
+	sessions            map[string]Session
+	passwordResetTokens map[int64]PasswordResetToken
+	recoveryCodes       map[int64]RecoveryCode
+	failedLogins        map[int64]FailedLogin
+	balanceAdjustments  map[int64]BalanceAdjustment
+	productPostings     map[productPostingKey]ProductPosting
+	// sequences hold the last id of every bigserial column. As in Postgres,
+	// ids taken by a transaction that rolls back are not reused.
+	sequences map[string]int64
+}
+
+// accountMemberKey is the primary key of account_members
+type accountMemberKey struct {
+	accountID int64
+	username  string
+}
+
+// productPostingKey is the primary key of product_postings, the period as YYYY-MM-DD
+type productPostingKey struct {
+	accountID int64
+	period    string
+}
+
+func newProductPostingKey(accountID int64, period pgtype.Date) productPostingKey {
+	return productPostingKey{accountID, period.Time.Format(time.DateOnly)}
+}
+
+func (tables *memoryTables) nextID(table string) int64 {
+	tables.sequences[table]++
+	return tables.sequences[table]
+}
+
+// memoryTx journals how to undo each change of a transaction
+type memoryTx struct {
+	// startedAt is what now() returns during the transaction, as in Postgres
+	startedAt time.Time
+	undo      []func()
+}
+
+func (tx *memoryTx) rollback() {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+	tx.undo = nil
+}
+
+// memoryQueries runs the queries on the tables of its store,
+// inside of tx when it is set or as a statement of its own otherwise.
+type memoryQueries struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+var _ Querier = (*memoryQueries)(nil)
+
+// read runs fn under the read lock, which the transaction already holds exclusively
+func (q *memoryQueries) read(fn func(tables *memoryTables) error) error {
+	if q.tx == nil {
+		q.store.mu.RLock()
+		defer q.store.mu.RUnlock()
+	}
+	return fn(q.store.tables)
+}
+
+// write runs fn under the write lock. Statements check every constraint before
+// changing anything, so one that fails outside of a transaction leaves no trace.
+func (q *memoryQueries) write(fn func(tables *memoryTables) error) error {
+	if q.tx == nil {
+		q.store.mu.Lock()
+		defer q.store.mu.Unlock()
+	}
+	return fn(q.store.tables)
+}
+
+func (q *memoryQueries) now() pgtype.Timestamptz {
+	if q.tx != nil {
+		return pgtype.Timestamptz{Time: q.tx.startedAt, Valid: true}
+	}
+	return pgtype.Timestamptz{Time: memoryNow(), Valid: true}
+}
+
+// memoryNow has the microsecond precision of timestamptz
+func memoryNow() time.Time {
+	return time.Now().Round(time.Microsecond)
+}
+
+func memoryTimestamp(value pgtype.Timestamptz) pgtype.Timestamptz {
+	value.Time = value.Time.Round(time.Microsecond)
+	return value
+}
+
+// putRow inserts or replaces a row, journaling its previous state in a transaction
+func putRow[K comparable, V any](q *memoryQueries, table map[K]V, key K, row V) {
+	if q.tx != nil {
+		previous, existed := table[key]
+		q.tx.undo = append(q.tx.undo, func() {
+			if existed {
+				table[key] = previous
+			} else {
+				delete(table, key)
+			}
+		})
+	}
+	table[key] = row
+}
+
+// deleteRow removes a row, journaling it in a transaction
+func deleteRow[K comparable, V any](q *memoryQueries, table map[K]V, key K) {
+	previous, existed := table[key]
+	if !existed {
+		return
+	}
+	if q.tx != nil {
+		q.tx.undo = append(q.tx.undo, func() {
+			table[key] = previous
+		})
+	}
+	delete(table, key)
+}
+
+// The errors below carry the codes and constraint names Postgres uses for the schema in db/migration
+
+func uniqueViolation(constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23505",
+		Message:        fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		ConstraintName: constraint,
+	}
+}
+
+func foreignKeyViolation(table string, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23503",
+		Message:        fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+func foreignKeyReferenced(table string, constraint string, referencingTable string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23503",
+		Message:        fmt.Sprintf("update or delete on table %q violates foreign key constraint %q on table %q", table, constraint, referencingTable),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+func checkViolation(table string, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           checkViolationCode,
+		Message:        fmt.Sprintf("new row for relation %q violates check constraint %q", table, constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+func negativeLimit(code string, clause string) error {
+	return &pgconn.PgError{
+		Severity: "ERROR",
+		Code:     code,
+		Message:  clause + " must not be negative",
+	}
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func requirePgError(t *testing.T, err error, code string, constraint string) {
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	require.Equal(t, code, pgErr.Code)
+	require.Equal(t, constraint, pgErr.ConstraintName)
+}
+
+func TestMemoryStoreRollback(t *testing.T) {
+	store := NewMemoryStore().(*MemoryStore)
+
+	user, err := store.CreateUser(context.Background(), CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "hashed",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	account, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  100,
+		Currency: util.USD,
+		Product:  DefaultAccountProduct,
+	})
+	require.NoError(t, err)
+
+	errFailed := errors.New("failed")
+	var entry Entry
+	err = store.execTx(context.Background(), transferTxOptions, func(q Querier) error {
+		var err error
+		entry, err = q.CreateEntry(context.Background(), CreateEntryParams{AccountID: account.ID, Amount: 50})
+		if err != nil {
+			return err
+		}
+		if _, err = q.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: account.ID, Amount: 50}); err != nil {
+			return err
+		}
+		if err = q.DeleteAccount(context.Background(), account.ID); err == nil {
+			return errors.New("account with entries was deleted")
+		}
+		return errFailed
+	})
+	require.ErrorIs(t, err, errFailed)
+
+	_, err = store.GetEntry(context.Background(), entry.ID)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	account2, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account, account2)
+
+	// Like a sequence, the id taken by the rolled back entry is not reused
+	entry2, err := store.CreateEntry(context.Background(), CreateEntryParams{AccountID: account.ID, Amount: 10})
+	require.NoError(t, err)
+	require.Greater(t, entry2.ID, entry.ID)
+}
+
+func TestMemoryStoreCancelledTx(t *testing.T) {
+	store := NewMemoryStore().(*MemoryStore)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := store.execTx(ctx, transferTxOptions, func(q Querier) error {
+		return errors.New("transaction started with a cancelled context")
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+// The cases below run on whichever store the tests use, so they hold the
+// in-memory store to the errors Postgres returns for the same statements
+
+func TestTransferTxInsufficientBalanceRollsBack(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance + 1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientBalance)
+
+	transfers, err := testQueries.GetTransferByFromAccountID(context.Background(), GetTransferByFromAccountIDParams{
+		FromAccountID: account1.ID,
+		Limit:         5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+
+	updated1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updated1.Balance)
+}
+
+func TestTransferTxUnknownAccount(t *testing.T) {
+	account := createRandomAccount(t)
+
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   account.ID + 1_000_000,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	entries, err := testQueries.ListEntries(context.Background(), ListEntriesParams{AccountID: account.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestAccountBalanceCheck(t *testing.T) {
+	account := createRandomAccount(t)
+
+	_, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: -account.Balance - 1,
+	})
+	requirePgError(t, err, "23514", "accounts_balance_check")
+
+	updated, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, updated.Balance)
+}
+
+func TestForeignKeyConstraints(t *testing.T) {
+	_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    util.RandomString(12),
+		Currency: util.USD,
+		Product:  DefaultAccountProduct,
+	})
+	requirePgError(t, err, "23503", "accounts_owner_fkey")
+
+	entry := createRandomEntry(t)
+	err = testQueries.DeleteAccount(context.Background(), entry.AccountID)
+	requirePgError(t, err, "23503", "entries_account_id_fkey")
+}
+
+func TestUniqueEmail(t *testing.T) {
+	user := createRandomUser(t)
+
+	_, err := testQueries.CreateUser(context.Background(), CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: user.HashedPassword,
+		FullName:       user.FullName,
+		Email:          user.Email,
+	})
+	requirePgError(t, err, "23505", "users_email_key")
+}
@@ -14,6 +14,64 @@ type Account struct {
 	Currency  string             `json:"currency"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 	Balance   int64              `json:"balance"`
+	Product   string             `json:"product"`
+}
+
+// users who can act on an account, and what they can do
+type AccountMember struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	// most a spender may transfer out of the account per calendar month in UTC, in minor units
+	SpendLimit int64  `json:"spend_limit"`
+	InvitedBy  string `json:"invited_by"`
+	// null until the invited user accepts
+	AcceptedAt pgtype.Timestamptz `json:"accepted_at"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+// kinds of account, e.g. checking or savings, and their rules
+type AccountProduct struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	// empty allows every enabled currency
+	AllowedCurrencies []string `json:"allowed_currencies"`
+	// 0 is unlimited
+	MaxAccountsPerUser int32 `json:"max_accounts_per_user"`
+	// in minor units, outgoing transfers cannot go below it
+	MinBalance int64 `json:"min_balance"`
+	// outgoing transfers per calendar month in UTC, 0 is unlimited
+	MaxMonthlyWithdrawals int32 `json:"max_monthly_withdrawals"`
+	// in minor units per calendar month in UTC, 0 is unlimited
+	MaxMonthlyWithdrawalAmount int64 `json:"max_monthly_withdrawal_amount"`
+	// yearly interest in basis points
+	InterestRateBps int32 `json:"interest_rate_bps"`
+	// in minor units
+	MonthlyFee int64              `json:"monthly_fee"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+// manual corrections posted by operators, each backed by a ledger entry
+type BalanceAdjustment struct {
+	ID        int64              `json:"id"`
+	AccountID int64              `json:"account_id"`
+	EntryID   int64              `json:"entry_id"`
+	Amount    int64              `json:"amount"`
+	Reason    string             `json:"reason"`
+	Operator  string             `json:"operator"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+// ISO 4217 currencies accounts can be held in
+type Currency struct {
+	Code string `json:"code"`
+	// decimals of the minor unit, 2 for cents
+	Exponent int16 `json:"exponent"`
+	// disabled currencies take no new accounts or transfers
+	Enabled     bool               `json:"enabled"`
+	DisplayName string             `json:"display_name"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
 }
 
 type Entry struct {
@@ -24,6 +82,43 @@ type Entry struct {
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
+type FailedLogin struct {
+	ID int64 `json:"id"`
+	// not a foreign key, attempts against unknown usernames are tracked too
+	Username  string             `json:"username"`
+	ClientIp  string             `json:"client_ip"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the token sent to the user
+	TokenHash string             `json:"token_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+// interest and fees of the account product posted for a month, at most once per account
+type ProductPosting struct {
+	AccountID int64 `json:"account_id"`
+	// first day of the month the posting is for
+	Period   pgtype.Date `json:"period"`
+	Interest int64       `json:"interest"`
+	// in minor units, less than the product fee when the balance could not cover it
+	Fee       int64              `json:"fee"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type RecoveryCode struct {
+	ID        int64              `json:"id"`
+	Username  string             `json:"username"`
+	CodeHash  string             `json:"code_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
 type Session struct {
 	ID           string             `json:"id"`
 	Username     string             `json:"username"`
@@ -31,6 +126,12 @@ type Session struct {
 	IsRevoked    bool               `json:"is_revoked"`
 	CreatedAt    pgtype.Timestamptz `json:"created_at"`
 	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	// id of the first session of a chain of rotated refresh tokens
+	FamilyID string `json:"family_id"`
+	// set once the refresh token has been exchanged for a new one
+	ReplacedBy pgtype.Text `json:"replaced_by"`
+	UserAgent  string      `json:"user_agent"`
+	ClientIp   string      `json:"client_ip"`
 }
 
 type Transfer struct {
@@ -40,6 +141,20 @@ type Transfer struct {
 	// must be +ve
 	Amount    int64              `json:"amount"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	BatchID   pgtype.Int8        `json:"batch_id"`
+	// member who made the transfer, null for transfers made outside of a member, e.g. by bankctl
+	InitiatedBy pgtype.Text `json:"initiated_by"`
+}
+
+// transfers submitted together, e.g. a payroll run
+type TransferBatch struct {
+	ID        int64              `json:"id"`
+	Owner     string             `json:"owner"`
+	Mode      string             `json:"mode"`
+	Currency  string             `json:"currency"`
+	Legs      int32              `json:"legs"`
+	Succeeded int32              `json:"succeeded"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
 type User struct {
@@ -49,4 +164,13 @@ type User struct {
 	Email             string             `json:"email"`
 	PasswordChangedAt pgtype.Timestamptz `json:"password_changed_at"`
 	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	// base32 secret, set during enrollment and only enforced once totp_enabled is true
+	TotpSecret  pgtype.Text `json:"totp_secret"`
+	TotpEnabled bool        `json:"totp_enabled"`
+	Role        string      `json:"role"`
+	// consecutive failures, reset by a successful login
+	FailedLoginAttempts int32              `json:"failed_login_attempts"`
+	LockedUntil         pgtype.Timestamptz `json:"locked_until"`
+	// newest TOTP time step accepted, codes of this step or older are replays
+	TotpLastStep int64 `json:"totp_last_step"`
 }
@@ -0,0 +1,82 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: password_reset.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createPasswordResetToken = `-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (
+    username, token_hash, expires_at
+) VALUES (
+  $1, $2, $3
+)
+RETURNING id, username, token_hash, used_at, created_at, expires_at
+`
+
+type CreatePasswordResetTokenParams struct {
+	Username  string             `json:"username"`
+	TokenHash string             `json:"token_hash"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error) {
+	row := q.db.QueryRow(ctx, createPasswordResetToken, arg.Username, arg.TokenHash, arg.ExpiresAt)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.UsedAt,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getPasswordResetTokenForUpdate = `-- name: GetPasswordResetTokenForUpdate :one
+SELECT id, username, token_hash, used_at, created_at, expires_at FROM password_reset_tokens
+WHERE token_hash = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetPasswordResetTokenForUpdate(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	row := q.db.QueryRow(ctx, getPasswordResetTokenForUpdate, tokenHash)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.UsedAt,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const markPasswordResetTokenUsed = `-- name: MarkPasswordResetTokenUsed :one
+UPDATE password_reset_tokens
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL
+RETURNING id, username, token_hash, used_at, created_at, expires_at
+`
+
+func (q *Queries) MarkPasswordResetTokenUsed(ctx context.Context, id int64) (PasswordResetToken, error) {
+	row := q.db.QueryRow(ctx, markPasswordResetTokenUsed, id)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.UsedAt,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPasswordResetToken(t *testing.T, user User, duration time.Duration) (string, PasswordResetToken) {
+	token := util.RandomString(32)
+
+	arg := CreatePasswordResetTokenParams{
+		Username:  user.Username,
+		TokenHash: util.HashSecret(token),
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(duration), Valid: true},
+	}
+
+	resetToken, err := testQueries.CreatePasswordResetToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, resetToken)
+
+	require.Equal(t, arg.Username, resetToken.Username)
+	require.Equal(t, arg.TokenHash, resetToken.TokenHash)
+	require.False(t, resetToken.UsedAt.Valid)
+	require.WithinDuration(t, arg.ExpiresAt.Time, resetToken.ExpiresAt.Time, time.Second)
+
+	return token, resetToken
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := testStore
+	user := createRandomUser(t)
+	token, _ := createRandomPasswordResetToken(t, user, time.Minute)
+
+	session := createRandomSession(t, user)
+
+	newHashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	result, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      util.HashSecret(token),
+		HashedPassword: newHashedPassword,
+	})
+	require.NoError(t, err)
+	require.Equal(t, newHashedPassword, result.User.HashedPassword)
+	require.WithinDuration(t, time.Now(), result.User.PasswordChangedAt.Time, time.Second)
+
+	session, err = testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsRevoked)
+
+	// the token is single-use
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      util.HashSecret(token),
+		HashedPassword: newHashedPassword,
+	})
+	require.ErrorIs(t, err, ErrInvalidResetToken)
+}
+
+func TestResetPasswordTxExpiredToken(t *testing.T) {
+	store := testStore
+	user := createRandomUser(t)
+	token, _ := createRandomPasswordResetToken(t, user, -time.Minute)
+
+	_, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      util.HashSecret(token),
+		HashedPassword: user.HashedPassword,
+	})
+	require.ErrorIs(t, err, ErrInvalidResetToken)
+
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      util.HashSecret(util.RandomString(32)),
+		HashedPassword: user.HashedPassword,
+	})
+	require.ErrorIs(t, err, ErrInvalidResetToken)
+}
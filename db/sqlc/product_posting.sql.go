@@ -0,0 +1,113 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: product_posting.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createProductPosting = `-- name: CreateProductPosting :one
+INSERT INTO product_postings (
+  account_id, period, interest, fee
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING account_id, period, interest, fee, created_at
+`
+
+type CreateProductPostingParams struct {
+	AccountID int64       `json:"account_id"`
+	Period    pgtype.Date `json:"period"`
+	Interest  int64       `json:"interest"`
+	Fee       int64       `json:"fee"`
+}
+
+func (q *Queries) CreateProductPosting(ctx context.Context, arg CreateProductPostingParams) (ProductPosting, error) {
+	row := q.db.QueryRow(ctx, createProductPosting,
+		arg.AccountID,
+		arg.Period,
+		arg.Interest,
+		arg.Fee,
+	)
+	var i ProductPosting
+	err := row.Scan(
+		&i.AccountID,
+		&i.Period,
+		&i.Interest,
+		&i.Fee,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getProductPosting = `-- name: GetProductPosting :one
+SELECT account_id, period, interest, fee, created_at FROM product_postings
+WHERE account_id = $1 AND period = $2
+LIMIT 1
+`
+
+type GetProductPostingParams struct {
+	AccountID int64       `json:"account_id"`
+	Period    pgtype.Date `json:"period"`
+}
+
+func (q *Queries) GetProductPosting(ctx context.Context, arg GetProductPostingParams) (ProductPosting, error) {
+	row := q.db.QueryRow(ctx, getProductPosting, arg.AccountID, arg.Period)
+	var i ProductPosting
+	err := row.Scan(
+		&i.AccountID,
+		&i.Period,
+		&i.Interest,
+		&i.Fee,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAccountsWithProductPlans = `-- name: ListAccountsWithProductPlans :many
+SELECT a.id, a.owner, a.currency, a.created_at, a.balance, a.product FROM accounts a
+JOIN account_products p ON p.code = a.product
+WHERE (p.interest_rate_bps > 0 OR p.monthly_fee > 0)
+  AND a.created_at < $1
+  AND a.id > $2
+ORDER BY a.id
+LIMIT $3
+`
+
+type ListAccountsWithProductPlansParams struct {
+	OpenedBefore pgtype.Timestamptz `json:"opened_before"`
+	AfterID      int64              `json:"after_id"`
+	Limit        int32              `json:"limit"`
+}
+
+func (q *Queries) ListAccountsWithProductPlans(ctx context.Context, arg ListAccountsWithProductPlansParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listAccountsWithProductPlans, arg.OpenedBefore, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Balance,
+			&i.Product,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
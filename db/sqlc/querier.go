@@ -0,0 +1,100 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Querier interface {
+	AcceptAccountInvitation(ctx context.Context, arg AcceptAccountInvitationParams) (AccountMember, error)
+	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	// Owners who accepted, the ones who can still manage the account
+	CountAccountOwners(ctx context.Context, accountID int64) (int64, error)
+	CountAccountsByProduct(ctx context.Context, arg CountAccountsByProductParams) (int64, error)
+	CountRecentFailedLoginsByIP(ctx context.Context, arg CountRecentFailedLoginsByIPParams) (int64, error)
+	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountMember(ctx context.Context, arg CreateAccountMemberParams) (AccountMember, error)
+	CreateAccountProduct(ctx context.Context, arg CreateAccountProductParams) (AccountProduct, error)
+	CreateBalanceAdjustment(ctx context.Context, arg CreateBalanceAdjustmentParams) (BalanceAdjustment, error)
+	CreateEntries(ctx context.Context, arg []CreateEntriesParams) (int64, error)
+	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateFailedLogin(ctx context.Context, arg CreateFailedLoginParams) (FailedLogin, error)
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error)
+	CreateProductPosting(ctx context.Context, arg CreateProductPostingParams) (ProductPosting, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferBatch(ctx context.Context, arg CreateTransferBatchParams) (TransferBatch, error)
+	CreateTransfers(ctx context.Context, arg []CreateTransfersParams) (int64, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteAccount(ctx context.Context, id int64) error
+	DeleteAccountMember(ctx context.Context, arg DeleteAccountMemberParams) error
+	DeleteEntry(ctx context.Context, id int64) error
+	DeleteRecoveryCodes(ctx context.Context, username string) error
+	DeleteSession(ctx context.Context, id string) error
+	DeleteTransfer(ctx context.Context, id int64) (Transfer, error)
+	DisableUserTOTP(ctx context.Context, username string) (User, error)
+	EnableUserTOTP(ctx context.Context, username string) (User, error)
+	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountMember(ctx context.Context, arg GetAccountMemberParams) (AccountMember, error)
+	GetAccountProduct(ctx context.Context, code string) (AccountProduct, error)
+	GetAccountsForUpdate(ctx context.Context, ids []int64) ([]Account, error)
+	GetCurrency(ctx context.Context, code string) (Currency, error)
+	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetFailedLoginsByUsername(ctx context.Context, username string) (GetFailedLoginsByUsernameRow, error)
+	GetMemberSpendingSince(ctx context.Context, arg GetMemberSpendingSinceParams) (int64, error)
+	GetPasswordResetTokenForUpdate(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	GetProductPosting(ctx context.Context, arg GetProductPostingParams) (ProductPosting, error)
+	GetSession(ctx context.Context, id string) (Session, error)
+	GetSessionFamilyState(ctx context.Context, arg GetSessionFamilyStateParams) (GetSessionFamilyStateRow, error)
+	GetSessionForUpdate(ctx context.Context, id string) (Session, error)
+	GetTransferByFromAccountID(ctx context.Context, arg GetTransferByFromAccountIDParams) ([]Transfer, error)
+	GetTransferByID(ctx context.Context, id int64) (Transfer, error)
+	GetTransferByToAccountID(ctx context.Context, arg GetTransferByToAccountIDParams) ([]Transfer, error)
+	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserForUpdate(ctx context.Context, username string) (User, error)
+	GetWithdrawalsSince(ctx context.Context, arg GetWithdrawalsSinceParams) (GetWithdrawalsSinceRow, error)
+	IncrementUserFailedLogins(ctx context.Context, username string) (User, error)
+	ListAccountMembers(ctx context.Context, accountID int64) ([]AccountMember, error)
+	ListAccountProducts(ctx context.Context) ([]AccountProduct, error)
+	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsWithProductPlans(ctx context.Context, arg ListAccountsWithProductPlansParams) ([]Account, error)
+	ListActiveSessions(ctx context.Context, username string) ([]Session, error)
+	ListBalanceAdjustments(ctx context.Context, arg ListBalanceAdjustmentsParams) ([]BalanceAdjustment, error)
+	ListBatchTransfers(ctx context.Context, batchID pgtype.Int8) ([]Transfer, error)
+	ListCurrencies(ctx context.Context) ([]Currency, error)
+	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	// Accounts the user is a member of, once the invitation is accepted
+	ListMemberAccounts(ctx context.Context, arg ListMemberAccountsParams) ([]Account, error)
+	ListPendingInvitations(ctx context.Context, username string) ([]AccountMember, error)
+	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	// Accounts whose balance differs from the sum of their ledger entries
+	ListUnreconciledAccounts(ctx context.Context) ([]ListUnreconciledAccountsRow, error)
+	LockUser(ctx context.Context, arg LockUserParams) (User, error)
+	MarkPasswordResetTokenUsed(ctx context.Context, id int64) (PasswordResetToken, error)
+	MarkSessionRotated(ctx context.Context, arg MarkSessionRotatedParams) (Session, error)
+	ResetUserFailedLogins(ctx context.Context, username string) (User, error)
+	RevokeSession(ctx context.Context, id string) error
+	RevokeSessionFamily(ctx context.Context, familyID string) error
+	RevokeUserSessions(ctx context.Context, username string) error
+	SetAccountProductEnabled(ctx context.Context, arg SetAccountProductEnabledParams) (AccountProduct, error)
+	SetCurrencyEnabled(ctx context.Context, arg SetCurrencyEnabledParams) (Currency, error)
+	SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error)
+	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountMemberRole(ctx context.Context, arg UpdateAccountMemberRoleParams) (AccountMember, error)
+	UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry, error)
+	UpdateTransfer(ctx context.Context, arg UpdateTransferParams) (Transfer, error)
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error)
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (User, error)
+}
+
+var _ Querier = (*Queries)(nil)
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// QueryLogger is a pgx tracer that logs failed queries with the ID of the request that ran them
+type QueryLogger struct{}
+
+type queryLoggerKey struct{}
+
+func (QueryLogger) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryLoggerKey{}, data.SQL)
+}
+
+func (QueryLogger) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	// Missing rows are an expected outcome that handlers deal with
+	if data.Err == nil || errors.Is(data.Err, pgx.ErrNoRows) {
+		return
+	}
+
+	sql, _ := ctx.Value(queryLoggerKey{}).(string)
+
+	log.Error().
+		Err(data.Err).
+		Str("request_id", util.RequestIDFromContext(ctx)).
+		Str("sql", sql).
+		Msg("database query failed")
+}
@@ -13,17 +13,20 @@ import (
 
 const createSession = `-- name: CreateSession :one
 INSERT INTO sessions (
-    id, username, refresh_token, is_revoked, expires_at
+    id, family_id, username, refresh_token, user_agent, client_ip, is_revoked, expires_at
 ) VALUES (
-  $1, $2, $3, $4, $5
+  $1, $2, $3, $4, $5, $6, $7, $8
 )
-RETURNING id, username, refresh_token, is_revoked, created_at, expires_at
+RETURNING id, username, refresh_token, is_revoked, created_at, expires_at, family_id, replaced_by, user_agent, client_ip
 `
 
 type CreateSessionParams struct {
 	ID           string             `json:"id"`
+	FamilyID     string             `json:"family_id"`
 	Username     string             `json:"username"`
 	RefreshToken string             `json:"refresh_token"`
+	UserAgent    string             `json:"user_agent"`
+	ClientIp     string             `json:"client_ip"`
 	IsRevoked    bool               `json:"is_revoked"`
 	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
 }
@@ -31,8 +34,11 @@ type CreateSessionParams struct {
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
 	row := q.db.QueryRow(ctx, createSession,
 		arg.ID,
+		arg.FamilyID,
 		arg.Username,
 		arg.RefreshToken,
+		arg.UserAgent,
+		arg.ClientIp,
 		arg.IsRevoked,
 		arg.ExpiresAt,
 	)
@@ -44,6 +50,10 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.IsRevoked,
 		&i.CreatedAt,
 		&i.ExpiresAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.UserAgent,
+		&i.ClientIp,
 	)
 	return i, err
 }
@@ -59,7 +69,7 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 }
 
 const getSession = `-- name: GetSession :one
-SELECT id, username, refresh_token, is_revoked, created_at, expires_at FROM sessions
+SELECT id, username, refresh_token, is_revoked, created_at, expires_at, family_id, replaced_by, user_agent, client_ip FROM sessions
 WHERE id = $1 LIMIT 1
 `
 
@@ -73,6 +83,136 @@ func (q *Queries) GetSession(ctx context.Context, id string) (Session, error) {
 		&i.IsRevoked,
 		&i.CreatedAt,
 		&i.ExpiresAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.UserAgent,
+		&i.ClientIp,
+	)
+	return i, err
+}
+
+const getSessionFamilyState = `-- name: GetSessionFamilyState :one
+SELECT
+  users.password_changed_at,
+  EXISTS (
+    SELECT 1 FROM sessions
+    WHERE sessions.family_id = $1
+      AND sessions.username = users.username
+      AND sessions.is_revoked = false
+      AND sessions.expires_at > now()
+  ) AS session_active
+FROM users
+WHERE users.username = $2
+`
+
+type GetSessionFamilyStateParams struct {
+	FamilyID string `json:"family_id"`
+	Username string `json:"username"`
+}
+
+type GetSessionFamilyStateRow struct {
+	PasswordChangedAt pgtype.Timestamptz `json:"password_changed_at"`
+	SessionActive     bool               `json:"session_active"`
+}
+
+func (q *Queries) GetSessionFamilyState(ctx context.Context, arg GetSessionFamilyStateParams) (GetSessionFamilyStateRow, error) {
+	row := q.db.QueryRow(ctx, getSessionFamilyState, arg.FamilyID, arg.Username)
+	var i GetSessionFamilyStateRow
+	err := row.Scan(&i.PasswordChangedAt, &i.SessionActive)
+	return i, err
+}
+
+const getSessionForUpdate = `-- name: GetSessionForUpdate :one
+SELECT id, username, refresh_token, is_revoked, created_at, expires_at, family_id, replaced_by, user_agent, client_ip FROM sessions
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetSessionForUpdate(ctx context.Context, id string) (Session, error) {
+	row := q.db.QueryRow(ctx, getSessionForUpdate, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RefreshToken,
+		&i.IsRevoked,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.UserAgent,
+		&i.ClientIp,
+	)
+	return i, err
+}
+
+const listActiveSessions = `-- name: ListActiveSessions :many
+SELECT id, username, refresh_token, is_revoked, created_at, expires_at, family_id, replaced_by, user_agent, client_ip FROM sessions
+WHERE username = $1
+  AND is_revoked = false
+  AND expires_at > now()
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListActiveSessions(ctx context.Context, username string) ([]Session, error) {
+	rows, err := q.db.Query(ctx, listActiveSessions, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RefreshToken,
+			&i.IsRevoked,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.FamilyID,
+			&i.ReplacedBy,
+			&i.UserAgent,
+			&i.ClientIp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markSessionRotated = `-- name: MarkSessionRotated :one
+UPDATE sessions
+SET
+  is_revoked = true,
+  replaced_by = $1
+WHERE id = $2
+RETURNING id, username, refresh_token, is_revoked, created_at, expires_at, family_id, replaced_by, user_agent, client_ip
+`
+
+type MarkSessionRotatedParams struct {
+	ReplacedBy pgtype.Text `json:"replaced_by"`
+	ID         string      `json:"id"`
+}
+
+func (q *Queries) MarkSessionRotated(ctx context.Context, arg MarkSessionRotatedParams) (Session, error) {
+	row := q.db.QueryRow(ctx, markSessionRotated, arg.ReplacedBy, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RefreshToken,
+		&i.IsRevoked,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.UserAgent,
+		&i.ClientIp,
 	)
 	return i, err
 }
@@ -87,3 +227,25 @@ func (q *Queries) RevokeSession(ctx context.Context, id string) error {
 	_, err := q.db.Exec(ctx, revokeSession, id)
 	return err
 }
+
+const revokeSessionFamily = `-- name: RevokeSessionFamily :exec
+UPDATE sessions
+SET is_revoked = true
+WHERE family_id = $1 AND is_revoked = false
+`
+
+func (q *Queries) RevokeSessionFamily(ctx context.Context, familyID string) error {
+	_, err := q.db.Exec(ctx, revokeSessionFamily, familyID)
+	return err
+}
+
+const revokeUserSessions = `-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET is_revoked = true
+WHERE username = $1 AND is_revoked = false
+`
+
+func (q *Queries) RevokeUserSessions(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, revokeUserSessions, username)
+	return err
+}
@@ -1,3 +1,221 @@
 package db
 
-//TODO: Write tests for the session.go file
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomSession(t *testing.T, user User) Session {
+	sessionID := uuid.NewString()
+
+	arg := CreateSessionParams{
+		ID:           sessionID,
+		FamilyID:     sessionID,
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    util.RandomString(12),
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+
+	session, err := testQueries.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, session)
+
+	require.Equal(t, arg.ID, session.ID)
+	require.Equal(t, arg.FamilyID, session.FamilyID)
+	require.Equal(t, arg.Username, session.Username)
+	require.Equal(t, arg.RefreshToken, session.RefreshToken)
+	require.Equal(t, arg.UserAgent, session.UserAgent)
+	require.Equal(t, arg.ClientIp, session.ClientIp)
+	require.False(t, session.IsRevoked)
+	require.False(t, session.ReplacedBy.Valid)
+	require.NotZero(t, session.CreatedAt)
+
+	return session
+}
+
+func TestCreateSession(t *testing.T) {
+	createRandomSession(t, createRandomUser(t))
+}
+
+func TestRevokeSession(t *testing.T) {
+	session1 := createRandomSession(t, createRandomUser(t))
+
+	err := testQueries.RevokeSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+
+	session2, err := testQueries.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.True(t, session2.IsRevoked)
+}
+
+func TestListActiveSessions(t *testing.T) {
+	user := createRandomUser(t)
+
+	var sessions []Session
+	for i := 0; i < 3; i++ {
+		sessions = append(sessions, createRandomSession(t, user))
+	}
+
+	// Revoked sessions and sessions of other users are not listed
+	err := testQueries.RevokeSession(context.Background(), sessions[0].ID)
+	require.NoError(t, err)
+	createRandomSession(t, createRandomUser(t))
+
+	activeSessions, err := testQueries.ListActiveSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, activeSessions, 2)
+
+	for _, session := range activeSessions {
+		require.Equal(t, user.Username, session.Username)
+		require.False(t, session.IsRevoked)
+		require.NotEqual(t, sessions[0].ID, session.ID)
+	}
+}
+
+func TestRevokeSessionFamily(t *testing.T) {
+	user := createRandomUser(t)
+	session1 := createRandomSession(t, user)
+	session2 := createRandomSession(t, user)
+
+	err := testQueries.RevokeSessionFamily(context.Background(), session1.FamilyID)
+	require.NoError(t, err)
+
+	revoked, err := testQueries.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.True(t, revoked.IsRevoked)
+
+	// Other devices of the same user stay signed in
+	other, err := testQueries.GetSession(context.Background(), session2.ID)
+	require.NoError(t, err)
+	require.False(t, other.IsRevoked)
+}
+
+func TestRotateSessionTx(t *testing.T) {
+	store := testStore
+	user := createRandomUser(t)
+	session1 := createRandomSession(t, user)
+
+	// The role changes after the session was created, the new session sees it
+	user, err := testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Username: user.Username,
+		Role:     util.AdminRole,
+	})
+	require.NoError(t, err)
+
+	var newSessionUser User
+	newSessionArg := func(user User) (CreateSessionParams, error) {
+		newSessionUser = user
+		return CreateSessionParams{
+			ID:           uuid.NewString(),
+			Username:     user.Username,
+			RefreshToken: util.RandomString(32),
+			ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+		}, nil
+	}
+
+	result, err := store.RotateSessionTx(context.Background(), RotateSessionTxParams{
+		SessionID:  session1.ID,
+		Username:   user.Username,
+		IssuedAt:   time.Now(),
+		NewSession: newSessionArg,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.AdminRole, newSessionUser.Role)
+	require.Equal(t, util.AdminRole, result.User.Role)
+	session2 := result.Session
+	require.Equal(t, session1.FamilyID, session2.FamilyID)
+	require.False(t, session2.IsRevoked)
+
+	rotated, err := testQueries.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.True(t, rotated.IsRevoked)
+	require.Equal(t, session2.ID, rotated.ReplacedBy.String)
+
+	// presenting the old refresh token again revokes the whole family
+	reuse, err := store.RotateSessionTx(context.Background(), RotateSessionTxParams{
+		SessionID:  session1.ID,
+		Username:   user.Username,
+		IssuedAt:   time.Now(),
+		NewSession: newSessionArg,
+	})
+	require.ErrorIs(t, err, ErrRefreshTokenReused)
+	require.Equal(t, session1.FamilyID, reuse.FamilyID)
+
+	session2, err = testQueries.GetSession(context.Background(), session2.ID)
+	require.NoError(t, err)
+	require.True(t, session2.IsRevoked)
+
+	_, err = store.RotateSessionTx(context.Background(), RotateSessionTxParams{
+		SessionID:  session2.ID,
+		Username:   user.Username,
+		IssuedAt:   time.Now(),
+		NewSession: newSessionArg,
+	})
+	require.ErrorIs(t, err, ErrSessionRevoked)
+}
+
+func TestRotateSessionTxAfterPasswordChange(t *testing.T) {
+	store := testStore
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+	issuedAt := time.Now().Add(-time.Minute)
+
+	_, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Username:          user.Username,
+		PasswordChangedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = store.RotateSessionTx(context.Background(), RotateSessionTxParams{
+		SessionID: session.ID,
+		Username:  user.Username,
+		IssuedAt:  issuedAt,
+		NewSession: func(user User) (CreateSessionParams, error) {
+			return CreateSessionParams{
+				ID:           uuid.NewString(),
+				Username:     user.Username,
+				RefreshToken: util.RandomString(32),
+				ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+			}, nil
+		},
+	})
+	require.ErrorIs(t, err, ErrSessionRevoked)
+}
+
+func TestGetSessionFamilyState(t *testing.T) {
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+
+	arg := GetSessionFamilyStateParams{
+		FamilyID: session.FamilyID,
+		Username: user.Username,
+	}
+
+	state, err := testQueries.GetSessionFamilyState(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, state.SessionActive)
+	require.WithinDuration(t, user.PasswordChangedAt.Time, state.PasswordChangedAt.Time, time.Second)
+
+	err = testQueries.RevokeSessionFamily(context.Background(), session.FamilyID)
+	require.NoError(t, err)
+
+	state, err = testQueries.GetSessionFamilyState(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, state.SessionActive)
+
+	// A session family cannot be used for another user
+	state, err = testQueries.GetSessionFamilyState(context.Background(), GetSessionFamilyStateParams{
+		FamilyID: createRandomSession(t, user).FamilyID,
+		Username: createRandomUser(t).Username,
+	})
+	require.NoError(t, err)
+	require.False(t, state.SessionActive)
+}
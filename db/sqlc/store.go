@@ -4,46 +4,100 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var ErrInsufficientBalance = errors.New("insufficient balance for transfer")
 
-type Store struct {
+const (
+	// checkViolationCode is the Postgres error code of a failed CHECK constraint
+	checkViolationCode = "23514"
+	// accountsBalanceCheck keeps balances from going negative
+	accountsBalanceCheck = "accounts_balance_check"
+)
+
+// Store provides every query and transaction, so handlers can be tested with a mock
+type Store interface {
+	Querier
+	CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (Account, error)
+	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error)
+	AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (AdjustBalanceTxResult, error)
+	RecordFailedLoginTx(ctx context.Context, arg RecordFailedLoginTxParams) error
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error)
+	RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (RotateSessionTxResult, error)
+	EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (EnableTOTPTxResult, error)
+	DisableTOTPTx(ctx context.Context, username string) (User, error)
+	UpdateAccountMemberTx(ctx context.Context, arg UpdateAccountMemberRoleParams) (AccountMember, error)
+	RemoveAccountMemberTx(ctx context.Context, arg DeleteAccountMemberParams) error
+	PostProductPlanTx(ctx context.Context, arg PostProductPlanTxParams) (PostProductPlanTxResult, error)
+}
+
+// txStore implements the transactions of the Store on top of the queries,
+// so every implementation shares them and only provides execTx
+type txStore struct {
+	// execTx runs fn in a transaction, committed when fn returns nil and rolled back otherwise.
+	// fn may run more than once, so it must not keep state between runs.
+	execTx func(ctx context.Context, opts txOptions, fn func(Querier) error) error
+}
+
+// SQLStore runs the queries and transactions on Postgres
+type SQLStore struct {
 	*Queries
-	db *pgxpool.Pool
+	txStore
+	db     *pgxpool.Pool
+	config TxConfig
 }
 
-func NewStore(db *pgxpool.Pool) *Store {
-	return &Store{
+func NewStore(db *pgxpool.Pool, config TxConfig) Store {
+	store := &SQLStore{
 		db:      db,
 		Queries: New(db),
+		config:  config,
 	}
+	store.txStore = txStore{execTx: store.execSQLTx}
+	return store
 }
 
-func (store *Store) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, pgx.TxOptions{})
-	if err != nil {
-		return err
+// execSQLTx runs fn at the isolation level configured for the transaction,
+// and runs it again when Postgres aborted it to resolve a conflict, see retryTx
+func (store *SQLStore) execSQLTx(ctx context.Context, opts txOptions, fn func(Querier) error) error {
+	isoLevel := opts.isoLevel
+	if level, ok := store.config.IsoLevels[opts.name]; ok {
+		isoLevel = level
 	}
 
-	q := New(tx)
-	err = fn(q)
-	if err != nil {
-		if rbErr := tx.Rollback(ctx); rbErr != nil {
-			return fmt.Errorf("tx error: %v, rb error: %v", err, rbErr)
+	return retryTx(ctx, store.config, opts.name, func() error {
+		tx, err := store.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: isoLevel})
+		if err != nil {
+			return err
 		}
-		return err
-	}
-	return tx.Commit(ctx)
+
+		q := New(tx)
+		err = fn(q)
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				return fmt.Errorf("tx error: %w, rb error: %v", err, rbErr)
+			}
+			return err
+		}
+		return tx.Commit(ctx)
+	})
 }
 
 type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	Amount        int64 `json:"amount"`
+	// InitiatedBy is the member making the transfer, whose role and spend limit are
+	// checked once the accounts are locked. It is empty for transfers made outside of
+	// a member, e.g. by bankctl.
+	InitiatedBy string `json:"initiated_by"`
 }
 
 type TransferTxResult struct {
@@ -57,14 +111,17 @@ type TransferTxResult struct {
 // Transfer Tx performs a money transfer from one account to the other
 // It creates a transfer record and update the account balance
 // It returns the created transfer record
-func (store *Store) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+//
+// Both accounts are locked in id order before the balance is checked, the same
+// order addMoney updates them in, so concurrent transfers between the same accounts
+// queue up instead of deadlocking and each one checks the balance left by the last.
+// The transfer must then keep the minimum balance and the monthly withdrawal limits
+// of the product of the source account, and the spend limit of the member making it.
+func (store txStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
-	err := store.execTx(ctx, func(q *Queries) error {
-		var err error
-
-		// Check if source account has sufficient balance
-		fromAccount, err := q.GetAccount(ctx, arg.FromAccountID)
+	err := store.execTx(ctx, transferTxOptions, func(q Querier) error {
+		fromAccount, err := lockAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
 		if err != nil {
 			return err
 		}
@@ -73,8 +130,24 @@ func (store *Store) TransferTx(ctx context.Context, arg TransferTxParams) (Trans
 			return ErrInsufficientBalance
 		}
 
-		// Continue with transfer if sufficient balance exists
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams(arg))
+		if arg.InitiatedBy != "" {
+			err = checkTransferMember(ctx, q, arg.FromAccountID, arg.InitiatedBy, arg.Amount)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = newWithdrawalRules(q, time.Now()).check(ctx, fromAccount, fromAccount.Balance, arg.Amount)
+		if err != nil {
+			return err
+		}
+
+		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+			InitiatedBy:   pgtype.Text{String: arg.InitiatedBy, Valid: arg.InitiatedBy != ""},
+		})
 		if err != nil {
 			return err
 		}
@@ -109,12 +182,46 @@ func (store *Store) TransferTx(ctx context.Context, arg TransferTxParams) (Trans
 
 		return nil
 	})
+	// The balance check constraint is the last line of defense, a transfer it
+	// stops failed for the same reason as one stopped by the check above
+	if isBalanceCheckViolation(err) {
+		err = ErrInsufficientBalance
+	}
 	return result, err
 }
 
+// lockAccounts locks the source and destination of a transfer, lowest id first,
+// and returns the source account as it is once locked. A missing account fails
+// the transfer with pgx.ErrNoRows before anything is written.
+func lockAccounts(ctx context.Context, q Querier, fromAccountID int64, toAccountID int64) (Account, error) {
+	firstID, secondID := fromAccountID, toAccountID
+	if secondID < firstID {
+		firstID, secondID = secondID, firstID
+	}
+
+	first, err := q.GetAccountForUpdate(ctx, firstID)
+	if err != nil {
+		return Account{}, err
+	}
+	second, err := q.GetAccountForUpdate(ctx, secondID)
+	if err != nil {
+		return Account{}, err
+	}
+
+	if firstID == fromAccountID {
+		return first, nil
+	}
+	return second, nil
+}
+
+func isBalanceCheckViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == checkViolationCode && pgErr.ConstraintName == accountsBalanceCheck
+}
+
 func addMoney(
 	ctx context.Context,
-	q *Queries,
+	q Querier,
 	accountID1 int64,
 	amount1 int64,
 	accountID2 int64,
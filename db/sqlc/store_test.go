@@ -2,13 +2,16 @@ package db
 
 import (
 	"context"
+	"errors"
+	"math/rand/v2"
 	"testing"
 
+	"github.com/Aadityaa2606/Bank-API/util"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTransfer(t *testing.T) {
-	store := NewStore(testDB)
+	store := testStore
 
 	account1 := createRandomAccount(t)
 	account2 := createRandomAccount(t)
@@ -111,7 +114,7 @@ func TestTransfer(t *testing.T) {
 }
 
 func TestTransferDeadlock(t *testing.T) {
-	store := NewStore(testDB)
+	store := testStore
 
 	account1 := createRandomAccount(t)
 	account2 := createRandomAccount(t)
@@ -158,3 +161,90 @@ func TestTransferDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+// TestTransferNoOverdraft runs many transfers in both directions between a few
+// accounts holding little money, so most of them race for the same funds
+func TestTransferNoOverdraft(t *testing.T) {
+	store := testStore
+
+	numAccounts := 4
+	initialBalance := int64(100)
+
+	accounts := make([]Account, numAccounts)
+	for i := range accounts {
+		account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    createRandomUser(t).Username,
+			Balance:  initialBalance,
+			Currency: util.USD,
+			Product:  DefaultAccountProduct,
+		})
+		require.NoError(t, err)
+		accounts[i] = account
+	}
+
+	workers := 20
+	transfersPerWorker := 25
+
+	type outcome struct {
+		arg TransferTxParams
+		err error
+	}
+	outcomes := make(chan outcome)
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := 0; i < transfersPerWorker; i++ {
+				from := rand.IntN(numAccounts)
+				to := (from + 1 + rand.IntN(numAccounts-1)) % numAccounts
+				arg := TransferTxParams{
+					FromAccountID: accounts[from].ID,
+					ToAccountID:   accounts[to].ID,
+					Amount:        1 + rand.Int64N(initialBalance),
+				}
+
+				_, err := store.TransferTx(context.Background(), arg)
+				outcomes <- outcome{arg: arg, err: err}
+			}
+		}()
+	}
+
+	expected := make(map[int64]int64, numAccounts)
+	for _, account := range accounts {
+		expected[account.ID] = initialBalance
+	}
+
+	succeeded := 0
+	for i := 0; i < workers*transfersPerWorker; i++ {
+		outcome := <-outcomes
+		if errors.Is(outcome.err, ErrInsufficientBalance) {
+			continue
+		}
+		require.NoError(t, outcome.err)
+
+		succeeded++
+		expected[outcome.arg.FromAccountID] -= outcome.arg.Amount
+		expected[outcome.arg.ToAccountID] += outcome.arg.Amount
+	}
+	require.Positive(t, succeeded)
+
+	var total int64
+	for _, account := range accounts {
+		updated, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+
+		require.GreaterOrEqual(t, updated.Balance, int64(0))
+		require.Equal(t, expected[account.ID], updated.Balance)
+		total += updated.Balance
+	}
+	require.Equal(t, int64(numAccounts)*initialBalance, total)
+}
+
+func TestTransferTxBalanceCheckViolation(t *testing.T) {
+	// A transfer stopped by the constraint instead of the balance check
+	store := txStore{execTx: func(ctx context.Context, opts txOptions, fn func(Querier) error) error {
+		return checkViolation("accounts", accountsBalanceCheck)
+	}}
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountID: 1, ToAccountID: 2, Amount: 10})
+	require.ErrorIs(t, err, ErrInsufficientBalance)
+}
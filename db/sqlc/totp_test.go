@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableAndDisableTOTPTx(t *testing.T) {
+	store := testStore
+	user := createRandomUser(t)
+	require.False(t, user.TotpEnabled)
+
+	secret, err := util.GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	user, err = testQueries.SetUserTOTPSecret(context.Background(), SetUserTOTPSecretParams{
+		Username:   user.Username,
+		TotpSecret: pgtype.Text{String: secret, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, secret, user.TotpSecret.String)
+	require.False(t, user.TotpEnabled)
+
+	codes, err := util.GenerateRecoveryCodes(3)
+	require.NoError(t, err)
+
+	codeHashes := make([]string, len(codes))
+	for i, code := range codes {
+		codeHashes[i] = util.HashSecret(util.NormalizeRecoveryCode(code))
+	}
+
+	result, err := store.EnableTOTPTx(context.Background(), EnableTOTPTxParams{
+		Username:           user.Username,
+		RecoveryCodeHashes: codeHashes,
+	})
+	require.NoError(t, err)
+	require.True(t, result.User.TotpEnabled)
+
+	// recovery codes are single-use
+	arg := UseRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: codeHashes[0],
+	}
+	recoveryCode, err := testQueries.UseRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, recoveryCode.UsedAt.Valid)
+
+	_, err = testQueries.UseRecoveryCode(context.Background(), arg)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	user, err = store.DisableTOTPTx(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.False(t, user.TotpEnabled)
+	require.False(t, user.TotpSecret.Valid)
+
+	_, err = testQueries.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: codeHashes[1],
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	user := createRandomUser(t)
+	arg := UseTOTPStepParams{
+		Username: user.Username,
+		Step:     time.Now().Unix() / 30,
+	}
+
+	user, err := testQueries.UseTOTPStep(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Step, user.TotpLastStep)
+
+	// A step is accepted once, older ones never again
+	_, err = testQueries.UseTOTPStep(context.Background(), arg)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	arg.Step--
+	_, err = testQueries.UseTOTPStep(context.Background(), arg)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	// A new secret starts over
+	user, err = testQueries.SetUserTOTPSecret(context.Background(), SetUserTOTPSecretParams{
+		Username:   user.Username,
+		TotpSecret: pgtype.Text{String: "SECRET", Valid: true},
+	})
+	require.NoError(t, err)
+	require.Zero(t, user.TotpLastStep)
+}
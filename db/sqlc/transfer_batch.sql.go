@@ -0,0 +1,48 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: transfer_batch.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createTransferBatch = `-- name: CreateTransferBatch :one
+INSERT INTO transfer_batches (
+    owner, mode, currency, legs, succeeded
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING id, owner, mode, currency, legs, succeeded, created_at
+`
+
+type CreateTransferBatchParams struct {
+	Owner     string `json:"owner"`
+	Mode      string `json:"mode"`
+	Currency  string `json:"currency"`
+	Legs      int32  `json:"legs"`
+	Succeeded int32  `json:"succeeded"`
+}
+
+func (q *Queries) CreateTransferBatch(ctx context.Context, arg CreateTransferBatchParams) (TransferBatch, error) {
+	row := q.db.QueryRow(ctx, createTransferBatch,
+		arg.Owner,
+		arg.Mode,
+		arg.Currency,
+		arg.Legs,
+		arg.Succeeded,
+	)
+	var i TransferBatch
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Mode,
+		&i.Currency,
+		&i.Legs,
+		&i.Succeeded,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -7,25 +7,33 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
-    from_account_id, to_account_id, amount
+    from_account_id, to_account_id, amount, initiated_by
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4
 )
-RETURNING id, from_account_id, to_account_id, amount, created_at
+RETURNING id, from_account_id, to_account_id, amount, created_at, batch_id, initiated_by
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64       `json:"from_account_id"`
+	ToAccountID   int64       `json:"to_account_id"`
+	Amount        int64       `json:"amount"`
+	InitiatedBy   pgtype.Text `json:"initiated_by"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRow(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.InitiatedBy,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -33,14 +41,24 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.BatchID,
+		&i.InitiatedBy,
 	)
 	return i, err
 }
 
+type CreateTransfersParams struct {
+	BatchID       pgtype.Int8 `json:"batch_id"`
+	FromAccountID int64       `json:"from_account_id"`
+	ToAccountID   int64       `json:"to_account_id"`
+	Amount        int64       `json:"amount"`
+	InitiatedBy   pgtype.Text `json:"initiated_by"`
+}
+
 const deleteTransfer = `-- name: DeleteTransfer :one
 DELETE FROM transfers
 WHERE id = $1
-RETURNING id, from_account_id, to_account_id, amount, created_at
+RETURNING id, from_account_id, to_account_id, amount, created_at, batch_id, initiated_by
 `
 
 func (q *Queries) DeleteTransfer(ctx context.Context, id int64) (Transfer, error) {
@@ -52,12 +70,33 @@ func (q *Queries) DeleteTransfer(ctx context.Context, id int64) (Transfer, error
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.BatchID,
+		&i.InitiatedBy,
 	)
 	return i, err
 }
 
+const getMemberSpendingSince = `-- name: GetMemberSpendingSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM transfers
+WHERE from_account_id = $1 AND initiated_by = $2 AND created_at >= $3
+`
+
+type GetMemberSpendingSinceParams struct {
+	FromAccountID int64              `json:"from_account_id"`
+	InitiatedBy   pgtype.Text        `json:"initiated_by"`
+	Since         pgtype.Timestamptz `json:"since"`
+}
+
+func (q *Queries) GetMemberSpendingSince(ctx context.Context, arg GetMemberSpendingSinceParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getMemberSpendingSince, arg.FromAccountID, arg.InitiatedBy, arg.Since)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
 const getTransferByFromAccountID = `-- name: GetTransferByFromAccountID :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, batch_id, initiated_by FROM transfers
 WHERE from_account_id = $1
 ORDER BY id
 LIMIT $2
@@ -85,6 +124,8 @@ func (q *Queries) GetTransferByFromAccountID(ctx context.Context, arg GetTransfe
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.BatchID,
+			&i.InitiatedBy,
 		); err != nil {
 			return nil, err
 		}
@@ -97,7 +138,7 @@ func (q *Queries) GetTransferByFromAccountID(ctx context.Context, arg GetTransfe
 }
 
 const getTransferByID = `-- name: GetTransferByID :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, batch_id, initiated_by FROM transfers
 WHERE id = $1
 `
 
@@ -110,12 +151,14 @@ func (q *Queries) GetTransferByID(ctx context.Context, id int64) (Transfer, erro
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.BatchID,
+		&i.InitiatedBy,
 	)
 	return i, err
 }
 
 const getTransferByToAccountID = `-- name: GetTransferByToAccountID :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, batch_id, initiated_by FROM transfers
 WHERE to_account_id = $1
 ORDER BY id
 LIMIT $2
@@ -143,6 +186,65 @@ func (q *Queries) GetTransferByToAccountID(ctx context.Context, arg GetTransferB
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.BatchID,
+			&i.InitiatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWithdrawalsSince = `-- name: GetWithdrawalsSince :one
+SELECT COUNT(*) AS count, COALESCE(SUM(amount), 0)::bigint AS total
+FROM transfers
+WHERE from_account_id = $1 AND created_at >= $2
+`
+
+type GetWithdrawalsSinceParams struct {
+	FromAccountID int64              `json:"from_account_id"`
+	Since         pgtype.Timestamptz `json:"since"`
+}
+
+type GetWithdrawalsSinceRow struct {
+	Count int64 `json:"count"`
+	Total int64 `json:"total"`
+}
+
+func (q *Queries) GetWithdrawalsSince(ctx context.Context, arg GetWithdrawalsSinceParams) (GetWithdrawalsSinceRow, error) {
+	row := q.db.QueryRow(ctx, getWithdrawalsSince, arg.FromAccountID, arg.Since)
+	var i GetWithdrawalsSinceRow
+	err := row.Scan(&i.Count, &i.Total)
+	return i, err
+}
+
+const listBatchTransfers = `-- name: ListBatchTransfers :many
+SELECT id, from_account_id, to_account_id, amount, created_at, batch_id, initiated_by FROM transfers
+WHERE batch_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListBatchTransfers(ctx context.Context, batchID pgtype.Int8) ([]Transfer, error) {
+	rows, err := q.db.Query(ctx, listBatchTransfers, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.BatchID,
+			&i.InitiatedBy,
 		); err != nil {
 			return nil, err
 		}
@@ -155,7 +257,7 @@ func (q *Queries) GetTransferByToAccountID(ctx context.Context, arg GetTransferB
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, batch_id, initiated_by FROM transfers
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -181,6 +283,8 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.BatchID,
+			&i.InitiatedBy,
 		); err != nil {
 			return nil, err
 		}
@@ -196,7 +300,7 @@ const updateTransfer = `-- name: UpdateTransfer :one
 UPDATE transfers
 SET amount = $1
 WHERE id = $2
-RETURNING id, from_account_id, to_account_id, amount, created_at
+RETURNING id, from_account_id, to_account_id, amount, created_at, batch_id, initiated_by
 `
 
 type UpdateTransferParams struct {
@@ -213,6 +317,8 @@ func (q *Queries) UpdateTransfer(ctx context.Context, arg UpdateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.BatchID,
+		&i.InitiatedBy,
 	)
 	return i, err
 }
@@ -0,0 +1,204 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Roles of the members of an account
+const (
+	// MemberOwner can do anything with the account, including closing it
+	MemberOwner = "owner"
+	// MemberCoOwner can transfer without limit and manage viewers and spenders
+	MemberCoOwner = "co_owner"
+	// MemberViewer can only read the account
+	MemberViewer = "viewer"
+	// MemberSpender can read the account and transfer up to its spend limit per calendar month
+	MemberSpender = "spender"
+)
+
+var (
+	ErrLastOwner          = errors.New("account must keep at least one owner")
+	ErrSpendLimit         = errors.New("transfer exceeds the spend limit of the member")
+	ErrTransferNotAllowed = errors.New("member is not allowed to transfer from the account")
+)
+
+// Active tells whether the member accepted the invitation. Pending members can do nothing.
+func (member AccountMember) Active() bool {
+	return member.AcceptedAt.Valid
+}
+
+// CanTransfer tells whether the member may send amount out of the account in one transfer.
+// It does not know what a spender already sent this month, TransferTx and BatchTransferTx
+// check that once the account is locked, see spendingRules.
+func (member AccountMember) CanTransfer(amount int64) bool {
+	if !member.Active() {
+		return false
+	}
+	switch member.Role {
+	case MemberOwner, MemberCoOwner:
+		return true
+	case MemberSpender:
+		return amount <= member.SpendLimit
+	}
+	return false
+}
+
+// spendingRules holds spenders to their spend limit, which covers every transfer they
+// make out of an account in a calendar month in UTC. The transfers of the month are read
+// once per member and transaction, the ones made since are added with record.
+type spendingRules struct {
+	q          Querier
+	monthStart pgtype.Timestamptz
+	spent      map[accountMemberKey]int64
+}
+
+func newSpendingRules(q Querier, now time.Time) *spendingRules {
+	return &spendingRules{
+		q:          q,
+		monthStart: pgtype.Timestamptz{Time: monthStart(now), Valid: true},
+		spent:      make(map[accountMemberKey]int64),
+	}
+}
+
+// check returns ErrSpendLimit when member is a spender who cannot send amount on top of
+// what it sent this month. The account must be locked so its transfers cannot change meanwhile.
+func (rules *spendingRules) check(ctx context.Context, member AccountMember, amount int64) error {
+	if member.Role != MemberSpender {
+		return nil
+	}
+
+	key := accountMemberKey{member.AccountID, member.Username}
+	spent, ok := rules.spent[key]
+	if !ok {
+		var err error
+		spent, err = rules.q.GetMemberSpendingSince(ctx, GetMemberSpendingSinceParams{
+			FromAccountID: member.AccountID,
+			InitiatedBy:   pgtype.Text{String: member.Username, Valid: true},
+			Since:         rules.monthStart,
+		})
+		if err != nil {
+			return err
+		}
+		rules.spent[key] = spent
+	}
+
+	if spent+amount > member.SpendLimit {
+		return ErrSpendLimit
+	}
+	return nil
+}
+
+// record counts a transfer that passed check towards the spend limit of the month
+func (rules *spendingRules) record(member AccountMember, amount int64) {
+	if member.Role != MemberSpender {
+		return
+	}
+	rules.spent[accountMemberKey{member.AccountID, member.Username}] += amount
+}
+
+// checkTransferMember loads the member making a transfer once the accounts are locked, and
+// returns ErrTransferNotAllowed or ErrSpendLimit when it cannot send amount
+func checkTransferMember(ctx context.Context, q Querier, accountID int64, username string, amount int64) error {
+	member, err := q.GetAccountMember(ctx, GetAccountMemberParams{
+		AccountID: accountID,
+		Username:  username,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTransferNotAllowed
+		}
+		return err
+	}
+
+	if !member.CanTransfer(amount) {
+		if member.Active() && member.Role == MemberSpender {
+			return ErrSpendLimit
+		}
+		return ErrTransferNotAllowed
+	}
+	return newSpendingRules(q, time.Now()).check(ctx, member, amount)
+}
+
+// CanManageAccount tells whether the member may change or close the account itself
+func (member AccountMember) CanManageAccount() bool {
+	return member.Active() && member.Role == MemberOwner
+}
+
+// CanManageRole tells whether the member may invite, change or remove members with role.
+// Owners manage everyone, co-owners only the viewers and spenders.
+func (member AccountMember) CanManageRole(role string) bool {
+	if !member.Active() {
+		return false
+	}
+	switch member.Role {
+	case MemberOwner:
+		return true
+	case MemberCoOwner:
+		return role == MemberViewer || role == MemberSpender
+	}
+	return false
+}
+
+// UpdateAccountMemberTx changes the role of a member, unless it would leave the account
+// without an owner. The account is locked so two owners cannot demote each other at once.
+func (store txStore) UpdateAccountMemberTx(ctx context.Context, arg UpdateAccountMemberRoleParams) (AccountMember, error) {
+	var member AccountMember
+
+	err := store.execTx(ctx, updateAccountMemberTxOptions, func(q Querier) error {
+		err := checkKeepsOwner(ctx, q, arg.AccountID, arg.Username, arg.Role)
+		if err != nil {
+			return err
+		}
+
+		member, err = q.UpdateAccountMemberRole(ctx, arg)
+		return err
+	})
+	return member, err
+}
+
+// RemoveAccountMemberTx removes a member or declines an invitation, unless it would
+// leave the account without an owner
+func (store txStore) RemoveAccountMemberTx(ctx context.Context, arg DeleteAccountMemberParams) error {
+	return store.execTx(ctx, removeAccountMemberTxOptions, func(q Querier) error {
+		err := checkKeepsOwner(ctx, q, arg.AccountID, arg.Username, "")
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteAccountMember(ctx, arg)
+	})
+}
+
+// checkKeepsOwner locks the account and returns ErrLastOwner when username is its only
+// active owner and newRole is not owner. A missing member fails with pgx.ErrNoRows.
+func checkKeepsOwner(ctx context.Context, q Querier, accountID int64, username string, newRole string) error {
+	_, err := q.GetAccountForUpdate(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	member, err := q.GetAccountMember(ctx, GetAccountMemberParams{
+		AccountID: accountID,
+		Username:  username,
+	})
+	if err != nil {
+		return err
+	}
+	if member.Role != MemberOwner || !member.Active() || newRole == MemberOwner {
+		return nil
+	}
+
+	owners, err := q.CountAccountOwners(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
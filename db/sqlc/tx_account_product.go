@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DefaultAccountProduct is the product of accounts opened without naming one
+const DefaultAccountProduct = "checking"
+
+var (
+	ErrUnknownProduct     = errors.New("unknown account product")
+	ErrProductDisabled    = errors.New("account product is disabled")
+	ErrCurrencyNotAllowed = errors.New("account product does not allow this currency")
+	ErrTooManyAccounts    = errors.New("maximum number of accounts of this product reached")
+	ErrMinimumBalance     = errors.New("transfer would take the balance below the product minimum")
+	ErrWithdrawalLimit    = errors.New("monthly withdrawal limit of the product reached")
+)
+
+type CreateAccountTxParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+	Product  string `json:"product"`
+}
+
+// CreateAccountTx opens an account with a zero balance once the rules of its product allow it,
+// and makes the owner its first member.
+//
+// The owner is locked first, so two requests opening the last account a product allows
+// cannot both see room for it.
+func (store txStore) CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, createAccountTxOptions, func(q Querier) error {
+		_, err := q.GetUserForUpdate(ctx, arg.Owner)
+		if err != nil {
+			return err
+		}
+
+		product, err := q.GetAccountProduct(ctx, arg.Product)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrUnknownProduct
+			}
+			return err
+		}
+		if !product.Enabled {
+			return ErrProductDisabled
+		}
+		if len(product.AllowedCurrencies) > 0 && !slices.Contains(product.AllowedCurrencies, arg.Currency) {
+			return ErrCurrencyNotAllowed
+		}
+
+		if product.MaxAccountsPerUser > 0 {
+			count, err := q.CountAccountsByProduct(ctx, CountAccountsByProductParams{
+				Owner:   arg.Owner,
+				Product: arg.Product,
+			})
+			if err != nil {
+				return err
+			}
+			if count >= int64(product.MaxAccountsPerUser) {
+				return ErrTooManyAccounts
+			}
+		}
+
+		account, err = q.CreateAccount(ctx, CreateAccountParams{
+			Owner:    arg.Owner,
+			Currency: arg.Currency,
+			Product:  arg.Product,
+			Balance:  0,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateAccountMember(ctx, CreateAccountMemberParams{
+			AccountID:  account.ID,
+			Username:   arg.Owner,
+			Role:       MemberOwner,
+			InvitedBy:  arg.Owner,
+			AcceptedAt: account.CreatedAt,
+		})
+		return err
+	})
+	return account, err
+}
+
+// withdrawalRules checks outgoing transfers against the product of their source account.
+// Products and the withdrawals of the month are read once per account and transaction,
+// the transfers made since are added with record.
+type withdrawalRules struct {
+	q          Querier
+	monthStart pgtype.Timestamptz
+	products   map[string]AccountProduct
+	usage      map[int64]GetWithdrawalsSinceRow
+}
+
+// monthStart returns the first instant of the calendar month of t in UTC
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func newWithdrawalRules(q Querier, now time.Time) *withdrawalRules {
+	return &withdrawalRules{
+		q:          q,
+		monthStart: pgtype.Timestamptz{Time: monthStart(now), Valid: true},
+		products:   make(map[string]AccountProduct),
+		usage:      make(map[int64]GetWithdrawalsSinceRow),
+	}
+}
+
+// check returns ErrMinimumBalance or ErrWithdrawalLimit when account, holding balance,
+// cannot send amount. The account must be locked so its withdrawals cannot change meanwhile.
+func (rules *withdrawalRules) check(ctx context.Context, account Account, balance int64, amount int64) error {
+	product, ok := rules.products[account.Product]
+	if !ok {
+		var err error
+		product, err = rules.q.GetAccountProduct(ctx, account.Product)
+		if err != nil {
+			return err
+		}
+		rules.products[account.Product] = product
+	}
+
+	if balance-amount < product.MinBalance {
+		return ErrMinimumBalance
+	}
+	if product.MaxMonthlyWithdrawals == 0 && product.MaxMonthlyWithdrawalAmount == 0 {
+		return nil
+	}
+
+	usage, ok := rules.usage[account.ID]
+	if !ok {
+		var err error
+		usage, err = rules.q.GetWithdrawalsSince(ctx, GetWithdrawalsSinceParams{
+			FromAccountID: account.ID,
+			Since:         rules.monthStart,
+		})
+		if err != nil {
+			return err
+		}
+		rules.usage[account.ID] = usage
+	}
+
+	if product.MaxMonthlyWithdrawals > 0 && usage.Count >= int64(product.MaxMonthlyWithdrawals) {
+		return ErrWithdrawalLimit
+	}
+	if product.MaxMonthlyWithdrawalAmount > 0 && usage.Total+amount > product.MaxMonthlyWithdrawalAmount {
+		return ErrWithdrawalLimit
+	}
+	return nil
+}
+
+// record counts a transfer that passed check towards the limits of the month
+func (rules *withdrawalRules) record(accountID int64, amount int64) {
+	usage, ok := rules.usage[accountID]
+	if !ok {
+		// The product of the account has no monthly limits
+		return
+	}
+	usage.Count++
+	usage.Total += amount
+	rules.usage[accountID] = usage
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomProduct adds a product under a random code, so runs against
+// Postgres do not collide with the products of earlier runs
+func createRandomProduct(t *testing.T, arg CreateAccountProductParams) AccountProduct {
+	arg.Code = util.RandomString(10)
+	arg.Name = arg.Code
+	if arg.AllowedCurrencies == nil {
+		arg.AllowedCurrencies = []string{}
+	}
+
+	product, err := testQueries.CreateAccountProduct(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Code, product.Code)
+	require.True(t, product.Enabled)
+	require.Equal(t, arg.AllowedCurrencies, product.AllowedCurrencies)
+	require.Equal(t, arg.MinBalance, product.MinBalance)
+	require.NotZero(t, product.CreatedAt)
+	return product
+}
+
+func createProductAccount(t *testing.T, product AccountProduct, balance int64) Account {
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    createRandomUser(t).Username,
+		Balance:  balance,
+		Currency: util.USD,
+		Product:  product.Code,
+	})
+	require.NoError(t, err)
+	addAccountMember(t, account, account.Owner, MemberOwner, 0)
+	return account
+}
+
+func TestCreateAccountTx(t *testing.T) {
+	user := createRandomUser(t)
+
+	account, err := testStore.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		Owner:    user.Username,
+		Currency: util.EUR,
+		Product:  DefaultAccountProduct,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, account.Owner)
+	require.Equal(t, util.EUR, account.Currency)
+	require.Equal(t, DefaultAccountProduct, account.Product)
+	require.Zero(t, account.Balance)
+}
+
+func TestCreateAccountTxMaxAccounts(t *testing.T) {
+	product := createRandomProduct(t, CreateAccountProductParams{MaxAccountsPerUser: 2})
+	user := createRandomUser(t)
+	arg := CreateAccountTxParams{Owner: user.Username, Currency: util.USD, Product: product.Code}
+
+	for range 2 {
+		_, err := testStore.CreateAccountTx(context.Background(), arg)
+		require.NoError(t, err)
+	}
+	_, err := testStore.CreateAccountTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrTooManyAccounts)
+
+	// The limit is per product, other accounts can still be opened
+	arg.Product = DefaultAccountProduct
+	_, err = testStore.CreateAccountTx(context.Background(), arg)
+	require.NoError(t, err)
+}
+
+func TestCreateAccountTxRejected(t *testing.T) {
+	euroOnly := createRandomProduct(t, CreateAccountProductParams{AllowedCurrencies: []string{util.EUR}})
+	disabled := createRandomProduct(t, CreateAccountProductParams{})
+	_, err := testQueries.SetAccountProductEnabled(context.Background(), SetAccountProductEnabledParams{
+		Code:    disabled.Code,
+		Enabled: false,
+	})
+	require.NoError(t, err)
+
+	user := createRandomUser(t)
+	for _, tc := range []struct {
+		product string
+		err     error
+	}{
+		{util.RandomString(10), ErrUnknownProduct},
+		{disabled.Code, ErrProductDisabled},
+		{euroOnly.Code, ErrCurrencyNotAllowed},
+	} {
+		_, err := testStore.CreateAccountTx(context.Background(), CreateAccountTxParams{
+			Owner:    user.Username,
+			Currency: util.USD,
+			Product:  tc.product,
+		})
+		require.ErrorIs(t, err, tc.err)
+	}
+
+	accounts, err := testQueries.ListAccounts(context.Background(), ListAccountsParams{Owner: user.Username, Limit: 5})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+}
+
+func TestCreateAccountProductLimitsCheck(t *testing.T) {
+	_, err := testQueries.CreateAccountProduct(context.Background(), CreateAccountProductParams{
+		Code:              util.RandomString(10),
+		Name:              "negative",
+		AllowedCurrencies: []string{},
+		MinBalance:        -1,
+	})
+	requirePgError(t, err, checkViolationCode, "account_products_limits_check")
+}
+
+func TestTransferTxMinimumBalance(t *testing.T) {
+	product := createRandomProduct(t, CreateAccountProductParams{MinBalance: 50})
+	from := createProductAccount(t, product, 100)
+	to := createRandomAccount(t)
+
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        51,
+	})
+	require.ErrorIs(t, err, ErrMinimumBalance)
+
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        50,
+	})
+	require.NoError(t, err)
+	requireBalance(t, from, 50)
+}
+
+func TestTransferTxWithdrawalLimits(t *testing.T) {
+	product := createRandomProduct(t, CreateAccountProductParams{
+		MaxMonthlyWithdrawals:      3,
+		MaxMonthlyWithdrawalAmount: 100,
+	})
+	to := createRandomAccount(t)
+
+	// The count runs out first
+	from := createProductAccount(t, product, 1000)
+	for range 3 {
+		_, err := testStore.TransferTx(context.Background(), TransferTxParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10})
+		require.NoError(t, err)
+	}
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10})
+	require.ErrorIs(t, err, ErrWithdrawalLimit)
+
+	// The amount runs out first
+	from = createProductAccount(t, product, 1000)
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 90})
+	require.NoError(t, err)
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 11})
+	require.ErrorIs(t, err, ErrWithdrawalLimit)
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10})
+	require.NoError(t, err)
+
+	// Incoming transfers do not count
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{FromAccountID: to.ID, ToAccountID: from.ID, Amount: 10})
+	require.NoError(t, err)
+}
+
+func TestBatchTransferTxWithdrawalLimits(t *testing.T) {
+	product := createRandomProduct(t, CreateAccountProductParams{MaxMonthlyWithdrawals: 2})
+	from := createProductAccount(t, product, 1000)
+	to := createBatchAccount(t, createRandomUser(t).Username, 0)
+
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10})
+	require.NoError(t, err)
+
+	// The legs of the batch count towards the limit along with the transfers before it
+	result, err := testStore.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Owner:    from.Owner,
+		Currency: util.USD,
+		Mode:     BatchBestEffort,
+		Legs: []BatchTransferLeg{
+			{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10},
+			{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, result.Legs[0].Err)
+	require.ErrorIs(t, result.Legs[1].Err, ErrWithdrawalLimit)
+	requireBalance(t, from, 980)
+
+	_, err = testStore.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Owner:    from.Owner,
+		Currency: util.USD,
+		Mode:     BatchAllOrNothing,
+		Legs:     []BatchTransferLeg{{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10}},
+	})
+	var legErr *BatchLegError
+	require.ErrorAs(t, err, &legErr)
+	require.ErrorIs(t, err, ErrWithdrawalLimit)
+}
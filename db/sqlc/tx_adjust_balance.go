@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrEmptyAdjustmentReason = errors.New("balance adjustments need a reason")
+
+type AdjustBalanceTxParams struct {
+	AccountID int64  `json:"account_id"`
+	Amount    int64  `json:"amount"`
+	Reason    string `json:"reason"`
+	Operator  string `json:"operator"`
+}
+
+type AdjustBalanceTxResult struct {
+	Adjustment BalanceAdjustment `json:"adjustment"`
+	Entry      Entry             `json:"entry"`
+	Account    Account           `json:"account"`
+}
+
+// AdjustBalanceTx corrects the balance of an account outside of a transfer.
+// The correction goes through the ledger like any other movement, as an entry
+// linked to an adjustment record saying who made it and why, so accounts stay
+// reconciled with their entries.
+func (store txStore) AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (AdjustBalanceTxResult, error) {
+	var result AdjustBalanceTxResult
+
+	if arg.Reason == "" {
+		return result, ErrEmptyAdjustmentReason
+	}
+
+	err := store.execTx(ctx, adjustBalanceTxOptions, func(q Querier) error {
+		var err error
+		result, err = adjustBalance(ctx, q, arg)
+		return err
+	})
+	if isBalanceCheckViolation(err) {
+		err = ErrInsufficientBalance
+	}
+	return result, err
+}
+
+// adjustBalance posts an adjustment inside of the transaction of q
+func adjustBalance(ctx context.Context, q Querier, arg AdjustBalanceTxParams) (AdjustBalanceTxResult, error) {
+	var result AdjustBalanceTxResult
+
+	account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+	if err != nil {
+		return result, err
+	}
+
+	if account.Balance+arg.Amount < 0 {
+		return result, ErrInsufficientBalance
+	}
+
+	result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.AccountID,
+		Amount:    arg.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		ID:     arg.AccountID,
+		Amount: arg.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Adjustment, err = q.CreateBalanceAdjustment(ctx, CreateBalanceAdjustmentParams{
+		AccountID: arg.AccountID,
+		EntryID:   result.Entry.ID,
+		Amount:    arg.Amount,
+		Reason:    arg.Reason,
+		Operator:  arg.Operator,
+	})
+	return result, err
+}
@@ -0,0 +1,281 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MaxBatchTransferLegs bounds the size of a batch, so a single transaction
+// never holds the locks of too many accounts
+const MaxBatchTransferLegs = 1000
+
+// Modes of a batch transfer
+const (
+	// BatchAllOrNothing commits the batch only when every leg can be made
+	BatchAllOrNothing = "all_or_nothing"
+	// BatchBestEffort makes the legs that can be made and reports the others
+	BatchBestEffort = "best_effort"
+)
+
+var (
+	ErrEmptyBatch             = errors.New("batch has no transfers")
+	ErrBatchTooLarge          = fmt.Errorf("batch has more than %d transfers", MaxBatchTransferLegs)
+	ErrUnknownBatchMode       = fmt.Errorf("batch mode must be %s or %s", BatchAllOrNothing, BatchBestEffort)
+	ErrInvalidTransferAmount  = errors.New("transfer amount must be positive")
+	ErrSameAccountTransfer    = errors.New("cannot transfer to the same account")
+	ErrTransferAccountMissing = errors.New("account not found")
+	ErrAccountNotOwned        = errors.New("batch owner cannot transfer from the source account")
+	ErrCurrencyMismatch       = errors.New("account currency does not match the batch currency")
+)
+
+// BatchLegError tells which leg stopped an all-or-nothing batch
+type BatchLegError struct {
+	Index int
+	Err   error
+}
+
+func (err *BatchLegError) Error() string {
+	return fmt.Sprintf("transfer %d: %v", err.Index, err.Err)
+}
+
+func (err *BatchLegError) Unwrap() error {
+	return err.Err
+}
+
+type BatchTransferLeg struct {
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	Amount        int64 `json:"amount"`
+}
+
+type BatchTransferTxParams struct {
+	// Owner must be a member allowed to transfer from the source account of every leg
+	Owner    string             `json:"owner"`
+	Currency string             `json:"currency"`
+	Mode     string             `json:"mode"`
+	Legs     []BatchTransferLeg `json:"legs"`
+}
+
+// BatchLegResult is the outcome of one leg, Err is nil when its transfer was made
+type BatchLegResult struct {
+	Transfer Transfer `json:"transfer"`
+	Err      error    `json:"-"`
+}
+
+type BatchTransferTxResult struct {
+	Batch TransferBatch `json:"batch"`
+	// Legs has the outcome of every leg, in the order of the params
+	Legs []BatchLegResult `json:"legs"`
+	// Accounts holds the balance of every account the batch moved money in or out of
+	Accounts []Account `json:"accounts"`
+}
+
+// BatchTransferTx makes many transfers in one transaction, e.g. a payroll run.
+//
+// Every account of the batch is locked in one statement, in id order like TransferTx,
+// and the legs are then checked in order against the balances left by the previous ones,
+// the product rules of their source account and the spend limit of the batch owner,
+// counting the legs before them.
+// Transfers and entries of the legs that pass are written with COPY, and each account
+// is updated once with its net change. In all-or-nothing mode the first leg that fails
+// rolls the batch back with a *BatchLegError, in best-effort mode it is reported in its
+// BatchLegResult and the other legs go ahead.
+func (store txStore) BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error) {
+	var result BatchTransferTxResult
+
+	switch {
+	case len(arg.Legs) == 0:
+		return result, ErrEmptyBatch
+	case len(arg.Legs) > MaxBatchTransferLegs:
+		return result, ErrBatchTooLarge
+	case arg.Mode != BatchAllOrNothing && arg.Mode != BatchBestEffort:
+		return result, ErrUnknownBatchMode
+	}
+
+	err := store.execTx(ctx, batchTransferTxOptions, func(q Querier) error {
+		result = BatchTransferTxResult{Legs: make([]BatchLegResult, len(arg.Legs))}
+
+		var accountIDs []int64
+		for _, leg := range arg.Legs {
+			accountIDs = append(accountIDs, leg.FromAccountID, leg.ToAccountID)
+		}
+		slices.Sort(accountIDs)
+		accountIDs = slices.Compact(accountIDs)
+
+		lockedAccounts, err := q.GetAccountsForUpdate(ctx, accountIDs)
+		if err != nil {
+			return err
+		}
+		accounts := make(map[int64]Account, len(lockedAccounts))
+		for _, account := range lockedAccounts {
+			accounts[account.ID] = account
+		}
+
+		// members holds the membership of the batch owner in every source account
+		members := make(map[int64]AccountMember)
+		for _, leg := range arg.Legs {
+			if _, ok := members[leg.FromAccountID]; ok {
+				continue
+			}
+			member, err := q.GetAccountMember(ctx, GetAccountMemberParams{
+				AccountID: leg.FromAccountID,
+				Username:  arg.Owner,
+			})
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+			members[leg.FromAccountID] = member
+		}
+
+		// changes holds the net amount each account gains or loses with the legs so far
+		changes := make(map[int64]int64)
+		rules := newWithdrawalRules(q, time.Now())
+		spending := newSpendingRules(q, time.Now())
+		var succeeded []int
+		for i, leg := range arg.Legs {
+			member := members[leg.FromAccountID]
+			err := checkBatchLeg(arg, leg, accounts, member, changes)
+			if err == nil {
+				fromAccount := accounts[leg.FromAccountID]
+				err = rules.check(ctx, fromAccount, fromAccount.Balance+changes[leg.FromAccountID], leg.Amount)
+				if err == nil {
+					err = spending.check(ctx, member, leg.Amount)
+				}
+				// Only broken rules fail a leg, a failed query fails the batch
+				if err != nil && !isBatchLegFailure(err) {
+					return err
+				}
+			}
+			if err != nil {
+				if arg.Mode == BatchAllOrNothing {
+					return &BatchLegError{Index: i, Err: err}
+				}
+				result.Legs[i].Err = err
+				continue
+			}
+
+			changes[leg.FromAccountID] -= leg.Amount
+			changes[leg.ToAccountID] += leg.Amount
+			rules.record(leg.FromAccountID, leg.Amount)
+			spending.record(member, leg.Amount)
+			succeeded = append(succeeded, i)
+		}
+
+		result.Batch, err = q.CreateTransferBatch(ctx, CreateTransferBatchParams{
+			Owner:     arg.Owner,
+			Mode:      arg.Mode,
+			Currency:  arg.Currency,
+			Legs:      int32(len(arg.Legs)),
+			Succeeded: int32(len(succeeded)),
+		})
+		if err != nil {
+			return err
+		}
+		if len(succeeded) == 0 {
+			return nil
+		}
+
+		batchID := pgtype.Int8{Int64: result.Batch.ID, Valid: true}
+		transferRows := make([]CreateTransfersParams, 0, len(succeeded))
+		entryRows := make([]CreateEntriesParams, 0, 2*len(succeeded))
+		for _, i := range succeeded {
+			leg := arg.Legs[i]
+			transferRows = append(transferRows, CreateTransfersParams{
+				BatchID:       batchID,
+				FromAccountID: leg.FromAccountID,
+				ToAccountID:   leg.ToAccountID,
+				Amount:        leg.Amount,
+				InitiatedBy:   pgtype.Text{String: arg.Owner, Valid: true},
+			})
+			entryRows = append(entryRows,
+				CreateEntriesParams{AccountID: leg.FromAccountID, Amount: -leg.Amount},
+				CreateEntriesParams{AccountID: leg.ToAccountID, Amount: leg.Amount},
+			)
+		}
+
+		if _, err = q.CreateTransfers(ctx, transferRows); err != nil {
+			return err
+		}
+		if _, err = q.CreateEntries(ctx, entryRows); err != nil {
+			return err
+		}
+
+		// COPY returns no rows, the transfers are read back in the order they were written
+		transfers, err := q.ListBatchTransfers(ctx, batchID)
+		if err != nil {
+			return err
+		}
+		if len(transfers) != len(succeeded) {
+			return fmt.Errorf("batch %d has %d transfers, expected %d", result.Batch.ID, len(transfers), len(succeeded))
+		}
+		for j, i := range succeeded {
+			result.Legs[i].Transfer = transfers[j]
+		}
+
+		for _, accountID := range accountIDs {
+			if changes[accountID] == 0 {
+				continue
+			}
+			account, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{
+				ID:     accountID,
+				Amount: changes[accountID],
+			})
+			if err != nil {
+				return err
+			}
+			result.Accounts = append(result.Accounts, account)
+		}
+
+		return nil
+	})
+	if isBalanceCheckViolation(err) {
+		err = ErrInsufficientBalance
+	}
+	return result, err
+}
+
+// isBatchLegFailure tells whether err was returned by a broken rule, as opposed to a failed query
+func isBatchLegFailure(err error) bool {
+	return errors.Is(err, ErrMinimumBalance) || errors.Is(err, ErrWithdrawalLimit) || errors.Is(err, ErrSpendLimit)
+}
+
+// checkBatchLeg checks a leg against the locked accounts, the membership of the batch owner
+// in its source account and the changes of the legs before it
+func checkBatchLeg(arg BatchTransferTxParams, leg BatchTransferLeg, accounts map[int64]Account, member AccountMember, changes map[int64]int64) error {
+	if leg.Amount <= 0 {
+		return ErrInvalidTransferAmount
+	}
+	if leg.FromAccountID == leg.ToAccountID {
+		return ErrSameAccountTransfer
+	}
+
+	fromAccount, ok := accounts[leg.FromAccountID]
+	if !ok {
+		return ErrTransferAccountMissing
+	}
+	toAccount, ok := accounts[leg.ToAccountID]
+	if !ok {
+		return ErrTransferAccountMissing
+	}
+
+	if !member.Active() || member.Role == MemberViewer {
+		return ErrAccountNotOwned
+	}
+	if !member.CanTransfer(leg.Amount) {
+		return ErrSpendLimit
+	}
+	if fromAccount.Currency != arg.Currency || toAccount.Currency != arg.Currency {
+		return ErrCurrencyMismatch
+	}
+
+	if fromAccount.Balance+changes[leg.FromAccountID] < leg.Amount {
+		return ErrInsufficientBalance
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createBatchAccount(t *testing.T, owner string, balance int64) Account {
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    owner,
+		Balance:  balance,
+		Currency: util.USD,
+		Product:  DefaultAccountProduct,
+	})
+	require.NoError(t, err)
+	addAccountMember(t, account, owner, MemberOwner, 0)
+	return account
+}
+
+func requireBalance(t *testing.T, account Account, balance int64) {
+	updated, err := testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, balance, updated.Balance)
+}
+
+func TestBatchTransferTx(t *testing.T) {
+	owner := createRandomUser(t)
+	from1 := createBatchAccount(t, owner.Username, 100)
+	from2 := createBatchAccount(t, owner.Username, 50)
+	to := createBatchAccount(t, createRandomUser(t).Username, 0)
+
+	result, err := testStore.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Owner:    owner.Username,
+		Currency: util.USD,
+		Mode:     BatchAllOrNothing,
+		Legs: []BatchTransferLeg{
+			{FromAccountID: from1.ID, ToAccountID: to.ID, Amount: 60},
+			{FromAccountID: from2.ID, ToAccountID: to.ID, Amount: 50},
+		},
+	})
+	require.NoError(t, err)
+
+	require.NotZero(t, result.Batch.ID)
+	require.Equal(t, int32(2), result.Batch.Legs)
+	require.Equal(t, int32(2), result.Batch.Succeeded)
+	require.Len(t, result.Legs, 2)
+	for i, leg := range []struct {
+		from   Account
+		amount int64
+	}{{from1, 60}, {from2, 50}} {
+		require.NoError(t, result.Legs[i].Err)
+		transfer := result.Legs[i].Transfer
+		require.NotZero(t, transfer.ID)
+		require.Equal(t, leg.from.ID, transfer.FromAccountID)
+		require.Equal(t, to.ID, transfer.ToAccountID)
+		require.Equal(t, leg.amount, transfer.Amount)
+		require.Equal(t, result.Batch.ID, transfer.BatchID.Int64)
+	}
+	require.Less(t, result.Legs[0].Transfer.ID, result.Legs[1].Transfer.ID)
+
+	require.Len(t, result.Accounts, 3)
+	requireBalance(t, from1, 40)
+	requireBalance(t, from2, 0)
+	requireBalance(t, to, 110)
+
+	entries, err := testStore.ListEntries(context.Background(), ListEntriesParams{AccountID: to.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+}
+
+func TestBatchTransferTxAllOrNothing(t *testing.T) {
+	owner := createRandomUser(t)
+	from := createBatchAccount(t, owner.Username, 100)
+	to := createBatchAccount(t, createRandomUser(t).Username, 0)
+
+	_, err := testStore.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Owner:    owner.Username,
+		Currency: util.USD,
+		Mode:     BatchAllOrNothing,
+		Legs: []BatchTransferLeg{
+			{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 70},
+			// Only 30 are left after the first leg
+			{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 40},
+		},
+	})
+	require.ErrorIs(t, err, ErrInsufficientBalance)
+
+	var legErr *BatchLegError
+	require.ErrorAs(t, err, &legErr)
+	require.Equal(t, 1, legErr.Index)
+
+	requireBalance(t, from, 100)
+	requireBalance(t, to, 0)
+}
+
+func TestBatchTransferTxBestEffort(t *testing.T) {
+	owner := createRandomUser(t)
+	from := createBatchAccount(t, owner.Username, 100)
+	other := createBatchAccount(t, createRandomUser(t).Username, 100)
+	to := createBatchAccount(t, createRandomUser(t).Username, 0)
+
+	result, err := testStore.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Owner:    owner.Username,
+		Currency: util.USD,
+		Mode:     BatchBestEffort,
+		Legs: []BatchTransferLeg{
+			{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 70},
+			{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 40},
+			{FromAccountID: other.ID, ToAccountID: to.ID, Amount: 10},
+			{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 30},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, int32(4), result.Batch.Legs)
+	require.Equal(t, int32(2), result.Batch.Succeeded)
+	require.NoError(t, result.Legs[0].Err)
+	require.ErrorIs(t, result.Legs[1].Err, ErrInsufficientBalance)
+	require.ErrorIs(t, result.Legs[2].Err, ErrAccountNotOwned)
+	require.NoError(t, result.Legs[3].Err)
+	require.Equal(t, int64(30), result.Legs[3].Transfer.Amount)
+
+	requireBalance(t, from, 0)
+	requireBalance(t, other, 100)
+	requireBalance(t, to, 100)
+}
+
+func TestBatchTransferTxInvalidParams(t *testing.T) {
+	owner := createRandomUser(t)
+	from := createBatchAccount(t, owner.Username, 100)
+
+	testCases := []struct {
+		name string
+		arg  BatchTransferTxParams
+		err  error
+	}{
+		{
+			name: "Empty",
+			arg:  BatchTransferTxParams{Owner: owner.Username, Currency: util.USD, Mode: BatchBestEffort},
+			err:  ErrEmptyBatch,
+		},
+		{
+			name: "TooLarge",
+			arg: BatchTransferTxParams{
+				Owner:    owner.Username,
+				Currency: util.USD,
+				Mode:     BatchBestEffort,
+				Legs:     make([]BatchTransferLeg, MaxBatchTransferLegs+1),
+			},
+			err: ErrBatchTooLarge,
+		},
+		{
+			name: "UnknownMode",
+			arg: BatchTransferTxParams{
+				Owner:    owner.Username,
+				Currency: util.USD,
+				Mode:     "some",
+				Legs:     []BatchTransferLeg{{FromAccountID: from.ID, ToAccountID: from.ID, Amount: 1}},
+			},
+			err: ErrUnknownBatchMode,
+		},
+		{
+			name: "SameAccount",
+			arg: BatchTransferTxParams{
+				Owner:    owner.Username,
+				Currency: util.USD,
+				Mode:     BatchAllOrNothing,
+				Legs:     []BatchTransferLeg{{FromAccountID: from.ID, ToAccountID: from.ID, Amount: 1}},
+			},
+			err: ErrSameAccountTransfer,
+		},
+		{
+			name: "MissingAccount",
+			arg: BatchTransferTxParams{
+				Owner:    owner.Username,
+				Currency: util.USD,
+				Mode:     BatchAllOrNothing,
+				Legs:     []BatchTransferLeg{{FromAccountID: from.ID, ToAccountID: from.ID + 1000000, Amount: 1}},
+			},
+			err: ErrTransferAccountMissing,
+		},
+		{
+			name: "CurrencyMismatch",
+			arg: BatchTransferTxParams{
+				Owner:    owner.Username,
+				Currency: util.EUR,
+				Mode:     BatchAllOrNothing,
+				Legs:     []BatchTransferLeg{{FromAccountID: from.ID, ToAccountID: createBatchAccount(t, owner.Username, 0).ID, Amount: 1}},
+			},
+			err: ErrCurrencyMismatch,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := testStore.BatchTransferTx(context.Background(), tc.arg)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	requireBalance(t, from, 100)
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type RecordFailedLoginTxParams struct {
+	Username string `json:"username"`
+	ClientIP string `json:"client_ip"`
+	// LockoutDuration returns how long to lock an account after the given amount of consecutive failures
+	LockoutDuration func(failedAttempts int32) time.Duration
+}
+
+// RecordFailedLoginTx logs a failed login attempt and, when the username exists,
+// bumps its consecutive failure count and locks the account once the policy says so.
+func (store txStore) RecordFailedLoginTx(ctx context.Context, arg RecordFailedLoginTxParams) error {
+	return store.execTx(ctx, recordFailedLoginTxOptions, func(q Querier) error {
+		_, err := q.CreateFailedLogin(ctx, CreateFailedLoginParams{
+			Username: arg.Username,
+			ClientIp: arg.ClientIP,
+		})
+		if err != nil {
+			return err
+		}
+
+		user, err := q.IncrementUserFailedLogins(ctx, arg.Username)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		lockoutDuration := arg.LockoutDuration(user.FailedLoginAttempts)
+		if lockoutDuration <= 0 {
+			return nil
+		}
+
+		_, err = q.LockUser(ctx, LockUserParams{
+			Username:    user.Username,
+			LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(lockoutDuration), Valid: true},
+		})
+		return err
+	})
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var ErrProductPlanPosted = errors.New("product plan of the account is already posted for this month")
+
+type PostProductPlanTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// Month is any time in the calendar month to post for, in UTC
+	Month    time.Time `json:"month"`
+	Operator string    `json:"operator"`
+}
+
+type PostProductPlanTxResult struct {
+	Posting     ProductPosting      `json:"posting"`
+	Account     Account             `json:"account"`
+	Adjustments []BalanceAdjustment `json:"adjustments"`
+}
+
+// PostProductPlanTx credits the monthly interest and charges the monthly fee of the
+// product of an account, at most once per account and month.
+//
+// Both go through the ledger as balance adjustments made by the operator. Interest
+// is simple interest on the balance at the time of posting, so postings are meant
+// to run right after the month ends. The fee is charged after the interest and
+// only takes what the balance holds, since balances cannot go negative.
+func (store txStore) PostProductPlanTx(ctx context.Context, arg PostProductPlanTxParams) (PostProductPlanTxResult, error) {
+	var result PostProductPlanTxResult
+
+	period := pgtype.Date{Time: monthStart(arg.Month), Valid: true}
+	month := period.Time.Format("2006-01")
+
+	err := store.execTx(ctx, postProductPlanTxOptions, func(q Querier) error {
+		result = PostProductPlanTxResult{Adjustments: []BalanceAdjustment{}}
+
+		// The lock keeps a concurrent run from posting the same month twice
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.GetProductPosting(ctx, GetProductPostingParams{AccountID: account.ID, Period: period})
+		if err == nil {
+			return ErrProductPlanPosted
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		product, err := q.GetAccountProduct(ctx, account.Product)
+		if err != nil {
+			return err
+		}
+
+		interest := monthlyInterest(account.Balance, product.InterestRateBps)
+		fee := min(product.MonthlyFee, account.Balance+interest)
+
+		postings := []AdjustBalanceTxParams{
+			{Amount: interest, Reason: fmt.Sprintf("interest for %s at %d bps", month, product.InterestRateBps)},
+			{Amount: -fee, Reason: fmt.Sprintf("monthly fee for %s", month)},
+		}
+		for _, posting := range postings {
+			if posting.Amount == 0 {
+				continue
+			}
+			posting.AccountID = account.ID
+			posting.Operator = arg.Operator
+
+			adjusted, err := adjustBalance(ctx, q, posting)
+			if err != nil {
+				return err
+			}
+			account = adjusted.Account
+			result.Adjustments = append(result.Adjustments, adjusted.Adjustment)
+		}
+
+		result.Account = account
+		result.Posting, err = q.CreateProductPosting(ctx, CreateProductPostingParams{
+			AccountID: account.ID,
+			Period:    period,
+			Interest:  interest,
+			Fee:       fee,
+		})
+		return err
+	})
+	return result, err
+}
+
+// monthlyInterest is a twelfth of the yearly rate in basis points, rounded down.
+// The balance is split so the multiplication cannot overflow.
+func monthlyInterest(balance int64, rateBps int32) int64 {
+	const divisor = 12 * 10000
+	rate := int64(rateBps)
+	return balance/divisor*rate + balance%divisor*rate/divisor
+}
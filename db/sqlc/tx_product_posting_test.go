@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostProductPlanTx(t *testing.T) {
+	// 12% a year is 1% a month
+	product := createRandomProduct(t, CreateAccountProductParams{InterestRateBps: 1200, MonthlyFee: 50})
+	account := createProductAccount(t, product, 10000)
+	month := time.Date(2025, time.May, 17, 12, 0, 0, 0, time.UTC)
+
+	arg := PostProductPlanTxParams{AccountID: account.ID, Month: month, Operator: "bankctl"}
+	result, err := testStore.PostProductPlanTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, account.ID, result.Posting.AccountID)
+	require.Equal(t, time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC), result.Posting.Period.Time)
+	require.Equal(t, int64(100), result.Posting.Interest)
+	require.Equal(t, int64(50), result.Posting.Fee)
+	require.Equal(t, int64(10050), result.Account.Balance)
+	requireBalance(t, account, 10050)
+
+	require.Len(t, result.Adjustments, 2)
+	require.Equal(t, int64(100), result.Adjustments[0].Amount)
+	require.Equal(t, "interest for 2025-05 at 1200 bps", result.Adjustments[0].Reason)
+	require.Equal(t, int64(-50), result.Adjustments[1].Amount)
+	require.Equal(t, "monthly fee for 2025-05", result.Adjustments[1].Reason)
+	require.Equal(t, "bankctl", result.Adjustments[1].Operator)
+
+	// Running again for the same month posts nothing
+	arg.Month = month.AddDate(0, 0, 10)
+	_, err = testStore.PostProductPlanTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrProductPlanPosted)
+	requireBalance(t, account, 10050)
+
+	arg.Month = month.AddDate(0, 1, 0)
+	result, err = testStore.PostProductPlanTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), result.Posting.Interest)
+	requireBalance(t, account, 10100)
+}
+
+func TestPostProductPlanTxFeeAboveBalance(t *testing.T) {
+	product := createRandomProduct(t, CreateAccountProductParams{MonthlyFee: 500})
+	account := createProductAccount(t, product, 200)
+
+	result, err := testStore.PostProductPlanTx(context.Background(), PostProductPlanTxParams{
+		AccountID: account.ID,
+		Month:     time.Now(),
+		Operator:  "bankctl",
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Posting.Interest)
+	require.Equal(t, int64(200), result.Posting.Fee)
+	require.Len(t, result.Adjustments, 1)
+	requireBalance(t, account, 0)
+}
+
+func TestListAccountsWithProductPlans(t *testing.T) {
+	product := createRandomProduct(t, CreateAccountProductParams{MonthlyFee: 10})
+	withPlan := createProductAccount(t, product, 100)
+	withoutPlan := createRandomAccount(t)
+
+	arg := ListAccountsWithProductPlansParams{
+		OpenedBefore: withPlan.CreatedAt,
+		AfterID:      withPlan.ID - 1,
+		Limit:        1,
+	}
+	arg.OpenedBefore.Time = arg.OpenedBefore.Time.Add(time.Second)
+
+	accounts, err := testQueries.ListAccountsWithProductPlans(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, withPlan.ID, accounts[0].ID)
+
+	// Accounts opened after the month are left out
+	arg.OpenedBefore = withPlan.CreatedAt
+	accounts, err = testQueries.ListAccountsWithProductPlans(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	// So are accounts whose product has neither interest nor a fee
+	arg.OpenedBefore.Time = time.Now().Add(time.Minute)
+	arg.AfterID = withoutPlan.ID - 1
+	accounts, err = testQueries.ListAccountsWithProductPlans(context.Background(), arg)
+	require.NoError(t, err)
+	for _, account := range accounts {
+		require.NotEqual(t, withoutPlan.ID, account.ID)
+	}
+}
+
+func TestMonthlyInterest(t *testing.T) {
+	require.Equal(t, int64(0), monthlyInterest(0, 150))
+	require.Equal(t, int64(0), monthlyInterest(10000, 0))
+	// 1.5% a year on 100.00 is 0.125 a month, rounded down
+	require.Equal(t, int64(12), monthlyInterest(10000, 150))
+	require.Equal(t, int64(100), monthlyInterest(10000, 1200))
+
+	// The largest balance does not overflow
+	expected := new(big.Int).Mul(big.NewInt(math.MaxInt64), big.NewInt(150))
+	expected.Div(expected, big.NewInt(120000))
+	require.Equal(t, expected.Int64(), monthlyInterest(math.MaxInt64, 150))
+}
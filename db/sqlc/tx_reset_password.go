@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var ErrInvalidResetToken = errors.New("password reset token is invalid or has expired")
+
+type ResetPasswordTxParams struct {
+	TokenHash      string `json:"token_hash"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+type ResetPasswordTxResult struct {
+	User User `json:"user"`
+}
+
+// ResetPasswordTx consumes a password reset token and sets the new password.
+// The token is single-use: it is locked and marked as used in the same transaction,
+// and every existing session of the user is revoked once the password changes.
+func (store txStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := store.execTx(ctx, resetPasswordTxOptions, func(q Querier) error {
+		resetToken, err := q.GetPasswordResetTokenForUpdate(ctx, arg.TokenHash)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+
+		if resetToken.UsedAt.Valid || time.Now().After(resetToken.ExpiresAt.Time) {
+			return ErrInvalidResetToken
+		}
+
+		_, err = q.MarkPasswordResetTokenUsed(ctx, resetToken.ID)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username: resetToken.Username,
+			HashedPassword: pgtype.Text{
+				String: arg.HashedPassword,
+				Valid:  true,
+			},
+			PasswordChangedAt: pgtype.Timestamptz{
+				Time:  time.Now(),
+				Valid: true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.RevokeUserSessions(ctx, resetToken.Username)
+	})
+	return result, err
+}
@@ -0,0 +1,204 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/Aadityaa2606/Bank-API/metrics"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+)
+
+// txOptions describe one kind of transaction
+type txOptions struct {
+	// name identifies the transaction in TxConfig.IsoLevels, logs and metrics
+	name     string
+	isoLevel pgx.TxIsoLevel
+}
+
+// The default isolation of every transaction. They all lock the rows they check
+// with FOR UPDATE or change them in single statements, which read committed keeps
+// consistent. Stricter levels can be set with TxConfig.IsoLevels and rely on the
+// retries to resolve the conflicts they report.
+var (
+	createAccountTxOptions       = txOptions{name: "create_account", isoLevel: pgx.ReadCommitted}
+	transferTxOptions            = txOptions{name: "transfer", isoLevel: pgx.ReadCommitted}
+	batchTransferTxOptions       = txOptions{name: "batch_transfer", isoLevel: pgx.ReadCommitted}
+	adjustBalanceTxOptions       = txOptions{name: "adjust_balance", isoLevel: pgx.ReadCommitted}
+	recordFailedLoginTxOptions   = txOptions{name: "record_failed_login", isoLevel: pgx.ReadCommitted}
+	resetPasswordTxOptions       = txOptions{name: "reset_password", isoLevel: pgx.ReadCommitted}
+	rotateSessionTxOptions       = txOptions{name: "rotate_session", isoLevel: pgx.ReadCommitted}
+	enableTOTPTxOptions          = txOptions{name: "enable_totp", isoLevel: pgx.ReadCommitted}
+	disableTOTPTxOptions         = txOptions{name: "disable_totp", isoLevel: pgx.ReadCommitted}
+	updateAccountMemberTxOptions = txOptions{name: "update_account_member", isoLevel: pgx.ReadCommitted}
+	removeAccountMemberTxOptions = txOptions{name: "remove_account_member", isoLevel: pgx.ReadCommitted}
+	postProductPlanTxOptions     = txOptions{name: "post_product_plan", isoLevel: pgx.ReadCommitted}
+)
+
+var allTxOptions = []txOptions{
+	createAccountTxOptions,
+	transferTxOptions,
+	batchTransferTxOptions,
+	adjustBalanceTxOptions,
+	recordFailedLoginTxOptions,
+	resetPasswordTxOptions,
+	rotateSessionTxOptions,
+	enableTOTPTxOptions,
+	disableTOTPTxOptions,
+	updateAccountMemberTxOptions,
+	removeAccountMemberTxOptions,
+	postProductPlanTxOptions,
+}
+
+// Postgres aborts one of the transactions involved in these conflicts,
+// running it again usually succeeds
+const (
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
+)
+
+// TxConfig tunes how the SQLStore runs transactions
+type TxConfig struct {
+	// IsoLevels overrides the isolation level of transactions by name, e.g. "transfer"
+	IsoLevels map[string]pgx.TxIsoLevel
+	// MaxRetries bounds how many times a transaction aborted by a serialization
+	// failure or a deadlock is run again, 0 disables retries
+	MaxRetries int
+	// RetryBaseDelay is the backoff before the first retry, it doubles with every
+	// retry up to RetryMaxDelay and is jittered so conflicting transactions spread out
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+// NewTxConfig builds the transaction settings from the configuration
+func NewTxConfig(config util.Config) (TxConfig, error) {
+	isoLevels, err := ParseTxIsoLevels(config.TxIsolationLevels)
+	if err != nil {
+		return TxConfig{}, err
+	}
+
+	return TxConfig{
+		IsoLevels:      isoLevels,
+		MaxRetries:     int(config.TxMaxRetries),
+		RetryBaseDelay: config.TxRetryBaseDelay,
+		RetryMaxDelay:  config.TxRetryMaxDelay,
+	}, nil
+}
+
+var isoLevelNames = map[string]pgx.TxIsoLevel{
+	"read_committed":  pgx.ReadCommitted,
+	"repeatable_read": pgx.RepeatableRead,
+	"serializable":    pgx.Serializable,
+}
+
+// ParseTxIsoLevels reads a comma separated list of transaction=level pairs,
+// e.g. "transfer=serializable,rotate_session=repeatable_read"
+func ParseTxIsoLevels(spec string) (map[string]pgx.TxIsoLevel, error) {
+	isoLevels := make(map[string]pgx.TxIsoLevel)
+	if strings.TrimSpace(spec) == "" {
+		return isoLevels, nil
+	}
+
+	for _, pair := range strings.Split(spec, ",") {
+		name, levelName, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			return nil, fmt.Errorf("expected transaction=level, got %q", pair)
+		}
+
+		known := false
+		for _, opts := range allTxOptions {
+			known = known || opts.name == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown transaction %q", name)
+		}
+
+		level, ok := isoLevelNames[levelName]
+		if !ok {
+			return nil, fmt.Errorf("unknown isolation level %q for %s, use read_committed, repeatable_read or serializable", levelName, name)
+		}
+		isoLevels[name] = level
+	}
+
+	return isoLevels, nil
+}
+
+// retryTx calls run until it succeeds, fails with an error that retrying cannot fix,
+// or has been retried config.MaxRetries times. Only the error of the last run is returned.
+func retryTx(ctx context.Context, config TxConfig, name string, run func() error) error {
+	for retries := 0; ; retries++ {
+		err := run()
+
+		code, retryable := retryableTxError(err)
+		if !retryable {
+			if err == nil && retries > 0 {
+				log.Info().
+					Str("request_id", util.RequestIDFromContext(ctx)).
+					Str("tx", name).
+					Int("retries", retries).
+					Msg("transaction succeeded after retries")
+			}
+			return err
+		}
+
+		if retries >= config.MaxRetries {
+			metrics.TxRetriesExhausted(name)
+			log.Error().
+				Err(err).
+				Str("request_id", util.RequestIDFromContext(ctx)).
+				Str("tx", name).
+				Int("retries", retries).
+				Msg("transaction failed after exhausting its retries")
+			return err
+		}
+
+		delay := retryDelay(config, retries)
+		metrics.TxRetried(name, code)
+		log.Warn().
+			Err(err).
+			Str("request_id", util.RequestIDFromContext(ctx)).
+			Str("tx", name).
+			Int("retry", retries+1).
+			Dur("backoff", delay).
+			Msg("retrying transaction")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryableTxError returns the code of a serialization failure or deadlock
+func retryableTxError(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return "", false
+	}
+	return pgErr.Code, pgErr.Code == serializationFailureCode || pgErr.Code == deadlockDetectedCode
+}
+
+// retryDelay doubles the base delay with every retry and picks a random
+// duration in its upper half, so the delay never collapses to zero
+func retryDelay(config TxConfig, retries int) time.Duration {
+	delay := config.RetryBaseDelay
+	for i := 0; i < retries && delay < config.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, config.RetryMaxDelay)
+
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
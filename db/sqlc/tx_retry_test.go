@@ -0,0 +1,195 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+var testTxConfig = TxConfig{
+	MaxRetries:     3,
+	RetryBaseDelay: time.Millisecond,
+	RetryMaxDelay:  4 * time.Millisecond,
+}
+
+func TestRetryTx(t *testing.T) {
+	serializationFailure := &pgconn.PgError{Code: serializationFailureCode}
+	deadlock := &pgconn.PgError{Code: deadlockDetectedCode}
+
+	testCases := []struct {
+		name     string
+		errs     []error
+		runs     int
+		checkErr func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			errs: []error{nil},
+			runs: 1,
+			checkErr: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "RetriedConflicts",
+			errs: []error{serializationFailure, deadlock, nil},
+			runs: 3,
+			checkErr: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "WrappedConflict",
+			errs: []error{errors.Join(errors.New("rollback failed"), deadlock), nil},
+			runs: 2,
+			checkErr: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "RetriesExhausted",
+			errs: []error{deadlock, deadlock, deadlock, deadlock, nil},
+			runs: 4,
+			checkErr: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, deadlock)
+			},
+		},
+		{
+			name: "NotRetryable",
+			errs: []error{&pgconn.PgError{Code: "23514"}, nil},
+			runs: 1,
+			checkErr: func(t *testing.T, err error) {
+				var pgErr *pgconn.PgError
+				require.ErrorAs(t, err, &pgErr)
+				require.Equal(t, "23514", pgErr.Code)
+			},
+		},
+		{
+			name: "InsufficientBalance",
+			errs: []error{ErrInsufficientBalance, nil},
+			runs: 1,
+			checkErr: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInsufficientBalance)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			runs := 0
+			err := retryTx(context.Background(), testTxConfig, "test", func() error {
+				err := tc.errs[runs]
+				runs++
+				return err
+			})
+
+			tc.checkErr(t, err)
+			require.Equal(t, tc.runs, runs)
+		})
+	}
+}
+
+func TestRetryTxStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	config := TxConfig{MaxRetries: 3, RetryBaseDelay: time.Hour, RetryMaxDelay: time.Hour}
+
+	runs := 0
+	err := retryTx(ctx, config, "test", func() error {
+		runs++
+		cancel()
+		return &pgconn.PgError{Code: serializationFailureCode}
+	})
+
+	require.Error(t, err)
+	require.Equal(t, 1, runs)
+}
+
+func TestRetryDelay(t *testing.T) {
+	config := TxConfig{RetryBaseDelay: 10 * time.Millisecond, RetryMaxDelay: 50 * time.Millisecond}
+
+	for range 100 {
+		delay := retryDelay(config, 0)
+		require.GreaterOrEqual(t, delay, 5*time.Millisecond)
+		require.LessOrEqual(t, delay, 10*time.Millisecond)
+
+		delay = retryDelay(config, 2)
+		require.GreaterOrEqual(t, delay, 20*time.Millisecond)
+		require.LessOrEqual(t, delay, 40*time.Millisecond)
+
+		// Capped once doubling passes the maximum
+		delay = retryDelay(config, 10)
+		require.GreaterOrEqual(t, delay, 25*time.Millisecond)
+		require.LessOrEqual(t, delay, 50*time.Millisecond)
+	}
+
+	require.Zero(t, retryDelay(TxConfig{}, 3))
+}
+
+func TestParseTxIsoLevels(t *testing.T) {
+	isoLevels, err := ParseTxIsoLevels("")
+	require.NoError(t, err)
+	require.Empty(t, isoLevels)
+
+	isoLevels, err = ParseTxIsoLevels("transfer=serializable, rotate_session=repeatable_read")
+	require.NoError(t, err)
+	require.Equal(t, map[string]pgx.TxIsoLevel{
+		"transfer":       pgx.Serializable,
+		"rotate_session": pgx.RepeatableRead,
+	}, isoLevels)
+
+	_, err = ParseTxIsoLevels("transfer")
+	require.ErrorContains(t, err, "expected transaction=level")
+
+	_, err = ParseTxIsoLevels("payout=serializable")
+	require.ErrorContains(t, err, `unknown transaction "payout"`)
+
+	_, err = ParseTxIsoLevels("transfer=snapshot")
+	require.ErrorContains(t, err, `unknown isolation level "snapshot"`)
+}
+
+// TestSerializableTransfersRetry runs conflicting transfers at the serializable
+// level, where Postgres aborts all but one of them and the retries finish the rest
+func TestSerializableTransfersRetry(t *testing.T) {
+	sqlStore, ok := testStore.(*SQLStore)
+	if !ok {
+		t.Skip("the in-memory store never reports conflicts")
+	}
+
+	store := NewStore(sqlStore.db, TxConfig{
+		IsoLevels:      map[string]pgx.TxIsoLevel{transferTxOptions.name: pgx.Serializable},
+		MaxRetries:     20,
+		RetryBaseDelay: time.Millisecond,
+		RetryMaxDelay:  50 * time.Millisecond,
+	})
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	n := 5
+	amount := int64(10)
+	errs := make(chan error)
+
+	for range n {
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	for range n {
+		require.NoError(t, <-errs)
+	}
+
+	updated1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-int64(n)*amount, updated1.Balance)
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrSessionRevoked     = errors.New("session has been revoked")
+	ErrRefreshTokenReused = errors.New("refresh token has already been used, every session of its family has been revoked")
+)
+
+type RotateSessionTxParams struct {
+	// SessionID is the session of the refresh token being exchanged
+	SessionID string `json:"session_id"`
+	Username  string `json:"username"`
+	// IssuedAt is when the refresh token was issued, tokens older than the last password change are refused
+	IssuedAt time.Time `json:"issued_at"`
+	// NewSession builds the session of the new refresh token, which joins the family of the
+	// old one. It gets the user as they are now, so the new tokens carry their current role.
+	NewSession func(user User) (CreateSessionParams, error) `json:"-"`
+}
+
+type RotateSessionTxResult struct {
+	Session Session `json:"session"`
+	User    User    `json:"user"`
+	// FamilyID is the family of the refresh token, also set with ErrRefreshTokenReused
+	// so callers can drop the revoked family from their caches
+	FamilyID string `json:"family_id"`
+}
+
+// RotateSessionTx exchanges the session of a refresh token for a new one in the same family.
+// A refresh token can only be exchanged once. Presenting it again means it was stolen,
+// so the whole family is revoked and ErrRefreshTokenReused is returned.
+func (store txStore) RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (RotateSessionTxResult, error) {
+	var result RotateSessionTxResult
+	var reused bool
+
+	err := store.execTx(ctx, rotateSessionTxOptions, func(q Querier) error {
+		reused = false
+		result = RotateSessionTxResult{}
+
+		session, err := q.GetSessionForUpdate(ctx, arg.SessionID)
+		if err != nil {
+			return err
+		}
+
+		if session.Username != arg.Username {
+			return ErrSessionRevoked
+		}
+		result.FamilyID = session.FamilyID
+
+		// The revocation has to be committed, so it is reported after the transaction
+		if session.ReplacedBy.Valid {
+			reused = true
+			return q.RevokeSessionFamily(ctx, session.FamilyID)
+		}
+
+		if session.IsRevoked {
+			return ErrSessionRevoked
+		}
+
+		user, err := q.GetUser(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		if util.IssuedBeforePasswordChange(arg.IssuedAt, user.PasswordChangedAt.Time) {
+			return ErrSessionRevoked
+		}
+
+		newSession, err := arg.NewSession(user)
+		if err != nil {
+			return err
+		}
+		newSession.FamilyID = session.FamilyID
+
+		result.Session, err = q.CreateSession(ctx, newSession)
+		if err != nil {
+			return err
+		}
+		result.User = user
+
+		_, err = q.MarkSessionRotated(ctx, MarkSessionRotatedParams{
+			ID:         session.ID,
+			ReplacedBy: pgtype.Text{String: result.Session.ID, Valid: true},
+		})
+		return err
+	})
+	if err == nil && reused {
+		return result, ErrRefreshTokenReused
+	}
+	return result, err
+}
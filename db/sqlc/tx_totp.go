@@ -0,0 +1,62 @@
+package db
+
+import "context"
+
+type EnableTOTPTxParams struct {
+	Username           string   `json:"username"`
+	RecoveryCodeHashes []string `json:"recovery_code_hashes"`
+}
+
+type EnableTOTPTxResult struct {
+	User User `json:"user"`
+}
+
+// EnableTOTPTx turns on two-factor authentication for a user who has confirmed the TOTP secret.
+// Any previous recovery codes are replaced by the given ones.
+func (store txStore) EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (EnableTOTPTxResult, error) {
+	var result EnableTOTPTxResult
+
+	err := store.execTx(ctx, enableTOTPTxOptions, func(q Querier) error {
+		var err error
+
+		result.User, err = q.EnableUserTOTP(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		for _, codeHash := range arg.RecoveryCodeHashes {
+			_, err = q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				Username: arg.Username,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	return result, err
+}
+
+// DisableTOTPTx turns off two-factor authentication and removes the secret and recovery codes
+func (store txStore) DisableTOTPTx(ctx context.Context, username string) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, disableTOTPTxOptions, func(q Querier) error {
+		var err error
+
+		user, err = q.DisableUserTOTP(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteRecoveryCodes(ctx, username)
+	})
+	return user, err
+}
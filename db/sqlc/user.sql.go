@@ -17,7 +17,7 @@ INSERT INTO users (
 ) VALUES (
   $1, $2, $3, $4
 )
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, totp_enabled, role, failed_login_attempts, locked_until, totp_last_step
 `
 
 type CreateUserParams struct {
@@ -42,12 +42,74 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const disableUserTOTP = `-- name: DisableUserTOTP :one
+UPDATE users
+SET
+  totp_secret = NULL,
+  totp_enabled = false
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, totp_enabled, role, failed_login_attempts, locked_until, totp_last_step
+`
+
+func (q *Queries) DisableUserTOTP(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, disableUserTOTP, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :one
+UPDATE users
+SET totp_enabled = true
+WHERE username = $1 AND totp_secret IS NOT NULL
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, totp_enabled, role, failed_login_attempts, locked_until, totp_last_step
+`
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, enableUserTOTP, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at FROM users
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, totp_enabled, role, failed_login_attempts, locked_until, totp_last_step FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -61,6 +123,187 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, totp_enabled, role, failed_login_attempts, locked_until, totp_last_step FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUserForUpdate = `-- name: GetUserForUpdate :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, totp_enabled, role, failed_login_attempts, locked_until, totp_last_step FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetUserForUpdate(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserForUpdate, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const incrementUserFailedLogins = `-- name: IncrementUserFailedLogins :one
+UPDATE users
+SET failed_login_attempts = failed_login_attempts + 1
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, totp_enabled, role, failed_login_attempts, locked_until, totp_last_step
+`
+
+func (q *Queries) IncrementUserFailedLogins(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, incrementUserFailedLogins, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const lockUser = `-- name: LockUser :one
+UPDATE users
+SET locked_until = $1
+WHERE username = $2
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, totp_enabled, role, failed_login_attempts, locked_until, totp_last_step
+`
+
+type LockUserParams struct {
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	Username    string             `json:"username"`
+}
+
+func (q *Queries) LockUser(ctx context.Context, arg LockUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, lockUser, arg.LockedUntil, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const resetUserFailedLogins = `-- name: ResetUserFailedLogins :one
+UPDATE users
+SET
+  failed_login_attempts = 0,
+  locked_until = '0001-01-01 00:00:00Z'
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, totp_enabled, role, failed_login_attempts, locked_until, totp_last_step
+`
+
+func (q *Queries) ResetUserFailedLogins(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, resetUserFailedLogins, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :one
+UPDATE users
+SET
+  totp_secret = $1,
+  totp_enabled = false,
+  totp_last_step = 0
+WHERE
+  username = $2
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, totp_enabled, role, failed_login_attempts, locked_until, totp_last_step
+`
+
+type SetUserTOTPSecretParams struct {
+	TotpSecret pgtype.Text `json:"totp_secret"`
+	Username   string      `json:"username"`
+}
+
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserTOTPSecret, arg.TotpSecret, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
@@ -74,7 +317,7 @@ SET
   email = coalesce($4, email)
 WHERE 
   username = $5
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, totp_enabled, role, failed_login_attempts, locked_until, totp_last_step
 `
 
 type UpdateUserParams struct {
@@ -101,6 +344,76 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, totp_enabled, role, failed_login_attempts, locked_until, totp_last_step
+`
+
+type UpdateUserRoleParams struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserRole, arg.Username, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :one
+UPDATE users
+SET totp_last_step = $1
+WHERE username = $2 AND totp_last_step < $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, totp_enabled, role, failed_login_attempts, locked_until, totp_last_step
+`
+
+type UseTOTPStepParams struct {
+	Step     int64  `json:"step"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (User, error) {
+	row := q.db.QueryRow(ctx, useTOTPStep, arg.Step, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
@@ -172,3 +172,15 @@ func TestUpdateUserAllFields(t *testing.T) {
 	require.WithinDuration(t, oldUser.CreatedAt.Time, updatedUser.CreatedAt.Time, time.Second)
 	require.WithinDuration(t, oldUser.PasswordChangedAt.Time, updatedUser.PasswordChangedAt.Time, time.Second)
 }
+
+func TestUpdateUserRole(t *testing.T) {
+	user := createRandomUser(t)
+	require.Equal(t, util.DepositorRole, user.Role)
+
+	updatedUser, err := testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Username: user.Username,
+		Role:     util.AdminRole,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.AdminRole, updatedUser.Role)
+}
@@ -0,0 +1,124 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+// methodPolicy says who may call a gRPC method
+type methodPolicy struct {
+	// public methods are served without an access token
+	public bool
+	// roles restricts an authenticated method to users with one of the roles, empty allows any role
+	roles []string
+}
+
+// methodPolicies lists every method the server exposes. Methods missing from
+// the table are refused, so a new RPC is never exposed by accident.
+var methodPolicies = map[string]methodPolicy{
+	pb.SimpleBank_CreateUser_FullMethodName:           {public: true},
+	pb.SimpleBank_LoginUser_FullMethodName:            {public: true},
+	pb.SimpleBank_LoginUserMFA_FullMethodName:         {public: true},
+	pb.SimpleBank_RenewAccessToken_FullMethodName:     {public: true},
+	pb.SimpleBank_RequestPasswordReset_FullMethodName: {public: true},
+	pb.SimpleBank_ConfirmPasswordReset_FullMethodName: {public: true},
+
+	pb.SimpleBank_UpdateUser_FullMethodName:        {},
+	pb.SimpleBank_SetupTOTP_FullMethodName:         {},
+	pb.SimpleBank_EnableTOTP_FullMethodName:        {},
+	pb.SimpleBank_DisableTOTP_FullMethodName:       {},
+	pb.SimpleBank_ListSessions_FullMethodName:      {},
+	pb.SimpleBank_RevokeSession_FullMethodName:     {},
+	pb.SimpleBank_RevokeAllSessions_FullMethodName: {},
+	pb.SimpleBank_BatchTransfer_FullMethodName:     {},
+
+	pb.SimpleBank_UnlockUser_FullMethodName: {roles: []string{util.AdminRole}},
+
+	healthpb.Health_Check_FullMethodName: {public: true},
+	healthpb.Health_Watch_FullMethodName: {public: true},
+
+	reflectionv1.ServerReflection_ServerReflectionInfo_FullMethodName:      {public: true},
+	reflectionv1alpha.ServerReflection_ServerReflectionInfo_FullMethodName: {public: true},
+}
+
+// authorize enforces the policy of a method and returns the context handed to its handler
+func (server *Server) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	policy, ok := methodPolicies[fullMethod]
+	if !ok {
+		return nil, status.Errorf(codes.PermissionDenied, "method %s has no access policy", fullMethod)
+	}
+
+	if policy.public {
+		return ctx, nil
+	}
+
+	payload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	setLogUsername(ctx, payload.Username)
+
+	if len(policy.roles) > 0 && !hasRole(payload.Role, policy.roles) {
+		return nil, status.Errorf(codes.PermissionDenied, "permission denied")
+	}
+
+	return withAuthPayload(ctx, payload), nil
+}
+
+func hasRole(role string, roles []string) bool {
+	for _, allowed := range roles {
+		if role == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// UnaryAuthInterceptor authenticates unary calls according to methodPolicies
+func (server *Server) UnaryAuthInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	ctx, err := server.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// authServerStream carries the context with the auth payload to a streaming handler
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *authServerStream) Context() context.Context {
+	return stream.ctx
+}
+
+// StreamAuthInterceptor authenticates streaming calls according to methodPolicies
+func (server *Server) StreamAuthInterceptor(
+	srv any,
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := server.authorize(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &authServerStream{ServerStream: stream, ctx: ctx})
+}
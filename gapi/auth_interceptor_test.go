@@ -0,0 +1,98 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestEveryMethodHasPolicy(t *testing.T) {
+	for _, method := range pb.SimpleBank_ServiceDesc.Methods {
+		fullMethod := fmt.Sprintf("/%s/%s", pb.SimpleBank_ServiceDesc.ServiceName, method.MethodName)
+		_, ok := methodPolicies[fullMethod]
+		require.True(t, ok, "%s has no access policy", fullMethod)
+	}
+}
+
+func TestAuthorizeUnknownMethod(t *testing.T) {
+	server := &Server{}
+
+	_, err := server.authorize(context.Background(), "/pb.SimpleBank/Unknown")
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestAuthorizeMissingToken(t *testing.T) {
+	server := &Server{}
+
+	_, err := server.authorize(context.Background(), pb.SimpleBank_ListSessions_FullMethodName)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx, err := server.authorize(context.Background(), pb.SimpleBank_LoginUser_FullMethodName)
+	require.NoError(t, err)
+
+	_, err = getAuthPayload(ctx)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAuthorizeSessionState(t *testing.T) {
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		code       codes.Code
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionFamilyState(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.GetSessionFamilyStateRow{SessionActive: true}, nil)
+			},
+			code: codes.OK,
+		},
+		{
+			name: "SessionRevoked",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionFamilyState(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.GetSessionFamilyStateRow{SessionActive: false}, nil)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "StoreError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionFamilyState(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.GetSessionFamilyStateRow{}, errors.New("connection refused"))
+			},
+			code: codes.Internal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			accessToken, _, err := server.tokenMaker.CreateToken(util.RandomOwner(), util.DepositorRole, util.RandomString(16), time.Minute, token.TokenTypeAccessToken)
+			require.NoError(t, err)
+
+			md := metadata.Pairs(authorizationHeader, fmt.Sprintf("%s %s", authorizationBearer, accessToken))
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+
+			_, err = server.authorize(ctx, pb.SimpleBank_ListSessions_FullMethodName)
+			require.Equal(t, tc.code, status.Code(err))
+		})
+	}
+}
@@ -2,11 +2,14 @@ package gapi
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"strings"
 
+	"github.com/Aadityaa2606/Bank-API/revocation"
 	"github.com/Aadityaa2606/Bank-API/token"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -14,26 +17,61 @@ const (
 	authorizationBearer = "bearer"
 )
 
+type authPayloadKey struct{}
+
+// authorizeUser verifies the access token of the call. It returns Unauthenticated
+// for a missing, invalid or revoked token, and Internal when the session state
+// cannot be loaded, so clients do not drop their credentials over a server fault.
 func (server *Server) authorizeUser(ctx context.Context) (*token.Payload, error) {
 
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return nil, fmt.Errorf("metadata not found")
+		return nil, unauthenticatedError("metadata not found")
 	}
 	values := md.Get(authorizationHeader)
 	if len(values) == 0 {
-		return nil, fmt.Errorf("authorization token not found")
+		return nil, unauthenticatedError("authorization token not found")
 	}
 	authHeader := values[0]
 	fields := strings.Fields(authHeader)
 	if len(fields) != 2 || strings.ToLower(fields[0]) != authorizationBearer {
-		return nil, fmt.Errorf("invalid authorization header")
+		return nil, unauthenticatedError("invalid authorization header")
 	}
 
 	accessToken := fields[1]
-	payload, err := server.tokenMaker.VerifyToken(accessToken)
+	payload, err := server.tokenMaker.VerifyToken(accessToken, token.TokenTypeAccessToken)
+	if err != nil {
+		return nil, unauthenticatedError("invalid access token")
+	}
+
+	// The token is valid on its own, but its session may have been revoked since
+	err = server.revocationChecker.Check(ctx, payload)
 	if err != nil {
-		return nil, fmt.Errorf("invalid access token")
+		if errors.Is(err, revocation.ErrNoSession) ||
+			errors.Is(err, revocation.ErrSessionRevoked) ||
+			errors.Is(err, revocation.ErrPasswordChanged) {
+			return nil, unauthenticatedError(err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "cannot check session: %s", err)
+	}
+	return payload, nil
+}
+
+func unauthenticatedError(reason string) error {
+	return status.Errorf(codes.Unauthenticated, "unauthorized: %s", reason)
+}
+
+// withAuthPayload stores the verified access token payload for the handlers
+func withAuthPayload(ctx context.Context, payload *token.Payload) context.Context {
+	return context.WithValue(ctx, authPayloadKey{}, payload)
+}
+
+// getAuthPayload returns the payload the auth interceptor verified.
+// It fails for methods the policy table lists as public.
+func getAuthPayload(ctx context.Context) (*token.Payload, error) {
+	payload, ok := ctx.Value(authPayloadKey{}).(*token.Payload)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "unauthorized: no authenticated user")
 	}
 	return payload, nil
 }
@@ -0,0 +1,67 @@
+package gapi
+
+import (
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func convertUser(user db.User) *pb.User {
+	return &pb.User{
+		Username:          user.Username,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt.Time),
+		CreatedAt:         timestamppb.New(user.CreatedAt.Time),
+	}
+}
+
+func convertSession(session db.Session) *pb.Session {
+	return &pb.Session{
+		Id:        session.ID,
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		CreatedAt: timestamppb.New(session.CreatedAt.Time),
+		ExpiresAt: timestamppb.New(session.ExpiresAt.Time),
+	}
+}
+
+func convertMoney(money util.Money) (*pb.Money, error) {
+	value, err := money.Decimal()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Money{Value: value, Currency: money.Currency}, nil
+}
+
+func convertAccount(account db.Account) (*pb.Account, error) {
+	balance, err := convertMoney(util.NewMoney(account.Balance, account.Currency))
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.Account{
+		Id:        account.ID,
+		Owner:     account.Owner,
+		Currency:  account.Currency,
+		Balance:   balance,
+		CreatedAt: timestamppb.New(account.CreatedAt.Time),
+		Product:   account.Product,
+	}, nil
+}
+
+func convertTransfer(transfer db.Transfer, currency string) (*pb.Transfer, error) {
+	amount, err := convertMoney(util.NewMoney(transfer.Amount, currency))
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.Transfer{
+		Id:            transfer.ID,
+		FromAccountId: transfer.FromAccountID,
+		ToAccountId:   transfer.ToAccountID,
+		Amount:        amount,
+		CreatedAt:     timestamppb.New(transfer.CreatedAt.Time),
+	}, nil
+}
@@ -4,12 +4,41 @@ import (
 	"context"
 	"time"
 
+	"github.com/Aadityaa2606/Bank-API/util"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
+// logFields collects what the interceptors further down learn about a call,
+// such as the authenticated user, for the log line written once it is done
+type logFields struct {
+	username string
+}
+
+type logFieldsKey struct{}
+
+// setLogUsername records the authenticated user of the call for GrpcLogger
+func setLogUsername(ctx context.Context, username string) {
+	if fields, ok := ctx.Value(logFieldsKey{}).(*logFields); ok {
+		fields.username = username
+	}
+}
+
+// requestIDFromMetadata takes the request ID sent by the client or the gateway, or generates one
+func requestIDFromMetadata(ctx context.Context) string {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(util.RequestIDMetadataKey); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	return util.RequestIDOrNew(requestID)
+}
+
 func GrpcLogger(
 	ctx context.Context,
 	req any,
@@ -17,6 +46,14 @@ func GrpcLogger(
 	handler grpc.UnaryHandler,
 ) (resp any, err error) {
 
+	requestID := requestIDFromMetadata(ctx)
+	ctx = util.ContextWithRequestID(ctx, requestID)
+	// The gateway forwards this header back to the HTTP client
+	_ = grpc.SetHeader(ctx, metadata.Pairs(util.RequestIDMetadataKey, requestID))
+
+	fields := &logFields{}
+	ctx = context.WithValue(ctx, logFieldsKey{}, fields)
+
 	startTime := time.Now()
 	resp, err = handler(ctx, req)
 	duration := time.Since(startTime)
@@ -31,7 +68,17 @@ func GrpcLogger(
 		logger = log.Error().Err(err)
 	}
 
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		logger = logger.Str("trace_id", spanContext.TraceID().String())
+	}
+
+	if fields.username != "" {
+		logger = logger.Str("username", fields.username)
+	}
+
 	logger.
+		Str("protocol", "grpc").
+		Str("request_id", requestID).
 		Str("method", info.FullMethod).
 		Int("status_code", int(statusCode)).
 		Str("status_text", statusCode.String()).
@@ -0,0 +1,87 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Aadityaa2606/Bank-API/currency"
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/revocation"
+	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// testCurrencies stands in for the currencies table, so handler tests need no stubs for it
+type testCurrencies struct{}
+
+func (testCurrencies) ListCurrencies(ctx context.Context) ([]db.Currency, error) {
+	return []db.Currency{
+		{Code: "CAD", Exponent: 2, Enabled: false, DisplayName: "Canadian Dollar"},
+		{Code: util.EUR, Exponent: 2, Enabled: true, DisplayName: "Euro"},
+		{Code: util.INR, Exponent: 2, Enabled: true, DisplayName: "Indian Rupee"},
+		{Code: util.USD, Exponent: 2, Enabled: true, DisplayName: "US Dollar"},
+	}, nil
+}
+
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		MFAChallengeDuration: time.Minute,
+	}
+
+	server, err := NewServer(config, store, nil, revocation.NewChecker(store, time.Minute), currency.NewRegistry(testCurrencies{}, time.Minute))
+	require.NoError(t, err)
+
+	return server
+}
+
+// newAuthContext carries the payload the auth interceptor would have verified
+func newAuthContext(t *testing.T, username string) context.Context {
+	payload, err := token.NewPayload(username, util.DepositorRole, util.RandomString(16), time.Minute, token.TokenTypeAccessToken)
+	require.NoError(t, err)
+
+	return withAuthPayload(context.Background(), payload)
+}
+
+// cacheSessionState has the revocation checker cache the session family as active.
+// The returned func reports whether the cached state has been dropped since, by
+// checking again against a store that now finds the family revoked.
+func cacheSessionState(t *testing.T, checker *revocation.Checker, store *mockdb.MockStore, username string, familyID string) func() bool {
+	payload, err := token.NewPayload(username, util.DepositorRole, familyID, time.Minute, token.TokenTypeAccessToken)
+	require.NoError(t, err)
+
+	arg := db.GetSessionFamilyStateParams{FamilyID: familyID, Username: username}
+	gomock.InOrder(
+		store.EXPECT().GetSessionFamilyState(gomock.Any(), gomock.Eq(arg)).Times(1).
+			Return(db.GetSessionFamilyStateRow{SessionActive: true}, nil),
+		store.EXPECT().GetSessionFamilyState(gomock.Any(), gomock.Eq(arg)).MaxTimes(1).
+			Return(db.GetSessionFamilyStateRow{SessionActive: false}, nil),
+	)
+	require.NoError(t, checker.Check(context.Background(), payload))
+
+	return func() bool {
+		return errors.Is(checker.Check(context.Background(), payload), revocation.ErrSessionRevoked)
+	}
+}
+
+func randomUser(t *testing.T) (db.User, string) {
+	password := util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	user := db.User{
+		Username:       util.RandomOwner(),
+		Role:           util.DepositorRole,
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+	return user, password
+}
@@ -0,0 +1,122 @@
+package gapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"net"
+	"net/netip"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	// xForwardedForHeader is set by the gateway with the address of the HTTP client
+	xForwardedForHeader = "x-forwarded-for"
+	// grpcGatewayUserAgentHeader is set by the gateway with the user agent of the HTTP client
+	grpcGatewayUserAgentHeader = "grpcgateway-user-agent"
+	// userAgentHeader is sent by gRPC clients
+	userAgentHeader = "user-agent"
+	// gatewayTokenHeader carries gatewayToken on the calls of the gateway
+	gatewayTokenHeader = "x-gateway-token"
+)
+
+// gatewayToken tells the calls of the gateway of this process from those of other
+// clients, who may send any x-forwarded-for they like. It changes on every start.
+var gatewayToken = rand.Text()
+
+// gatewayCredentials adds gatewayToken to every call of the gateway
+type gatewayCredentials struct{}
+
+// GatewayCredentials is the dial option of the gateway, so the gRPC server
+// believes the client addresses it forwards
+func GatewayCredentials() grpc.DialOption {
+	return grpc.WithPerRPCCredentials(gatewayCredentials{})
+}
+
+func (gatewayCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{gatewayTokenHeader: gatewayToken}, nil
+}
+
+// RequireTransportSecurity is false, the gateway talks to the gRPC server of its own process
+func (gatewayCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+type Metadata struct {
+	UserAgent string
+	ClientIP  string
+}
+
+func (server *Server) extractMetadata(ctx context.Context) *Metadata {
+	mtdt := &Metadata{}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if userAgents := md.Get(grpcGatewayUserAgentHeader); len(userAgents) > 0 {
+			mtdt.UserAgent = userAgents[0]
+		} else if userAgents := md.Get(userAgentHeader); len(userAgents) > 0 {
+			mtdt.UserAgent = userAgents[0]
+		}
+
+		if fromGateway(md) {
+			mtdt.ClientIP = server.forwardedClientIP(md.Get(xForwardedForHeader))
+		}
+	}
+
+	if mtdt.ClientIP == "" {
+		if p, ok := peer.FromContext(ctx); ok {
+			mtdt.ClientIP = p.Addr.String()
+			if host, _, err := net.SplitHostPort(mtdt.ClientIP); err == nil {
+				mtdt.ClientIP = host
+			}
+		}
+	}
+
+	return mtdt
+}
+
+// fromGateway tells whether the gateway made the call. Clients of the gateway can add
+// tokens of their own through Grpc-Metadata headers, so any of them may be the right one.
+func fromGateway(md metadata.MD) bool {
+	for _, token := range md.Get(gatewayTokenHeader) {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(gatewayToken)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedClientIP reads the x-forwarded-for of the gateway, whose last entry is the
+// address the gateway got the request from. Entries are walked from the end as long as
+// they are trusted proxies, like Gin does, as only those are believed about the one before.
+func (server *Server) forwardedClientIP(forwardedFor []string) string {
+	var addresses []string
+	for _, value := range forwardedFor {
+		addresses = append(addresses, strings.Split(value, ",")...)
+	}
+
+	clientIP := ""
+	for i := len(addresses) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(addresses[i]))
+		if err != nil {
+			break
+		}
+		clientIP = addr.String()
+		if !server.isTrustedProxy(addr.Unmap()) {
+			break
+		}
+	}
+	return clientIP
+}
+
+func (server *Server) isTrustedProxy(addr netip.Addr) bool {
+	for _, proxy := range server.trustedProxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
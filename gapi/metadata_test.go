@@ -0,0 +1,79 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestExtractMetadataClientIP(t *testing.T) {
+	peerAddr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 50000}
+
+	testCases := []struct {
+		name           string
+		trustedProxies string
+		md             metadata.MD
+		clientIP       string
+	}{
+		{
+			name:     "DirectCaller",
+			md:       metadata.Pairs(userAgentHeader, "grpc-go"),
+			clientIP: "127.0.0.1",
+		},
+		{
+			name:     "DirectCallerForgingForwardedFor",
+			md:       metadata.Pairs(xForwardedForHeader, "203.0.113.7"),
+			clientIP: "127.0.0.1",
+		},
+		{
+			name:     "DirectCallerGuessingToken",
+			md:       metadata.Pairs(xForwardedForHeader, "203.0.113.7", gatewayTokenHeader, "guess"),
+			clientIP: "127.0.0.1",
+		},
+		{
+			name:     "Gateway",
+			md:       metadata.Pairs(xForwardedForHeader, "198.51.100.2", gatewayTokenHeader, gatewayToken),
+			clientIP: "198.51.100.2",
+		},
+		{
+			name:     "GatewayClientForgingForwardedFor",
+			md:       metadata.Pairs(xForwardedForHeader, "203.0.113.7, 198.51.100.2", gatewayTokenHeader, gatewayToken),
+			clientIP: "198.51.100.2",
+		},
+		{
+			name:           "GatewayBehindTrustedProxy",
+			trustedProxies: "10.0.0.0/8",
+			md:             metadata.Pairs(xForwardedForHeader, "203.0.113.7, 10.0.0.5", gatewayTokenHeader, gatewayToken),
+			clientIP:       "203.0.113.7",
+		},
+		{
+			name:     "GatewayClientAddingToken",
+			md:       metadata.Pairs(xForwardedForHeader, "198.51.100.2", gatewayTokenHeader, "guess", gatewayTokenHeader, gatewayToken),
+			clientIP: "198.51.100.2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			proxies, err := util.ParseTrustedProxies(tc.trustedProxies)
+			require.NoError(t, err)
+			server := &Server{trustedProxies: proxies}
+
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: peerAddr})
+			ctx = metadata.NewIncomingContext(ctx, tc.md)
+
+			require.Equal(t, tc.clientIP, server.extractMetadata(ctx).ClientIP)
+		})
+	}
+}
+
+func TestGatewayCredentials(t *testing.T) {
+	md, err := gatewayCredentials{}.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	require.True(t, fromGateway(metadata.New(md)))
+}
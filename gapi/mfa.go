@@ -0,0 +1,83 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/metrics"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recoveryCodeCount is the amount of recovery codes handed out when TOTP is enabled
+const recoveryCodeCount = 10
+
+// verifySecondFactor checks a TOTP code, or consumes a recovery code, of the user. Until
+// TOTP is enabled only codes of the secret being confirmed count, recovery codes come later.
+func (server *Server) verifySecondFactor(ctx context.Context, user db.User, code string) (bool, error) {
+	if !user.TotpSecret.Valid {
+		return false, nil
+	}
+
+	valid, err := server.useTOTPCode(ctx, user, code)
+	if err != nil || valid || !user.TotpEnabled {
+		return valid, err
+	}
+
+	_, err = server.store.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: util.HashSecret(util.NormalizeRecoveryCode(code)),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// useTOTPCode accepts a TOTP code once. Its time step must be newer than the last one
+// accepted for the user, and becomes the last one, so an observed code cannot be replayed.
+func (server *Server) useTOTPCode(ctx context.Context, user db.User, code string) (bool, error) {
+	step, valid := util.MatchTOTPCode(user.TotpSecret.String, code, time.Now())
+	if !valid || step <= user.TotpLastStep {
+		return false, nil
+	}
+
+	_, err := server.store.UseTOTPStep(ctx, db.UseTOTPStepParams{
+		Username: user.Username,
+		Step:     step,
+	})
+	if err != nil {
+		// Another request accepted a code of this step first
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// checkSecondFactor returns an error unless the code is valid. Locked users are refused and
+// wrong codes count towards the same lockout as wrong passwords, so a stolen session is not
+// enough to guess the code.
+func (server *Server) checkSecondFactor(ctx context.Context, user db.User, code string) error {
+	if time.Now().Before(user.LockedUntil.Time) {
+		metrics.LoginAttempt(metrics.LoginLocked)
+		return errTooManyLoginAttempts
+	}
+
+	valid, err := server.verifySecondFactor(ctx, user, code)
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot verify code: %v", err)
+	}
+	if !valid {
+		return server.rejectLogin(ctx, user.Username, status.Error(codes.Unauthenticated, "invalid two-factor authentication code"))
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Aadityaa2606/Bank-API/currency"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/metrics"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) BatchTransfer(ctx context.Context, req *pb.BatchTransferRequest) (*pb.BatchTransferResponse, error) {
+	authPayload, err := getAuthPayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	currencyErr := server.currencies.Validate(ctx, req.GetCurrency())
+	if currencyErr != nil && !errors.Is(currencyErr, currency.ErrUnknownCurrency) && !errors.Is(currencyErr, currency.ErrCurrencyDisabled) {
+		return nil, status.Errorf(codes.Internal, "cannot check currency: %v", currencyErr)
+	}
+
+	violations, legs := validateBatchTransferRequest(req, currencyErr)
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	arg := db.BatchTransferTxParams{
+		Owner:    authPayload.Username,
+		Currency: req.GetCurrency(),
+		Mode:     req.GetMode(),
+		Legs:     legs,
+	}
+	var total int64
+	for _, leg := range legs {
+		// Saturates instead of overflowing into a total below the threshold
+		total = min(total, math.MaxInt64-leg.Amount) + leg.Amount
+	}
+
+	if err := server.requireTransferStepUp(ctx, authPayload.Username, total, req.GetTotpCode()); err != nil {
+		return nil, err
+	}
+
+	result, err := server.store.BatchTransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientBalance) {
+			metrics.InsufficientBalance()
+		}
+		return nil, status.Errorf(batchTransferErrorCode(err), "cannot make batch transfer: %v", err)
+	}
+
+	rsp := &pb.BatchTransferResponse{
+		BatchId:   result.Batch.ID,
+		Mode:      result.Batch.Mode,
+		Succeeded: result.Batch.Succeeded,
+		Results:   make([]*pb.BatchTransferResult, len(result.Legs)),
+	}
+	// sources are the accounts the caller sent money from, which they may see
+	sources := make(map[int64]bool)
+	for i, leg := range result.Legs {
+		rsp.Results[i] = &pb.BatchTransferResult{Index: int32(i), Succeeded: leg.Err == nil}
+		if leg.Err != nil {
+			if errors.Is(leg.Err, db.ErrInsufficientBalance) {
+				metrics.InsufficientBalance()
+			}
+			rsp.Results[i].Error = leg.Err.Error()
+			continue
+		}
+		rsp.Results[i].Transfer, err = convertTransfer(leg.Transfer, req.GetCurrency())
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "cannot convert transfer: %v", err)
+		}
+		metrics.TransferCompleted(req.GetCurrency(), leg.Transfer.Amount)
+		sources[leg.Transfer.FromAccountID] = true
+	}
+	// Recipients' balances are none of the caller's business
+	for _, account := range result.Accounts {
+		if sources[account.ID] {
+			converted, err := convertAccount(account)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "cannot convert account: %v", err)
+			}
+			rsp.Accounts = append(rsp.Accounts, converted)
+		}
+	}
+
+	return rsp, nil
+}
+
+// requireTransferStepUp asks users with TOTP enabled for a second factor
+// before transfers at or above the configured threshold.
+func (server *Server) requireTransferStepUp(ctx context.Context, username string, amount int64, code string) error {
+	if server.config.TransferMFAThreshold <= 0 || amount < server.config.TransferMFAThreshold {
+		return nil
+	}
+
+	user, err := server.store.GetUser(ctx, username)
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot get user: %v", err)
+	}
+
+	if !user.TotpEnabled {
+		return nil
+	}
+
+	if code == "" {
+		return status.Errorf(codes.PermissionDenied, "two-factor authentication code is required for transfers of %d or more", server.config.TransferMFAThreshold)
+	}
+
+	return server.checkSecondFactor(ctx, user, code)
+}
+
+func batchTransferErrorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, db.ErrInsufficientBalance),
+		errors.Is(err, db.ErrMinimumBalance),
+		errors.Is(err, db.ErrWithdrawalLimit):
+		return codes.FailedPrecondition
+	case errors.Is(err, db.ErrTransferAccountMissing):
+		return codes.NotFound
+	case errors.Is(err, db.ErrAccountNotOwned),
+		errors.Is(err, db.ErrSpendLimit):
+		return codes.PermissionDenied
+	case errors.Is(err, db.ErrEmptyBatch),
+		errors.Is(err, db.ErrBatchTooLarge),
+		errors.Is(err, db.ErrUnknownBatchMode),
+		errors.Is(err, db.ErrInvalidTransferAmount),
+		errors.Is(err, db.ErrSameAccountTransfer),
+		errors.Is(err, db.ErrCurrencyMismatch):
+		return codes.InvalidArgument
+	}
+	return codes.Internal
+}
+
+// validateBatchTransferRequest also returns the legs with their amounts in minor units.
+// currencyErr tells why the currency registry refused the currency of the batch.
+func validateBatchTransferRequest(req *pb.BatchTransferRequest, currencyErr error) (violations []*errdetails.BadRequest_FieldViolation, legs []db.BatchTransferLeg) {
+	if currencyErr != nil {
+		violations = append(violations, fieldViolations("currency", currencyErr))
+	}
+
+	if req.GetMode() != db.BatchAllOrNothing && req.GetMode() != db.BatchBestEffort {
+		violations = append(violations, fieldViolations("mode", db.ErrUnknownBatchMode))
+	}
+
+	switch n := len(req.GetTransfers()); {
+	case n == 0:
+		violations = append(violations, fieldViolations("transfers", db.ErrEmptyBatch))
+	case n > db.MaxBatchTransferLegs:
+		violations = append(violations, fieldViolations("transfers", db.ErrBatchTooLarge))
+	}
+
+	legs = make([]db.BatchTransferLeg, len(req.GetTransfers()))
+	for i, leg := range req.GetTransfers() {
+		if leg.GetFromAccountId() <= 0 {
+			violations = append(violations, fieldViolations(fmt.Sprintf("transfers[%d].from_account_id", i), errors.New("must be a positive integer")))
+		}
+		if leg.GetToAccountId() <= 0 {
+			violations = append(violations, fieldViolations(fmt.Sprintf("transfers[%d].to_account_id", i), errors.New("must be a positive integer")))
+		}
+
+		legs[i] = db.BatchTransferLeg{FromAccountID: leg.GetFromAccountId(), ToAccountID: leg.GetToAccountId()}
+		// Without a known currency there is no exponent to read the amount with
+		if currencyErr != nil {
+			continue
+		}
+		amount, err := util.ParseMoney(leg.GetAmount(), req.GetCurrency())
+		if err == nil && amount.Amount <= 0 {
+			err = db.ErrInvalidTransferAmount
+		}
+		if err != nil {
+			violations = append(violations, fieldViolations(fmt.Sprintf("transfers[%d].amount", i), err))
+			continue
+		}
+		legs[i].Amount = amount.Amount
+	}
+
+	return violations, legs
+}
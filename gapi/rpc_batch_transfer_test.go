@@ -0,0 +1,160 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBatchTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	fromAccount := db.Account{ID: 1, Owner: user.Username, Currency: util.USD, Balance: 100}
+	toAccount := db.Account{ID: 2, Owner: util.RandomOwner(), Currency: util.USD}
+
+	req := &pb.BatchTransferRequest{
+		Currency: util.USD,
+		Mode:     db.BatchAllOrNothing,
+		Transfers: []*pb.BatchTransferLeg{
+			{FromAccountId: fromAccount.ID, ToAccountId: toAccount.ID, Amount: "0.10"},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.BatchTransferRequest
+		buildContext  func(t *testing.T) context.Context
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.BatchTransferResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  req,
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.BatchTransferTxParams{
+					Owner:    user.Username,
+					Currency: util.USD,
+					Mode:     db.BatchAllOrNothing,
+					Legs:     []db.BatchTransferLeg{{FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: 10}},
+				}
+				result := db.BatchTransferTxResult{
+					Batch:    db.TransferBatch{ID: 7, Mode: db.BatchAllOrNothing, Legs: 1, Succeeded: 1},
+					Legs:     []db.BatchLegResult{{Transfer: db.Transfer{ID: 3, FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: 10}}},
+					Accounts: []db.Account{fromAccount, toAccount},
+				}
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.BatchTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(7), rsp.GetBatchId())
+				require.Equal(t, int32(1), rsp.GetSucceeded())
+				require.Len(t, rsp.GetResults(), 1)
+				require.True(t, rsp.GetResults()[0].GetSucceeded())
+				require.Equal(t, int64(3), rsp.GetResults()[0].GetTransfer().GetId())
+				require.Equal(t, "0.10", rsp.GetResults()[0].GetTransfer().GetAmount().GetValue())
+				require.Len(t, rsp.GetAccounts(), 1)
+				require.Equal(t, fromAccount.ID, rsp.GetAccounts()[0].GetId())
+				require.Equal(t, "1.00", rsp.GetAccounts()[0].GetBalance().GetValue())
+			},
+		},
+		{
+			name: "InsufficientBalance",
+			req:  req,
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				err := &db.BatchLegError{Index: 0, Err: db.ErrInsufficientBalance}
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.BatchTransferTxResult{}, err)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.BatchTransferResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidArgument",
+			req: &pb.BatchTransferRequest{
+				Currency:  "XYZ",
+				Mode:      "some",
+				Transfers: []*pb.BatchTransferLeg{{FromAccountId: 0, ToAccountId: 2, Amount: "-1"}},
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.BatchTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "DisabledCurrency",
+			req: &pb.BatchTransferRequest{
+				Currency:  "CAD",
+				Mode:      db.BatchBestEffort,
+				Transfers: []*pb.BatchTransferLeg{{FromAccountId: fromAccount.ID, ToAccountId: toAccount.ID, Amount: "0.10"}},
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.BatchTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "ExcessPrecision",
+			req: &pb.BatchTransferRequest{
+				Currency:  util.USD,
+				Mode:      db.BatchBestEffort,
+				Transfers: []*pb.BatchTransferLeg{{FromAccountId: fromAccount.ID, ToAccountId: toAccount.ID, Amount: "0.101"}},
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.BatchTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "NoAuthPayload",
+			req:  req,
+			buildContext: func(t *testing.T) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.BatchTransferResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			rsp, err := server.BatchTransfer(tc.buildContext(t), tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ConfirmPasswordReset(ctx context.Context, req *pb.ConfirmPasswordResetRequest) (*pb.ConfirmPasswordResetResponse, error) {
+	violations := validateConfirmPasswordResetRequest(req)
+
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	hashedPassword, err := util.HashPassword(req.GetNewPassword())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to hash password: %s", err)
+	}
+
+	result, err := server.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenHash:      util.HashSecret(req.GetToken()),
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidResetToken) {
+			return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to reset password: %s", err)
+	}
+	server.revocationChecker.ForgetUser(result.User.Username)
+
+	rsp := &pb.ConfirmPasswordResetResponse{
+		User: convertUser(result.User),
+	}
+
+	return rsp, nil
+}
+
+func validateConfirmPasswordResetRequest(req *pb.ConfirmPasswordResetRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetToken() == "" {
+		violations = append(violations, fieldViolations("token", errors.New("token cannot be empty")))
+	}
+
+	if err := util.ValidatePassword(req.GetNewPassword()); err != nil {
+		violations = append(violations, fieldViolations("new_password", err))
+	}
+
+	return violations
+}
@@ -0,0 +1,146 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestConfirmPasswordResetAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	resetToken := util.RandomString(32)
+	newPassword := util.RandomString(8)
+
+	testCases := []struct {
+		name          string
+		req           *pb.ConfirmPasswordResetRequest
+		buildStubs    func(store *mockdb.MockStore)
+		forgotten     bool
+		checkResponse func(t *testing.T, rsp *pb.ConfirmPasswordResetResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.ConfirmPasswordResetRequest{Token: resetToken, NewPassword: newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Cond(func(arg db.ResetPasswordTxParams) bool {
+					return arg.TokenHash == util.HashSecret(resetToken) &&
+						util.CheckPassword(arg.HashedPassword, newPassword) == nil
+				})).Times(1).Return(db.ResetPasswordTxResult{User: user}, nil)
+			},
+			forgotten: true,
+			checkResponse: func(t *testing.T, rsp *pb.ConfirmPasswordResetResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, rsp.GetUser().GetUsername())
+			},
+		},
+		{
+			name: "InvalidToken",
+			req:  &pb.ConfirmPasswordResetRequest{Token: resetToken, NewPassword: newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ResetPasswordTxResult{}, db.ErrInvalidResetToken)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ConfirmPasswordResetResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.ConfirmPasswordResetRequest{Token: resetToken, NewPassword: newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ResetPasswordTxResult{}, pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ConfirmPasswordResetResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+		{
+			name: "EmptyToken",
+			req:  &pb.ConfirmPasswordResetRequest{NewPassword: newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ConfirmPasswordResetResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			forgotten := cacheSessionState(t, server.revocationChecker, store, user.Username, util.RandomString(16))
+
+			rsp, err := server.ConfirmPasswordReset(context.Background(), tc.req)
+			tc.checkResponse(t, rsp, err)
+			require.Equal(t, tc.forgotten, forgotten())
+		})
+	}
+}
+
+// TestConfirmPasswordResetTokens runs the real reset transaction, so expired, unknown
+// and used tokens are all refused and a successful reset ends the sessions of the user
+func TestConfirmPasswordResetTokens(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	server := newTestServer(t, store)
+
+	user, err := store.CreateUser(ctx, db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "hash",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	session, err := store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           util.RandomString(16),
+		FamilyID:     util.RandomString(16),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+
+	createToken := func(validFor time.Duration) string {
+		resetToken := util.RandomString(32)
+		_, err := store.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+			Username:  user.Username,
+			TokenHash: util.HashSecret(resetToken),
+			ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(validFor), Valid: true},
+		})
+		require.NoError(t, err)
+		return resetToken
+	}
+
+	confirm := func(resetToken string) codes.Code {
+		_, err := server.ConfirmPasswordReset(ctx, &pb.ConfirmPasswordResetRequest{Token: resetToken, NewPassword: util.RandomString(8)})
+		return status.Code(err)
+	}
+
+	require.Equal(t, codes.InvalidArgument, confirm(createToken(-time.Minute)))
+	require.Equal(t, codes.InvalidArgument, confirm(util.RandomString(32)))
+
+	resetToken := createToken(time.Minute)
+	require.Equal(t, codes.OK, confirm(resetToken))
+	require.Equal(t, codes.InvalidArgument, confirm(resetToken))
+
+	session, err = store.GetSession(ctx, session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsRevoked)
+}
@@ -0,0 +1,42 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) DisableTOTP(ctx context.Context, req *pb.DisableTOTPRequest) (*pb.DisableTOTPResponse, error) {
+	authPayload, err := getAuthPayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := validateTOTPCode(req.GetCode())
+
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot get user: %v", err)
+	}
+
+	if !user.TotpEnabled {
+		return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication is not enabled")
+	}
+
+	if err := server.checkSecondFactor(ctx, user, req.GetCode()); err != nil {
+		return nil, err
+	}
+
+	user, err = server.store.DisableTOTPTx(ctx, user.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot disable two-factor authentication: %v", err)
+	}
+
+	return &pb.DisableTOTPResponse{User: convertUser(user)}, nil
+}
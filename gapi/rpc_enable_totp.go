@@ -0,0 +1,71 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) EnableTOTP(ctx context.Context, req *pb.EnableTOTPRequest) (*pb.EnableTOTPResponse, error) {
+	authPayload, err := getAuthPayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := validateTOTPCode(req.GetCode())
+
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot get user: %v", err)
+	}
+
+	if user.TotpEnabled {
+		return nil, status.Errorf(codes.AlreadyExists, "two-factor authentication is already enabled")
+	}
+
+	if !user.TotpSecret.Valid {
+		return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication has not been set up")
+	}
+
+	if err := server.checkSecondFactor(ctx, user, req.GetCode()); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := util.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot generate recovery codes: %v", err)
+	}
+
+	recoveryCodeHashes := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		recoveryCodeHashes[i] = util.HashSecret(util.NormalizeRecoveryCode(code))
+	}
+
+	_, err = server.store.EnableTOTPTx(ctx, db.EnableTOTPTxParams{
+		Username:           user.Username,
+		RecoveryCodeHashes: recoveryCodeHashes,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot enable two-factor authentication: %v", err)
+	}
+
+	return &pb.EnableTOTPResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func validateTOTPCode(code string) (violations []*errdetails.BadRequest_FieldViolation) {
+	if code == "" {
+		violations = append(violations, fieldViolations("code", errors.New("code cannot be empty")))
+	}
+
+	return violations
+}
@@ -0,0 +1,30 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	authPayload, err := getAuthPayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := server.store.ListActiveSessions(ctx, authPayload.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot list sessions: %v", err)
+	}
+
+	rsp := &pb.ListSessionsResponse{
+		Sessions: make([]*pb.Session, len(sessions)),
+	}
+	for i, session := range sessions {
+		rsp.Sessions[i] = convertSession(session)
+	}
+
+	return rsp, nil
+}
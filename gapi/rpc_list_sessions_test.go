@@ -0,0 +1,81 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestListSessionsAPI(t *testing.T) {
+	username := util.RandomOwner()
+	sessions := []db.Session{randomSession(username), randomSession(username)}
+
+	testCases := []struct {
+		name          string
+		buildContext  func(t *testing.T) context.Context
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.ListSessionsResponse, err error)
+	}{
+		{
+			name: "OK",
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, username)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListActiveSessions(gomock.Any(), gomock.Eq(username)).Times(1).Return(sessions, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ListSessionsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, rsp.GetSessions(), len(sessions))
+				for i, session := range sessions {
+					require.True(t, proto.Equal(convertSession(session), rsp.GetSessions()[i]))
+				}
+			},
+		},
+		{
+			name: "NoAuthPayload",
+			buildContext: func(t *testing.T) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListActiveSessions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ListSessionsResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, username)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListActiveSessions(gomock.Any(), gomock.Eq(username)).Times(1).Return(nil, pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ListSessionsResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			rsp, err := server.ListSessions(tc.buildContext(t), &pb.ListSessionsRequest{})
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
@@ -2,9 +2,13 @@ package gapi
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/metrics"
 	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/token"
 	"github.com/Aadityaa2606/Bank-API/util"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -14,6 +18,11 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+var (
+	errInvalidCredentials   = status.Error(codes.Unauthenticated, "invalid username or password")
+	errTooManyLoginAttempts = status.Error(codes.ResourceExhausted, "too many failed login attempts, try again later")
+)
+
 func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
 	violations := validateLoginUserRequest(req)
 
@@ -21,48 +30,150 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 		return nil, invalidArgumentError(violations)
 	}
 
-	user, err := server.store.GetUser(ctx, req.Username)
+	user, err := server.checkCredentials(ctx, req.GetUsername(), req.GetPassword())
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, status.Errorf(codes.NotFound, "user not found")
+		return nil, err
+	}
+
+	// Users with two-factor authentication get a challenge instead of the tokens
+	if user.TotpEnabled {
+		challengeToken, challengePayload, err := server.tokenMaker.CreateToken(
+			user.Username,
+			user.Role,
+			"",
+			server.config.MFAChallengeDuration,
+			token.TokenTypeMFAChallenge,
+		)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "cannot create challenge token: %v", err)
 		}
-		return nil, status.Errorf(codes.Internal, "cannot get user: %v", err)
+
+		return &pb.LoginUserResponse{
+			MfaRequired:           true,
+			MfaChallengeToken:     challengeToken,
+			MfaChallengeExpiresAt: timestamppb.New(challengePayload.ExpiresAt.Time),
+		}, nil
 	}
 
-	err = util.CheckPassword(user.HashedPassword, req.Password)
+	return server.createLoginSession(ctx, user)
+}
+
+// checkCredentials verifies a username and password while enforcing the failed login limits
+// per client IP and per user. Every kind of bad credentials gets the same error so it
+// cannot be used to find out which usernames exist.
+func (server *Server) checkCredentials(ctx context.Context, username string, password string) (db.User, error) {
+	clientIP := server.extractMetadata(ctx).ClientIP
+	since := pgtype.Timestamptz{Time: time.Now().Add(-util.FailedLoginWindow), Valid: true}
+
+	ipFailures, err := server.store.CountRecentFailedLoginsByIP(ctx, db.CountRecentFailedLoginsByIPParams{
+		ClientIp: clientIP,
+		Since:    since,
+	})
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "invalid password")
+		return db.User{}, status.Errorf(codes.Internal, "cannot count failed logins: %v", err)
 	}
 
-	// Create the access token
-	accessToken, err := server.tokenMaker.CreateToken(user.Username, server.accessTokenDuration)
+	if ipFailures >= util.MaxFailedLoginsPerIP {
+		metrics.LoginAttempt(metrics.LoginLocked)
+		return db.User{}, errTooManyLoginAttempts
+	}
+
+	user, err := server.store.GetUser(ctx, username)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "cannot create access token: %v", err)
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return db.User{}, status.Errorf(codes.Internal, "cannot get user: %v", err)
+		}
+
+		// Unknown usernames take as long and get locked on the same schedule as real ones,
+		// going by their failed logins and when the last one happened
+		util.SimulatePasswordCheck(password)
+
+		failures, err := server.store.GetFailedLoginsByUsername(ctx, username)
+		if err != nil {
+			return db.User{}, status.Errorf(codes.Internal, "cannot get failed logins: %v", err)
+		}
+
+		if time.Now().Before(util.LockedUntil(failures.FailedAttempts, failures.LastFailedAt.Time)) {
+			metrics.LoginAttempt(metrics.LoginLocked)
+			return db.User{}, errTooManyLoginAttempts
+		}
+
+		return db.User{}, server.rejectLogin(ctx, username, errInvalidCredentials)
 	}
 
-	// Create the refresh token
-	refreshToken, err := server.tokenMaker.CreateToken(user.Username, server.refreshTokenDuration)
+	if time.Now().Before(user.LockedUntil.Time) {
+		metrics.LoginAttempt(metrics.LoginLocked)
+		return db.User{}, errTooManyLoginAttempts
+	}
+
+	err = util.CheckPassword(user.HashedPassword, password)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "cannot create refresh token: %v", err)
+		return db.User{}, server.rejectLogin(ctx, username, errInvalidCredentials)
 	}
 
-	// Get the payload from the access token
-	accessPayload, err := server.tokenMaker.VerifyToken(accessToken)
+	return user, nil
+}
+
+// rejectLogin records a failed login, which may lock the account, and returns the given error
+func (server *Server) rejectLogin(ctx context.Context, username string, rejection error) error {
+	metrics.LoginAttempt(metrics.LoginFailed)
+
+	err := server.store.RecordFailedLoginTx(ctx, db.RecordFailedLoginTxParams{
+		Username:        username,
+		ClientIP:        server.extractMetadata(ctx).ClientIP,
+		LockoutDuration: util.LockoutDuration,
+	})
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "cannot verify access token: %v", err)
+		return status.Errorf(codes.Internal, "cannot record failed login: %v", err)
 	}
 
-	// Get the payload from the refresh token
-	refreshPayload, err := server.tokenMaker.VerifyToken(refreshToken)
+	return rejection
+}
+
+// createLoginSession issues the access and refresh tokens of a fully authenticated user
+// and records the refresh token in a new session.
+func (server *Server) createLoginSession(ctx context.Context, user db.User) (*pb.LoginUserResponse, error) {
+	// A successful login starts the consecutive failure count over
+	if user.FailedLoginAttempts > 0 {
+		_, err := server.store.ResetUserFailedLogins(ctx, user.Username)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "cannot reset failed logins: %v", err)
+		}
+	}
+
+	// Create the refresh token
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		"",
+		server.config.RefreshTokenDuration,
+		token.TokenTypeRefreshToken,
+	)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "cannot verify refresh token: %v", err)
+		return nil, status.Errorf(codes.Internal, "cannot create refresh token: %v", err)
+	}
+
+	// Create the access token, linked to the session of the refresh token
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		refreshPayload.RegisteredClaims.ID,
+		server.config.AccessTokenDuration,
+		token.TokenTypeAccessToken,
+	)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot create access token: %v", err)
 	}
 
-	// Create a new session in the database
+	// Create a new session in the database, remembering the device it belongs to
+	mtdt := server.extractMetadata(ctx)
 	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
 		ID:           refreshPayload.RegisteredClaims.ID,
+		FamilyID:     refreshPayload.RegisteredClaims.ID,
 		Username:     refreshPayload.Username,
 		RefreshToken: refreshToken,
+		UserAgent:    mtdt.UserAgent,
+		ClientIp:     mtdt.ClientIP,
 		IsRevoked:    false,
 		ExpiresAt:    pgtype.Timestamptz{Time: refreshPayload.RegisteredClaims.ExpiresAt.Time, Valid: true},
 	})
@@ -70,19 +181,14 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 		return nil, status.Errorf(codes.Internal, "cannot create session: %v", err)
 	}
 
+	metrics.LoginAttempt(metrics.LoginSucceeded)
 	return &pb.LoginUserResponse{
 		AccessToken:           accessToken,
 		RefreshToken:          refreshToken,
 		SessionId:             session.ID,
 		AccessTokenExpiresAt:  timestamppb.New(accessPayload.RegisteredClaims.ExpiresAt.Time),
 		RefreshTokenExpiresAt: timestamppb.New(refreshPayload.RegisteredClaims.ExpiresAt.Time),
-		User: &pb.User{
-			Username:          user.Username,
-			FullName:          user.FullName,
-			Email:             user.Email,
-			PasswordChangedAt: timestamppb.New(user.PasswordChangedAt.Time),
-			CreatedAt:         timestamppb.New(user.CreatedAt.Time),
-		},
+		User:                  convertUser(user),
 	}, nil
 }
 
@@ -0,0 +1,48 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/token"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) LoginUserMFA(ctx context.Context, req *pb.LoginUserMFARequest) (*pb.LoginUserResponse, error) {
+	violations := validateLoginUserMFARequest(req)
+
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	challengePayload, err := server.tokenMaker.VerifyToken(req.GetChallengeToken(), token.TokenTypeMFAChallenge)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid challenge token: %s", err)
+	}
+
+	user, err := server.store.GetUser(ctx, challengePayload.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot get user: %v", err)
+	}
+
+	if err := server.checkSecondFactor(ctx, user, req.GetCode()); err != nil {
+		return nil, err
+	}
+
+	return server.createLoginSession(ctx, user)
+}
+
+func validateLoginUserMFARequest(req *pb.LoginUserMFARequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetChallengeToken() == "" {
+		violations = append(violations, fieldViolations("challenge_token", errors.New("challenge token cannot be empty")))
+	}
+
+	if req.GetCode() == "" {
+		violations = append(violations, fieldViolations("code", errors.New("code cannot be empty")))
+	}
+
+	return violations
+}
@@ -0,0 +1,80 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoginUserLockoutUnknownUser(t *testing.T) {
+	testCases := []struct {
+		name     string
+		failures int32
+		elapsed  time.Duration
+		code     codes.Code
+	}{
+		{name: "BelowLimit", failures: util.MaxFailedLoginsPerUser - 1, code: codes.Unauthenticated},
+		{name: "Locked", failures: util.MaxFailedLoginsPerUser, elapsed: 30 * time.Second, code: codes.ResourceExhausted},
+		{name: "LockExpired", failures: util.MaxFailedLoginsPerUser, elapsed: 90 * time.Second, code: codes.Unauthenticated},
+		{name: "LockDoubled", failures: util.MaxFailedLoginsPerUser + 1, elapsed: 90 * time.Second, code: codes.ResourceExhausted},
+		{name: "DoubledLockExpired", failures: util.MaxFailedLoginsPerUser + 1, elapsed: 3 * time.Minute, code: codes.Unauthenticated},
+	}
+
+	login := func(t *testing.T, username string, buildStubs func(store *mockdb.MockStore)) codes.Code {
+		store := mockdb.NewMockStore(gomock.NewController(t))
+		store.EXPECT().CountRecentFailedLoginsByIP(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+		buildStubs(store)
+
+		server := newTestServer(t, store)
+		_, err := server.LoginUser(context.Background(), &pb.LoginUserRequest{Username: username, Password: "wrong-password"})
+		return status.Code(err)
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lastFailure := time.Now().Add(-tc.elapsed)
+			recorded := 0
+			if tc.code == codes.Unauthenticated {
+				recorded = 1
+			}
+
+			// A real user locked by RecordFailedLoginTx at its last failure
+			user, _ := randomUser(t)
+			user.FailedLoginAttempts = tc.failures
+			if lockout := util.LockoutDuration(tc.failures); lockout > 0 {
+				user.LockedUntil = pgtype.Timestamptz{Time: lastFailure.Add(lockout), Valid: true}
+			}
+
+			realCode := login(t, user.Username, func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().RecordFailedLoginTx(gomock.Any(), gomock.Any()).Times(recorded).Return(nil)
+			})
+
+			// An unknown username with the same failures
+			username := util.RandomOwner()
+			unknownCode := login(t, username, func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(username)).Times(1).Return(db.User{}, pgx.ErrNoRows)
+				store.EXPECT().GetFailedLoginsByUsername(gomock.Any(), gomock.Eq(username)).Times(1).
+					Return(db.GetFailedLoginsByUsernameRow{
+						FailedAttempts: tc.failures,
+						LastFailedAt:   pgtype.Timestamptz{Time: lastFailure, Valid: true},
+					}, nil)
+				store.EXPECT().RecordFailedLoginTx(gomock.Any(), gomock.Any()).Times(recorded).Return(nil)
+			})
+
+			require.Equal(t, tc.code, realCode)
+			require.Equal(t, realCode, unknownCode)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) RenewAccessToken(ctx context.Context, req *pb.RenewAccessTokenRequest) (*pb.RenewAccessTokenResponse, error) {
+	violations := validateRenewAccessTokenRequest(req)
+
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(req.GetRefreshToken(), token.TokenTypeRefreshToken)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid refresh token: %s", err)
+	}
+
+	// Rotate the session, this fails if the refresh token was revoked or already used.
+	// The new tokens carry the role the user has now, not the one of the old token.
+	mtdt := server.extractMetadata(ctx)
+	var refreshToken string
+	var refreshPayload *token.Payload
+	result, err := server.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		SessionID: payload.ID.String(),
+		Username:  payload.Username,
+		IssuedAt:  payload.IssuedAt.Time,
+		NewSession: func(user db.User) (db.CreateSessionParams, error) {
+			var err error
+			refreshToken, refreshPayload, err = server.tokenMaker.CreateToken(
+				user.Username,
+				user.Role,
+				"",
+				server.config.RefreshTokenDuration,
+				token.TokenTypeRefreshToken,
+			)
+			if err != nil {
+				return db.CreateSessionParams{}, fmt.Errorf("cannot create refresh token: %w", err)
+			}
+
+			return db.CreateSessionParams{
+				ID:           refreshPayload.RegisteredClaims.ID,
+				Username:     refreshPayload.Username,
+				RefreshToken: refreshToken,
+				UserAgent:    mtdt.UserAgent,
+				ClientIp:     mtdt.ClientIP,
+				IsRevoked:    false,
+				ExpiresAt:    pgtype.Timestamptz{Time: refreshPayload.RegisteredClaims.ExpiresAt.Time, Valid: true},
+			}, nil
+		},
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRefreshTokenReused) {
+			// The access tokens of the stolen family must stop working right away
+			server.revocationChecker.ForgetSession(result.FamilyID)
+			return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+		}
+		if errors.Is(err, db.ErrSessionRevoked) {
+			return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Errorf(codes.Unauthenticated, "session not found")
+		}
+		return nil, status.Errorf(codes.Internal, "cannot rotate session: %v", err)
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
+		result.User.Username,
+		result.User.Role,
+		result.Session.FamilyID,
+		server.config.AccessTokenDuration,
+		token.TokenTypeAccessToken,
+	)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot create access token: %v", err)
+	}
+
+	return &pb.RenewAccessTokenResponse{
+		SessionId:             result.Session.ID,
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpiresAt.Time),
+		RefreshTokenExpiresAt: timestamppb.New(refreshPayload.ExpiresAt.Time),
+	}, nil
+}
+
+func validateRenewAccessTokenRequest(req *pb.RenewAccessTokenRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetRefreshToken() == "" {
+		violations = append(violations, fieldViolations("refresh_token", errors.New("refresh token cannot be empty")))
+	}
+
+	return violations
+}
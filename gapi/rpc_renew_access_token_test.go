@@ -0,0 +1,57 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRenewAccessTokenReuseRevokesAccessTokens(t *testing.T) {
+	user, _ := randomUser(t)
+	familyID := util.RandomString(16)
+
+	store := mockdb.NewMockStore(gomock.NewController(t))
+	server := newTestServer(t, store)
+
+	accessToken, _, err := server.tokenMaker.CreateToken(user.Username, user.Role, familyID, time.Minute, token.TokenTypeAccessToken)
+	require.NoError(t, err)
+	refreshToken, _, err := server.tokenMaker.CreateToken(user.Username, user.Role, "", time.Hour, token.TokenTypeRefreshToken)
+	require.NoError(t, err)
+
+	// The family is active until the reused refresh token revokes it
+	stateArg := db.GetSessionFamilyStateParams{FamilyID: familyID, Username: user.Username}
+	gomock.InOrder(
+		store.EXPECT().GetSessionFamilyState(gomock.Any(), gomock.Eq(stateArg)).Times(1).
+			Return(db.GetSessionFamilyStateRow{SessionActive: true}, nil),
+		store.EXPECT().GetSessionFamilyState(gomock.Any(), gomock.Eq(stateArg)).Times(1).
+			Return(db.GetSessionFamilyStateRow{SessionActive: false}, nil),
+	)
+	store.EXPECT().RotateSessionTx(gomock.Any(), gomock.Any()).Times(1).
+		Return(db.RotateSessionTxResult{FamilyID: familyID}, db.ErrRefreshTokenReused)
+
+	md := metadata.Pairs(authorizationHeader, fmt.Sprintf("%s %s", authorizationBearer, accessToken))
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	// The first call caches the family as active
+	_, err = server.authorize(ctx, pb.SimpleBank_ListSessions_FullMethodName)
+	require.NoError(t, err)
+
+	_, err = server.RenewAccessToken(context.Background(), &pb.RenewAccessTokenRequest{RefreshToken: refreshToken})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// The cached state is gone, so the access token is rejected before the cache expires
+	_, err = server.authorize(ctx, pb.SimpleBank_ListSessions_FullMethodName)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,88 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/mail"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// passwordResetTokenBytes is the amount of random bytes in a password reset token
+const passwordResetTokenBytes = 32
+
+func (server *Server) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error) {
+	violations := validateRequestPasswordResetRequest(req)
+
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	// The same response is returned whether or not the email is registered
+	rsp := &pb.RequestPasswordResetResponse{
+		Message: "if the email is registered, a password reset link has been sent",
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, req.GetEmail())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return rsp, nil
+		}
+		return nil, status.Errorf(codes.Internal, "cannot get user: %v", err)
+	}
+
+	// The token and the email are made in the background, so registered emails are not
+	// answered any slower. A full queue is only logged for the same reason.
+	if !server.mailer.Enqueue(server.passwordResetTask(user)) {
+		log.Error().Str("username", user.Username).Msg("email queue is full, password reset email dropped")
+	}
+
+	return rsp, nil
+}
+
+// passwordResetTask creates a reset token for the user and emails its link
+func (server *Server) passwordResetTask(user db.User) mail.Task {
+	return func(ctx context.Context, sender mail.EmailSender) error {
+		resetToken, err := util.RandomSecret(passwordResetTokenBytes)
+		if err != nil {
+			return fmt.Errorf("cannot create reset token: %w", err)
+		}
+
+		_, err = server.store.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+			Username:  user.Username,
+			TokenHash: util.HashSecret(resetToken),
+			ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(server.config.PasswordResetTokenDuration), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("cannot save reset token of %s: %w", user.Username, err)
+		}
+
+		resetLink := server.config.PasswordResetURL + "?token=" + url.QueryEscape(resetToken)
+		subject, content := mail.PasswordResetEmail(user.FullName, resetLink, server.config.PasswordResetTokenDuration)
+
+		err = sender.SendEmail(ctx, subject, content, []string{user.Email})
+		if err != nil {
+			return fmt.Errorf("cannot send password reset email to %s: %w", user.Username, err)
+		}
+		return nil
+	}
+}
+
+func validateRequestPasswordResetRequest(req *pb.RequestPasswordResetRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := util.ValidateEmail(req.GetEmail()); err != nil {
+		violations = append(violations, fieldViolations("email", err))
+	}
+
+	return violations
+}
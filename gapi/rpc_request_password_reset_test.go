@@ -0,0 +1,138 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/mail"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type recordingSender struct {
+	to chan []string
+}
+
+func (sender recordingSender) SendEmail(ctx context.Context, subject string, content string, to []string) error {
+	sender.to <- to
+	return nil
+}
+
+// drainMailer performs the queued email tasks and returns the recipients of the emails they sent
+func drainMailer(t *testing.T, queue *mail.Queue, sender recordingSender) [][]string {
+	go queue.Run()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, queue.Shutdown(ctx))
+
+	recipients := [][]string{}
+	for len(sender.to) > 0 {
+		recipients = append(recipients, <-sender.to)
+	}
+	return recipients
+}
+
+func TestRequestPasswordResetAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	message := "if the email is registered, a password reset link has been sent"
+
+	testCases := []struct {
+		name          string
+		req           *pb.RequestPasswordResetRequest
+		fullQueue     bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.RequestPasswordResetResponse, err error, recipients [][]string)
+	}{
+		{
+			name: "KnownEmail",
+			req:  &pb.RequestPasswordResetRequest{Email: user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Cond(func(arg db.CreatePasswordResetTokenParams) bool {
+					return arg.Username == user.Username && arg.TokenHash != ""
+				})).Times(1).Return(db.PasswordResetToken{}, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RequestPasswordResetResponse, err error, recipients [][]string) {
+				require.NoError(t, err)
+				require.Equal(t, message, rsp.GetMessage())
+				require.Equal(t, [][]string{{user.Email}}, recipients)
+			},
+		},
+		{
+			name: "UnknownEmail",
+			req:  &pb.RequestPasswordResetRequest{Email: user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(db.User{}, pgx.ErrNoRows)
+				store.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RequestPasswordResetResponse, err error, recipients [][]string) {
+				require.NoError(t, err)
+				require.Equal(t, message, rsp.GetMessage())
+				require.Empty(t, recipients)
+			},
+		},
+		{
+			name:      "FullQueue",
+			req:       &pb.RequestPasswordResetRequest{Email: user.Email},
+			fullQueue: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RequestPasswordResetResponse, err error, recipients [][]string) {
+				require.NoError(t, err)
+				require.Equal(t, message, rsp.GetMessage())
+				require.Empty(t, recipients)
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.RequestPasswordResetRequest{Email: user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(db.User{}, pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RequestPasswordResetResponse, err error, recipients [][]string) {
+				require.Equal(t, codes.Internal, status.Code(err))
+				require.Empty(t, recipients)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			req:  &pb.RequestPasswordResetRequest{Email: "not-an-email"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RequestPasswordResetResponse, err error, recipients [][]string) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+				require.Empty(t, recipients)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			sender := recordingSender{to: make(chan []string, 2)}
+			queue := mail.NewQueue(sender, 1)
+			if tc.fullQueue {
+				require.True(t, queue.Enqueue(func(ctx context.Context, sender mail.EmailSender) error { return nil }))
+			}
+
+			server := newTestServer(t, store)
+			server.mailer = queue
+
+			rsp, err := server.RequestPasswordReset(context.Background(), tc.req)
+			tc.checkResponse(t, rsp, err, drainMailer(t, queue, sender))
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.RevokeAllSessionsResponse, error) {
+	authPayload, err := getAuthPayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.store.RevokeUserSessions(ctx, authPayload.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot revoke sessions: %v", err)
+	}
+	server.revocationChecker.ForgetUser(authPayload.Username)
+
+	return &pb.RevokeAllSessionsResponse{Message: "all sessions revoked"}, nil
+}
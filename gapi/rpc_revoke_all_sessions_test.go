@@ -0,0 +1,81 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRevokeAllSessionsAPI(t *testing.T) {
+	username := util.RandomOwner()
+	session := randomSession(username)
+
+	testCases := []struct {
+		name          string
+		buildContext  func(t *testing.T) context.Context
+		buildStubs    func(store *mockdb.MockStore)
+		forgotten     bool
+		checkResponse func(t *testing.T, rsp *pb.RevokeAllSessionsResponse, err error)
+	}{
+		{
+			name: "OK",
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, username)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserSessions(gomock.Any(), gomock.Eq(username)).Times(1).Return(nil)
+			},
+			forgotten: true,
+			checkResponse: func(t *testing.T, rsp *pb.RevokeAllSessionsResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "all sessions revoked", rsp.GetMessage())
+			},
+		},
+		{
+			name: "NoAuthPayload",
+			buildContext: func(t *testing.T) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserSessions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RevokeAllSessionsResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, username)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserSessions(gomock.Any(), gomock.Eq(username)).Times(1).Return(pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RevokeAllSessionsResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			forgotten := cacheSessionState(t, server.revocationChecker, store, username, session.FamilyID)
+
+			rsp, err := server.RevokeAllSessions(tc.buildContext(t), &pb.RevokeAllSessionsRequest{})
+			tc.checkResponse(t, rsp, err)
+			require.Equal(t, tc.forgotten, forgotten())
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	authPayload, err := getAuthPayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := validateRevokeSessionRequest(req)
+
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	session, err := server.store.GetSession(ctx, req.GetSessionId())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "session not found")
+		}
+		return nil, status.Errorf(codes.Internal, "cannot get session: %v", err)
+	}
+
+	if session.Username != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "session doesn't belong to the authenticated user")
+	}
+
+	// Revoke the session along with the refresh tokens it was rotated from
+	err = server.store.RevokeSessionFamily(ctx, session.FamilyID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot revoke session: %v", err)
+	}
+	server.revocationChecker.ForgetSession(session.FamilyID)
+
+	return &pb.RevokeSessionResponse{Message: "session revoked"}, nil
+}
+
+func validateRevokeSessionRequest(req *pb.RevokeSessionRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetSessionId() == "" {
+		violations = append(violations, fieldViolations("session_id", errors.New("session id cannot be empty")))
+	}
+
+	return violations
+}
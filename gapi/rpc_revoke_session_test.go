@@ -0,0 +1,142 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func randomSession(username string) db.Session {
+	return db.Session{
+		ID:        util.RandomString(16),
+		Username:  username,
+		UserAgent: "test-agent",
+		ClientIp:  "192.0.2.1",
+		FamilyID:  util.RandomString(16),
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+}
+
+func TestRevokeSessionAPI(t *testing.T) {
+	owner := util.RandomOwner()
+	session := randomSession(owner)
+
+	testCases := []struct {
+		name          string
+		req           *pb.RevokeSessionRequest
+		buildContext  func(t *testing.T) context.Context
+		buildStubs    func(store *mockdb.MockStore)
+		forgotten     bool
+		checkResponse func(t *testing.T, rsp *pb.RevokeSessionResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.RevokeSessionRequest{SessionId: session.ID},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, owner)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).Times(1).Return(nil)
+			},
+			forgotten: true,
+			checkResponse: func(t *testing.T, rsp *pb.RevokeSessionResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "session revoked", rsp.GetMessage())
+			},
+		},
+		{
+			name: "OtherUsersSession",
+			req:  &pb.RevokeSessionRequest{SessionId: session.ID},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, util.RandomOwner())
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RevokeSessionResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "NotFound",
+			req:  &pb.RevokeSessionRequest{SessionId: session.ID},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, owner)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(db.Session{}, pgx.ErrNoRows)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RevokeSessionResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "NoAuthPayload",
+			req:  &pb.RevokeSessionRequest{SessionId: session.ID},
+			buildContext: func(t *testing.T) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RevokeSessionResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.RevokeSessionRequest{SessionId: session.ID},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, owner)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().RevokeSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).Times(1).Return(pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RevokeSessionResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+		{
+			name: "EmptySessionID",
+			req:  &pb.RevokeSessionRequest{},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, owner)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RevokeSessionResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			forgotten := cacheSessionState(t, server.revocationChecker, store, owner, session.FamilyID)
+
+			rsp, err := server.RevokeSession(tc.buildContext(t), tc.req)
+			tc.checkResponse(t, rsp, err)
+			require.Equal(t, tc.forgotten, forgotten())
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) SetupTOTP(ctx context.Context, req *pb.SetupTOTPRequest) (*pb.SetupTOTPResponse, error) {
+	authPayload, err := getAuthPayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot get user: %v", err)
+	}
+
+	if user.TotpEnabled {
+		return nil, status.Errorf(codes.AlreadyExists, "two-factor authentication is already enabled")
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot generate secret: %v", err)
+	}
+
+	_, err = server.store.SetUserTOTPSecret(ctx, db.SetUserTOTPSecretParams{
+		Username:   user.Username,
+		TotpSecret: pgtype.Text{String: secret, Valid: true},
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot save secret: %v", err)
+	}
+
+	return &pb.SetupTOTPResponse{
+		Secret:     secret,
+		OtpauthUri: util.TOTPURI(util.TOTPIssuer, user.Username, secret),
+	}, nil
+}
@@ -12,7 +12,6 @@ import (
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
@@ -20,9 +19,9 @@ func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest)
 		return nil, status.Error(codes.InvalidArgument, "username cannot be empty")
 	}
 
-	authPayload, err := server.authorizeUser(ctx)
+	authPayload, err := getAuthPayload(ctx)
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "unauthorized: %s", err)
+		return nil, err
 	}
 
 	if authPayload.Username != req.GetUsername() {
@@ -73,14 +72,13 @@ func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest)
 		return nil, status.Errorf(codes.Internal, "failed to update user: %s", err)
 	}
 
+	// Access tokens issued before the new password must stop working right away
+	if arg.PasswordChangedAt.Valid {
+		server.revocationChecker.ForgetUser(user.Username)
+	}
+
 	rsp := &pb.UpdateUserResponse{
-		User: &pb.User{
-			Username:          user.Username,
-			FullName:          user.FullName,
-			Email:             user.Email,
-			PasswordChangedAt: timestamppb.New(user.PasswordChangedAt.Time),
-			CreatedAt:         timestamppb.New(user.CreatedAt.Time),
-		},
+		User: convertUser(user),
 	}
 
 	return rsp, nil
@@ -0,0 +1,161 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+
+	mockdb "github.com/Aadityaa2606/Bank-API/db/mock"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUpdateUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	newFullName := util.RandomOwner()
+	newPassword := util.RandomString(8)
+
+	testCases := []struct {
+		name          string
+		req           *pb.UpdateUserRequest
+		buildContext  func(t *testing.T) context.Context
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.UpdateUserResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.UpdateUserRequest{Username: user.Username, FullName: &newFullName},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateUserParams{
+					Username: user.Username,
+					FullName: pgtype.Text{String: newFullName, Valid: true},
+				}
+				updated := user
+				updated.FullName = newFullName
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updated, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.UpdateUserResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, newFullName, rsp.GetUser().GetFullName())
+			},
+		},
+		{
+			name: "OtherUser",
+			req:  &pb.UpdateUserRequest{Username: user.Username, FullName: &newFullName},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, util.RandomOwner())
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "NoAuthPayload",
+			req:  &pb.UpdateUserRequest{Username: user.Username, FullName: &newFullName},
+			buildContext: func(t *testing.T) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "UserNotFound",
+			req:  &pb.UpdateUserRequest{Username: user.Username, FullName: &newFullName},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, pgx.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidEmail",
+			req:  &pb.UpdateUserRequest{Username: user.Username, Email: ptr("not-an-email")},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "PasswordChange",
+			req:  &pb.UpdateUserRequest{Username: user.Username, Password: &newPassword},
+			buildContext: func(t *testing.T) context.Context {
+				return newAuthContext(t, user.Username)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Cond(func(arg db.UpdateUserParams) bool {
+					return arg.PasswordChangedAt.Valid &&
+						arg.HashedPassword.Valid &&
+						util.CheckPassword(arg.HashedPassword.String, newPassword) == nil
+				})).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.UpdateUserResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mockdb.NewMockStore(gomock.NewController(t))
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			rsp, err := server.UpdateUser(tc.buildContext(t), tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestUpdateUserPasswordForgetsCachedSessions(t *testing.T) {
+	user, _ := randomUser(t)
+	newPassword := util.RandomString(8)
+
+	store := mockdb.NewMockStore(gomock.NewController(t))
+	server := newTestServer(t, store)
+
+	ctx := newAuthContext(t, user.Username)
+	payload, err := getAuthPayload(ctx)
+	require.NoError(t, err)
+
+	// Once to fill the cache, once more after the password change dropped it
+	store.EXPECT().GetSessionFamilyState(gomock.Any(), gomock.Any()).Times(2).
+		Return(db.GetSessionFamilyStateRow{SessionActive: true}, nil)
+	store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+
+	require.NoError(t, server.revocationChecker.Check(ctx, payload))
+	require.NoError(t, server.revocationChecker.Check(ctx, payload))
+
+	_, err = server.UpdateUser(ctx, &pb.UpdateUserRequest{Username: user.Username, Password: &newPassword})
+	require.NoError(t, err)
+
+	require.NoError(t, server.revocationChecker.Check(ctx, payload))
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
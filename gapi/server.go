@@ -2,44 +2,48 @@ package gapi
 
 import (
 	"fmt"
-	"os"
-	"time"
+	"net/netip"
 
+	"github.com/Aadityaa2606/Bank-API/currency"
 	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/mail"
 	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/revocation"
 	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/Aadityaa2606/Bank-API/util"
 )
 
 type Server struct {
 	pb.UnimplementedSimpleBankServer
-	store                *db.Store
-	tokenMaker           token.Maker
-	accessTokenDuration  time.Duration
-	refreshTokenDuration time.Duration
+	config            util.Config
+	store             db.Store
+	tokenMaker        token.Maker
+	revocationChecker *revocation.Checker
+	currencies        *currency.Registry
+	mailer            *mail.Queue
+	trustedProxies    []netip.Prefix
 }
 
 // NewServer creates a new gRPC server and set up routing.
-func NewServer(store *db.Store) (*Server, error) {
-	tokenMaker, err := token.NewJWTMaker(os.Getenv("TOKEN_SYMMETRIC_KEY"))
+func NewServer(config util.Config, store db.Store, mailer *mail.Queue, revocationChecker *revocation.Checker, currencies *currency.Registry) (*Server, error) {
+	tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
-	accessTokenDuration, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_DURATION"))
+	trustedProxies, err := util.ParseTrustedProxies(config.TrustedProxies)
 	if err != nil {
-		return nil, fmt.Errorf("cannot parse access token duration: %w", err)
-	}
-
-	refreshTokenDuration, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_DURATION"))
-	if err != nil {
-		return nil, fmt.Errorf("cannot parse refresh token duration: %w", err)
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
 	}
 
 	server := &Server{
-		store:                store,
-		tokenMaker:           tokenMaker,
-		accessTokenDuration:  accessTokenDuration,
-		refreshTokenDuration: refreshTokenDuration,
+		config:            config,
+		store:             store,
+		tokenMaker:        tokenMaker,
+		revocationChecker: revocationChecker,
+		currencies:        currencies,
+		mailer:            mailer,
+		trustedProxies:    trustedProxies,
 	}
 
 	return server, nil
@@ -0,0 +1,146 @@
+// Package health reports whether the service is alive and ready to take traffic,
+// over HTTP for load balancers and with the standard gRPC health service.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// checkTimeout bounds how long a readiness check may wait on the database
+	checkTimeout = 2 * time.Second
+	// watchInterval is how often the gRPC health status is refreshed
+	watchInterval = 5 * time.Second
+)
+
+var ErrNotReady = errors.New("service is starting or shutting down")
+
+const schemaVersionQuery = `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`
+
+// Checker tells if the database is reachable and migrated to the version the binary expects.
+// Without a pool, as with the in-memory store, only readiness itself is checked.
+type Checker struct {
+	pool          *pgxpool.Pool
+	schemaVersion int64
+	ready         atomic.Bool
+	grpcServer    *health.Server
+}
+
+// NewChecker starts out not ready, SetReady is called once the servers listen
+func NewChecker(pool *pgxpool.Pool, schemaVersion int64) *Checker {
+	checker := &Checker{
+		pool:          pool,
+		schemaVersion: schemaVersion,
+		grpcServer:    health.NewServer(),
+	}
+	checker.grpcServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	return checker
+}
+
+// SetReady flips readiness, it is false during startup and shutdown.
+// The gRPC health status follows right away instead of on the next Watch tick.
+func (checker *Checker) SetReady(ready bool) {
+	checker.ready.Store(ready)
+	checker.updateGrpcStatus(context.Background())
+}
+
+// Ready returns the reason the service cannot take traffic, or nil
+func (checker *Checker) Ready(ctx context.Context) error {
+	if !checker.ready.Load() {
+		return ErrNotReady
+	}
+
+	if checker.pool == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	if err := checker.pool.Ping(ctx); err != nil {
+		return fmt.Errorf("cannot reach database: %w", err)
+	}
+
+	var version int64
+	if err := checker.pool.QueryRow(ctx, schemaVersionQuery).Scan(&version); err != nil {
+		return fmt.Errorf("cannot read schema version: %w", err)
+	}
+
+	if version < checker.schemaVersion {
+		return fmt.Errorf("database schema is at version %d, expected %d", version, checker.schemaVersion)
+	}
+
+	return nil
+}
+
+// GrpcServer is the standard gRPC health service, kept up to date by SetReady and Watch
+func (checker *Checker) GrpcServer() healthpb.HealthServer {
+	return checker.grpcServer
+}
+
+// Watch refreshes the gRPC health status until the context is done
+func (checker *Checker) Watch(ctx context.Context) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		checker.updateGrpcStatus(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (checker *Checker) updateGrpcStatus(ctx context.Context) {
+	status := healthpb.HealthCheckResponse_SERVING
+	if err := checker.Ready(ctx); err != nil {
+		if !errors.Is(err, ErrNotReady) {
+			log.Warn().Err(err).Msg("readiness check failed")
+		}
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	checker.grpcServer.SetServingStatus("", status)
+}
+
+type statusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func writeStatus(w http.ResponseWriter, code int, rsp statusResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(rsp)
+}
+
+// LivenessHandler answers as long as the process can serve requests
+func (checker *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, http.StatusOK, statusResponse{Status: "ok"})
+	})
+}
+
+// ReadinessHandler answers 503 while the service should not get traffic
+func (checker *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := checker.Ready(r.Context()); err != nil {
+			writeStatus(w, http.StatusServiceUnavailable, statusResponse{Status: "unavailable", Error: err.Error()})
+			return
+		}
+		writeStatus(w, http.StatusOK, statusResponse{Status: "ok"})
+	})
+}
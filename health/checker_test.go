@@ -0,0 +1,55 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestNotReadyDuringStartup(t *testing.T) {
+	checker := NewChecker(nil, 1)
+
+	require.ErrorIs(t, checker.Ready(context.Background()), ErrNotReady)
+
+	recorder := httptest.NewRecorder()
+	checker.ReadinessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	checker.LivenessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	rsp, err := checker.GrpcServer().Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, rsp.Status)
+}
+
+func TestReadyWithoutDatabase(t *testing.T) {
+	checker := NewChecker(nil, 0)
+	checker.SetReady(true)
+
+	require.NoError(t, checker.Ready(context.Background()))
+
+	recorder := httptest.NewRecorder()
+	checker.ReadinessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestSetReadyUpdatesGrpcStatus(t *testing.T) {
+	checker := NewChecker(nil, 0)
+
+	// No Watch runs, so the status can only come from SetReady
+	checker.SetReady(true)
+	rsp, err := checker.GrpcServer().Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, rsp.Status)
+
+	checker.SetReady(false)
+	rsp, err = checker.GrpcServer().Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, rsp.Status)
+}
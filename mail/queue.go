@@ -0,0 +1,88 @@
+package mail
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// sendTimeout bounds the time a single task gets to build and send its email
+const sendTimeout = 30 * time.Second
+
+// Task builds an email and sends it with sender
+type Task func(ctx context.Context, sender EmailSender) error
+
+// Queue runs email tasks in the background, one at a time, so requests answer
+// without waiting for the database writes and the SMTP server behind an email.
+type Queue struct {
+	sender EmailSender
+	tasks  chan Task
+	done   chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewQueue creates a queue holding up to size tasks, see Run
+func NewQueue(sender EmailSender, size int) *Queue {
+	return &Queue{
+		sender: sender,
+		tasks:  make(chan Task, size),
+		done:   make(chan struct{}),
+	}
+}
+
+// Enqueue adds a task without waiting. It returns false when the queue is full
+// or shut down, and the task is dropped.
+func (queue *Queue) Enqueue(task Task) bool {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	if queue.closed {
+		return false
+	}
+	select {
+	case queue.tasks <- task:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run performs the queued tasks until Shutdown, a failed task is only logged
+func (queue *Queue) Run() {
+	defer close(queue.done)
+
+	for task := range queue.tasks {
+		queue.perform(task)
+	}
+}
+
+func (queue *Queue) perform(task Task) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	if err := task(ctx, queue.sender); err != nil {
+		log.Error().Err(err).Msg("cannot send email")
+	}
+}
+
+// Shutdown stops accepting tasks and waits for Run to finish the queued ones,
+// or for ctx to be done
+func (queue *Queue) Shutdown(ctx context.Context) error {
+	queue.mu.Lock()
+	if !queue.closed {
+		queue.closed = true
+		close(queue.tasks)
+	}
+	queue.mu.Unlock()
+
+	select {
+	case <-queue.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSender struct {
+	subjects chan string
+}
+
+func (sender recordingSender) SendEmail(ctx context.Context, subject string, content string, to []string) error {
+	sender.subjects <- subject
+	return nil
+}
+
+func sendTask(subject string) Task {
+	return func(ctx context.Context, sender EmailSender) error {
+		return sender.SendEmail(ctx, subject, "content", []string{"user@example.com"})
+	}
+}
+
+func TestQueue(t *testing.T) {
+	sender := recordingSender{subjects: make(chan string, 3)}
+	queue := NewQueue(sender, 2)
+
+	// Nothing runs the queue yet, so the third task does not fit
+	require.True(t, queue.Enqueue(sendTask("first")))
+	require.True(t, queue.Enqueue(func(ctx context.Context, sender EmailSender) error {
+		return errors.New("smtp is down")
+	}))
+	require.False(t, queue.Enqueue(sendTask("dropped")))
+
+	go queue.Run()
+	require.Equal(t, "first", <-sender.subjects)
+
+	require.True(t, queue.Enqueue(sendTask("second")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, queue.Shutdown(ctx))
+
+	// Shutdown waits for the queued tasks and refuses new ones
+	require.Equal(t, "second", <-sender.subjects)
+	require.False(t, queue.Enqueue(sendTask("late")))
+	require.Empty(t, sender.subjects)
+}
@@ -0,0 +1,85 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// EmailSender delivers plain-text emails to users
+type EmailSender interface {
+	SendEmail(ctx context.Context, subject string, content string, to []string) error
+}
+
+type SMTPSender struct {
+	address     string
+	username    string
+	password    string
+	fromAddress string
+}
+
+// NewSMTPSender creates a sender that delivers emails through an SMTP server
+func NewSMTPSender(host string, port string, username string, password string, fromAddress string) EmailSender {
+	return &SMTPSender{
+		address:     net.JoinHostPort(host, port),
+		username:    username,
+		password:    password,
+		fromAddress: fromAddress,
+	}
+}
+
+// SendEmail sends the email to all the recipients in a single SMTP transaction
+func (sender *SMTPSender) SendEmail(ctx context.Context, subject string, content string, to []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	host, _, err := net.SplitHostPort(sender.address)
+	if err != nil {
+		return fmt.Errorf("invalid smtp address: %w", err)
+	}
+
+	var auth smtp.Auth
+	if sender.username != "" {
+		auth = smtp.PlainAuth("", sender.username, sender.password, host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + sender.fromAddress,
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=\"UTF-8\"",
+		"",
+		content,
+	}, "\r\n")
+
+	err = smtp.SendMail(sender.address, auth, sender.fromAddress, to, []byte(msg))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+type LogSender struct{}
+
+// NewLogSender creates a sender that only writes emails to the log,
+// useful for local development where no SMTP server is available
+func NewLogSender() EmailSender {
+	return &LogSender{}
+}
+
+// SendEmail logs the email instead of delivering it. The content is left out,
+// emails carry secrets such as password reset links that logs must not keep.
+func (sender *LogSender) SendEmail(ctx context.Context, subject string, content string, to []string) error {
+	log.Info().
+		Strs("to", to).
+		Str("subject", subject).
+		Int("content_bytes", len(content)).
+		Msg("email not sent, logging instead")
+	return nil
+}
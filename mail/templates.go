@@ -0,0 +1,20 @@
+package mail
+
+import (
+	"fmt"
+	"time"
+)
+
+// PasswordResetEmail builds the subject and content of the email carrying a password reset link
+func PasswordResetEmail(fullName string, resetLink string, validFor time.Duration) (string, string) {
+	subject := "Reset your Simple Bank password"
+	content := fmt.Sprintf(
+		"Hello %s,\n\n"+
+			"We received a request to reset the password of your Simple Bank account.\n"+
+			"Use the link below to choose a new password. It can be used once and expires in %s.\n\n"+
+			"%s\n\n"+
+			"If you did not request a password reset you can ignore this email.\n",
+		fullName, validFor, resetLink,
+	)
+	return subject, content
+}
@@ -2,127 +2,169 @@ package main
 
 import (
 	"context"
-	"net"
-	"net/http"
+	"errors"
+	"flag"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
-	"github.com/Aadityaa2606/Bank-API/api"
+	"github.com/Aadityaa2606/Bank-API/currency"
+	"github.com/Aadityaa2606/Bank-API/db/migration"
 	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
-	"github.com/Aadityaa2606/Bank-API/gapi"
-	"github.com/Aadityaa2606/Bank-API/pb"
-	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/Aadityaa2606/Bank-API/health"
+	"github.com/Aadityaa2606/Bank-API/mail"
+	"github.com/Aadityaa2606/Bank-API/metrics"
+	"github.com/Aadityaa2606/Bank-API/revocation"
+	"github.com/Aadityaa2606/Bank-API/telemetry"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5/multitracer"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/joho/godotenv"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/reflection"
-	"google.golang.org/protobuf/encoding/protojson"
+	"golang.org/x/sync/errgroup"
 )
 
+var interruptSignals = []os.Signal{
+	os.Interrupt,
+	syscall.SIGTERM,
+}
+
 func main() {
-	// Loads environment variables
-	err := godotenv.Load()
+	config, err := util.LoadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatal().Err(err).Msg("Error loading .env file")
+		log.Fatal().Err(err).Msg("invalid configuration:")
 	}
-	dbSource := os.Getenv("DB_SOURCE")
 
-	if os.Getenv("ENVIROMENT") == "development" {
+	if config.Environment == util.EnvironmentDevelopment {
 		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	}
 
-	// Creates DB connection
-	conn, err := pgxpool.New(context.Background(), dbSource)
-	if err != nil {
-		log.Fatal().Err(err).Msg("cannot connect to db:")
+	for _, key := range config.UnknownKeys {
+		log.Warn().Str("key", key).Msg("unknown configuration key, check it for typos")
 	}
 
-	store := db.NewStore(conn)
+	if len(config.Args) > 0 {
+		if config.Args[0] != "migrate" {
+			log.Fatal().Msgf("unknown command %q, %s", config.Args[0], migrateUsage)
+		}
+		if err := runMigrate(config, config.Args[1:]); err != nil {
+			log.Fatal().Err(err).Msg("migration failed:")
+		}
+		return
+	}
 
-	if os.Getenv("SERVER_MODE") == "http" {
-		runGinServer(store)
-	} else {
-		go runGatewayServer(store)
-		runGrpcServer(store)
+	if err := config.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid configuration:")
 	}
-}
 
-func runGrpcServer(store *db.Store) {
-	server, err := gapi.NewServer(store)
+	shutdownTracing, err := telemetry.Setup(context.Background(), config.TracingExporter, config.TracingFile)
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot create server: ")
+		log.Fatal().Err(err).Msg("cannot set up tracing:")
 	}
 
-	grpcLogger := grpc.UnaryInterceptor(gapi.GrpcLogger)
+	store, conn, healthChecker := openStore(config)
+	mailer := mail.NewQueue(newEmailSender(config), int(config.EmailQueueSize))
+	go mailer.Run()
+	revocationChecker := revocation.NewChecker(store, config.RevocationCacheTTL)
+	currencies := currency.NewRegistry(store, config.CurrencyCacheTTL)
 
-	grpcServer := grpc.NewServer(grpcLogger)
+	ctx, stop := signal.NotifyContext(context.Background(), interruptSignals...)
+	defer stop()
 
-	pb.RegisterSimpleBankServer(grpcServer, server)
-	reflection.Register(grpcServer)
+	// Every server and background worker runs in the group, the first one
+	// to fail cancels ctx which shuts the others down as well
+	waitGroup, ctx := errgroup.WithContext(ctx)
 
-	listener, err := net.Listen("tcp", os.Getenv("GRPC_SERVER_ADDR"))
-	if err != nil {
-		log.Fatal().Err(err).Msg("cannot start server: ")
-	}
+	waitGroup.Go(func() error {
+		healthChecker.Watch(ctx)
+		return nil
+	})
 
-	log.Info().Msgf("starting gRPC server on %s", os.Getenv("GRPC_SERVER_ADDR"))
-	err = grpcServer.Serve(listener)
-	if err != nil {
-		log.Fatal().Err(err).Msg("cannot start server: ")
-	}
-}
+	runServers(ctx, waitGroup, config, store, mailer, revocationChecker, currencies, healthChecker)
+	healthChecker.SetReady(true)
 
-func runGatewayServer(store *db.Store) {
-	server, err := gapi.NewServer(store)
-	if err != nil {
-		log.Fatal().Err(err).Msg("cannot create server: ")
+	err = waitGroup.Wait()
+
+	// The servers are drained at this point, so no more emails are queued and
+	// once the queued ones are sent nothing uses the pool anymore
+	mailCtx, cancelMail := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	if err := mailer.Shutdown(mailCtx); err != nil {
+		log.Error().Err(err).Msg("cannot send every queued email")
 	}
+	cancelMail()
 
-	jsonOption := runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
-		MarshalOptions: protojson.MarshalOptions{
-			UseProtoNames: true,
-		},
-		UnmarshalOptions: protojson.UnmarshalOptions{
-			DiscardUnknown: true,
-		},
-	})
+	if conn != nil {
+		conn.Close()
+	}
 
-	grpcMux := runtime.NewServeMux(jsonOption)
-	ctx, cancel := context.WithCancel(context.Background())
+	tracingCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
 	defer cancel()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		log.Error().Err(err).Msg("cannot flush traces")
+	}
 
-	err = pb.RegisterSimpleBankHandlerServer(ctx, grpcMux, server)
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot register service: ")
+		log.Fatal().Err(err).Msg("server stopped with an error")
 	}
+	log.Info().Msg("server stopped")
+}
 
-	mux := http.NewServeMux()
-	mux.Handle("/", grpcMux)
+// openStore connects to Postgres and checks its schema, or creates an empty in-memory
+// store. The pool is nil for the in-memory store.
+func openStore(config util.Config) (db.Store, *pgxpool.Pool, *health.Checker) {
+	if config.StoreDriver == util.StoreDriverMemory {
+		log.Warn().Msg("STORE_DRIVER is memory, all data will be lost when the server stops")
+		return db.NewMemoryStore(), nil, health.NewChecker(nil, 0)
+	}
 
-	listener, err := net.Listen("tcp", os.Getenv("HTTP_SERVER_ADDR"))
+	txConfig, err := db.NewTxConfig(config)
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot start server: ")
+		log.Fatal().Err(err).Msg("invalid TX_ISOLATION_LEVELS:")
 	}
 
-	log.Info().Msgf("starting HTTP gateway server on %s", os.Getenv("HTTP_SERVER_ADDR"))
-	err = http.Serve(listener, mux)
+	// Creates DB connection
+	poolConfig, err := pgxpool.ParseConfig(config.DBSource)
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot start HTTP Gateway server: ")
+		log.Fatal().Err(err).Msg("cannot parse db source:")
 	}
-}
-
-func runGinServer(store *db.Store) {
-	server, err := api.NewServer(store)
+	poolConfig.ConnConfig.Tracer = multitracer.New(db.QueryLogger{}, telemetry.QueryTracer{})
 
+	conn, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot create server: ")
+		log.Fatal().Err(err).Msg("cannot connect to db:")
 	}
+	metrics.RegisterDBPool(conn)
 
-	log.Info().Msgf("starting HTTP/REST server on %s", os.Getenv("HTTP_SERVER_ADDR"))
-	err = server.Start(os.Getenv("HTTP_SERVER_ADDR"))
+	// Readiness needs the schema to be at least at the newest migration the binary was built with
+	schemaVersion, err := migration.LatestVersion()
 	if err != nil {
-		log.Fatal().Err(err).Msg("Cannot start server: ")
+		log.Fatal().Err(err).Msg("cannot read migrations:")
+	}
+	if err := prepareSchema(context.Background(), config, conn, schemaVersion); err != nil {
+		log.Fatal().Err(err).Msg("database is not ready:")
 	}
+	healthChecker := health.NewChecker(conn, schemaVersion)
+
+	return db.NewStore(conn, txConfig), conn, healthChecker
+}
+
+// newEmailSender uses SMTP when a host is configured and falls back to logging emails,
+// which Validate only allows in development
+func newEmailSender(config util.Config) mail.EmailSender {
+	if config.SMTPHost == "" {
+		log.Warn().Msg("SMTP_HOST is not set, emails will only be logged without their content")
+		return mail.NewLogSender()
+	}
+
+	return mail.NewSMTPSender(
+		config.SMTPHost,
+		config.SMTPPort,
+		config.SMTPUsername,
+		config.SMTPPassword,
+		config.EmailSenderAddress,
+	)
 }
@@ -0,0 +1,52 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	transfersCompleted = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_completed_total",
+		Help:      "Money transfers committed.",
+	})
+
+	transferVolume = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfer_volume_total",
+		Help:      "Amount moved by completed transfers, in the smallest unit of each currency.",
+	}, []string{"currency"})
+
+	insufficientBalanceRejections = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "insufficient_balance_rejections_total",
+		Help:      "Transfers refused because the source account balance was too low.",
+	})
+
+	loginAttempts = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_attempts_total",
+		Help:      "Login attempts, by result.",
+	}, []string{"result"})
+)
+
+// Results of a login attempt
+const (
+	LoginSucceeded = "success"
+	LoginFailed    = "failure"
+	LoginLocked    = "locked"
+)
+
+// TransferCompleted counts a committed transfer and its amount
+func TransferCompleted(currency string, amount int64) {
+	transfersCompleted.Inc()
+	transferVolume.WithLabelValues(currency).Add(float64(amount))
+}
+
+// InsufficientBalance counts a transfer refused for lack of funds
+func InsufficientBalance() {
+	insufficientBalanceRejections.Inc()
+}
+
+// LoginAttempt counts a login attempt with one of the login results
+func LoginAttempt(result string) {
+	loginAttempts.WithLabelValues(result).Inc()
+}
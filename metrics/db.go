@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	txRetries = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_tx_retries_total",
+		Help:      "Transactions run again after a serialization failure or deadlock, by transaction and error code.",
+	}, []string{"tx", "code"})
+
+	txRetriesExhausted = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_tx_retries_exhausted_total",
+		Help:      "Transactions that still conflicted after their last retry, by transaction.",
+	}, []string{"tx"})
+)
+
+// TxRetried counts a transaction run again after failing with the Postgres error code
+func TxRetried(tx string, code string) {
+	txRetries.WithLabelValues(tx, code).Inc()
+}
+
+// TxRetriesExhausted counts a transaction given up on after its last retry
+func TxRetriesExhausted(tx string) {
+	txRetriesExhausted.WithLabelValues(tx).Inc()
+}
+
+// poolCollector reads the statistics of a pgx pool every time the metrics are scraped
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	totalConns        *prometheus.Desc
+	maxConns          *prometheus.Desc
+	acquireCount      *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquireCount *prometheus.Desc
+	canceledAcquires  *prometheus.Desc
+}
+
+func newPoolDesc(name string, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+}
+
+// RegisterDBPool exposes the connection statistics of the database pool
+func RegisterDBPool(pool *pgxpool.Pool) {
+	Registry.MustRegister(&poolCollector{
+		pool:              pool,
+		acquiredConns:     newPoolDesc("acquired_conns", "Connections currently in use."),
+		idleConns:         newPoolDesc("idle_conns", "Connections currently idle."),
+		totalConns:        newPoolDesc("total_conns", "Connections currently open."),
+		maxConns:          newPoolDesc("max_conns", "Maximum size of the pool."),
+		acquireCount:      newPoolDesc("acquire_count_total", "Connections acquired from the pool."),
+		acquireDuration:   newPoolDesc("acquire_duration_seconds_total", "Time spent acquiring connections, including waiting for one."),
+		emptyAcquireCount: newPoolDesc("empty_acquire_count_total", "Acquires that had to wait because the pool was empty."),
+		canceledAcquires:  newPoolDesc("canceled_acquire_count_total", "Acquires canceled before a connection was available."),
+	})
+}
+
+func (collector *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(collector, ch)
+}
+
+func (collector *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := collector.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(collector.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(collector.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(collector.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(collector.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(collector.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(collector.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(collector.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(collector.canceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "gRPC calls handled, by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "Time spent handling gRPC calls, by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+func observeGrpcCall(method string, startTime time.Time, err error) {
+	grpcRequests.WithLabelValues(method, status.Code(err).String()).Inc()
+	grpcRequestDuration.WithLabelValues(method).Observe(time.Since(startTime).Seconds())
+}
+
+// UnaryServerInterceptor records the count and latency of every unary call per method
+func UnaryServerInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	startTime := time.Now()
+	resp, err := handler(ctx, req)
+	observeGrpcCall(info.FullMethod, startTime, err)
+	return resp, err
+}
+
+// StreamServerInterceptor records the count and duration of every streaming call per method
+func StreamServerInterceptor(
+	srv any,
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	startTime := time.Now()
+	err := handler(srv, stream)
+	observeGrpcCall(info.FullMethod, startTime, err)
+	return err
+}
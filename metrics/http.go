@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status_code"})
+
+	httpRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time spent handling HTTP requests, by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// GinMiddleware records the count and latency of every request per route
+func GinMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		startTime := time.Now()
+		ctx.Next()
+		duration := time.Since(startTime)
+
+		// Routes are labelled by pattern so IDs in paths do not create new series
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		method := ctx.Request.Method
+		httpRequests.WithLabelValues(method, route, strconv.Itoa(ctx.Writer.Status())).Inc()
+		httpRequestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GinMiddleware())
+	router.GET("/accounts/:id", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/accounts/1", "/accounts/2", "/missing"} {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(recorder, request)
+	}
+
+	// Both accounts share the route pattern instead of creating a series each
+	require.Equal(t, 2.0, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/accounts/:id", "200")))
+	require.Equal(t, 1.0, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "unmatched", "404")))
+}
+
+func TestTransferCompleted(t *testing.T) {
+	before := testutil.ToFloat64(transfersCompleted)
+
+	TransferCompleted("EUR", 150)
+	TransferCompleted("EUR", 50)
+
+	require.Equal(t, before+2, testutil.ToFloat64(transfersCompleted))
+	require.Equal(t, 200.0, testutil.ToFloat64(transferVolume.WithLabelValues("EUR")))
+}
+
+func TestHandler(t *testing.T) {
+	LoginAttempt(LoginSucceeded)
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.True(t, strings.Contains(recorder.Body.String(), `simplebank_login_attempts_total{result="success"}`))
+}
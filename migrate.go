@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Aadityaa2606/Bank-API/db/migration"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/rs/zerolog/log"
+)
+
+const migrateUsage = "usage: migrate up|down|status|version"
+
+// runMigrate applies the migrations embedded in the binary:
+//
+//	up       applies every pending migration
+//	down     rolls back the latest migration
+//	status   lists every migration and when it was applied
+//	version  prints the version of the database schema
+func runMigrate(config util.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	if err := config.ValidateDatabase(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), interruptSignals...)
+	defer stop()
+
+	db, err := sql.Open("pgx", config.DBSource)
+	if err != nil {
+		return fmt.Errorf("cannot connect to db: %w", err)
+	}
+	defer db.Close()
+
+	provider, err := migration.NewProvider(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		results, err := provider.Up(ctx)
+		logMigrationResults(results)
+		if err == nil && len(results) == 0 {
+			log.Info().Msg("no pending migrations")
+		}
+		return err
+	case "down":
+		result, err := provider.Down(ctx)
+		if result != nil {
+			logMigrationResults([]*goose.MigrationResult{result})
+		}
+		return err
+	case "status":
+		statuses, err := provider.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(statuses)
+		return nil
+	case "version":
+		version, err := provider.GetDBVersion(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println(version)
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, %s", args[0], migrateUsage)
+	}
+}
+
+func logMigrationResults(results []*goose.MigrationResult) {
+	for _, result := range results {
+		logger := log.Info()
+		if result.Error != nil {
+			logger = log.Error().Err(result.Error)
+		}
+		logger.
+			Int64("version", result.Source.Version).
+			Str("migration", result.Source.Path).
+			Str("direction", result.Direction).
+			Dur("duration", result.Duration).
+			Msg("migration applied")
+	}
+}
+
+func printMigrationStatus(statuses []*goose.MigrationStatus) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tSTATE\tAPPLIED AT\tMIGRATION")
+	for _, status := range statuses {
+		appliedAt := "-"
+		if !status.AppliedAt.IsZero() {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", status.Source.Version, status.State, appliedAt, status.Source.Path)
+	}
+	writer.Flush()
+}
+
+// prepareSchema applies the pending migrations when AUTO_MIGRATE is set, then
+// refuses to go on with a schema older than the one the binary was built for.
+// Newer schemas are fine, they are expected while a deployment rolls out.
+func prepareSchema(ctx context.Context, config util.Config, pool *pgxpool.Pool, schemaVersion int64) error {
+	db := stdlib.OpenDBFromPool(pool)
+	defer db.Close()
+
+	provider, err := migration.NewProvider(db)
+	if err != nil {
+		return err
+	}
+
+	if config.AutoMigrate {
+		results, err := provider.Up(ctx)
+		logMigrationResults(results)
+		if err != nil {
+			return fmt.Errorf("cannot apply migrations: %w", err)
+		}
+	}
+
+	// Waits on the migration lock, in case another replica is migrating right now
+	version, err := provider.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot read schema version: %w", err)
+	}
+
+	if version < schemaVersion {
+		return fmt.Errorf("database schema is at version %d, expected %d: run \"migrate up\" or set AUTO_MIGRATE=true", version, schemaVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+// Package revocation decides whether an access token that verified fine is still allowed in.
+// Access tokens are stateless, so revoking a session or changing a password has to be
+// looked up in the database. A short lived in-memory cache keeps that off the hot path.
+package revocation
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/Aadityaa2606/Bank-API/util"
+)
+
+// maxCacheEntries triggers a sweep of the expired entries once reached
+const maxCacheEntries = 10000
+
+var (
+	ErrNoSession       = errors.New("access token is not linked to a session")
+	ErrSessionRevoked  = errors.New("session has been revoked")
+	ErrPasswordChanged = errors.New("access token was issued before the last password change")
+)
+
+// sessionStateGetter is the part of the store the checker reads from
+type sessionStateGetter interface {
+	GetSessionFamilyState(ctx context.Context, arg db.GetSessionFamilyStateParams) (db.GetSessionFamilyStateRow, error)
+}
+
+type cacheEntry struct {
+	username          string
+	sessionActive     bool
+	passwordChangedAt time.Time
+	expiresAt         time.Time
+}
+
+// Checker rejects access tokens whose session was revoked or that predate a password change
+type Checker struct {
+	store sessionStateGetter
+	ttl   time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+func NewChecker(store sessionStateGetter, ttl time.Duration) *Checker {
+	return &Checker{
+		store:   store,
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Check returns an error when the session family of the token is no longer active,
+// or when the password of the user changed after the token was issued.
+func (checker *Checker) Check(ctx context.Context, payload *token.Payload) error {
+	if payload.SessionID == "" {
+		return ErrNoSession
+	}
+
+	entry, ok := checker.get(payload.SessionID)
+	if !ok || entry.username != payload.Username {
+		state, err := checker.store.GetSessionFamilyState(ctx, db.GetSessionFamilyStateParams{
+			FamilyID: payload.SessionID,
+			Username: payload.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		entry = cacheEntry{
+			username:          payload.Username,
+			sessionActive:     state.SessionActive,
+			passwordChangedAt: state.PasswordChangedAt.Time,
+			expiresAt:         time.Now().Add(checker.ttl),
+		}
+		checker.set(payload.SessionID, entry)
+	}
+
+	if !entry.sessionActive {
+		return ErrSessionRevoked
+	}
+
+	if util.IssuedBeforePasswordChange(payload.IssuedAt.Time, entry.passwordChangedAt) {
+		return ErrPasswordChanged
+	}
+
+	return nil
+}
+
+// ForgetSession drops the cached state of a session family, to be called after revoking it
+func (checker *Checker) ForgetSession(sessionID string) {
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+
+	delete(checker.entries, sessionID)
+}
+
+// ForgetUser drops the cached state of every session of a user,
+// to be called after revoking all of them or changing the password
+func (checker *Checker) ForgetUser(username string) {
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+
+	for sessionID, entry := range checker.entries {
+		if entry.username == username {
+			delete(checker.entries, sessionID)
+		}
+	}
+}
+
+func (checker *Checker) get(sessionID string) (cacheEntry, bool) {
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+
+	entry, ok := checker.entries[sessionID]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (checker *Checker) set(sessionID string, entry cacheEntry) {
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+
+	if len(checker.entries) >= maxCacheEntries {
+		now := time.Now()
+		for id, cached := range checker.entries {
+			if now.After(cached.expiresAt) {
+				delete(checker.entries, id)
+			}
+		}
+
+		// Still full of live entries, start over rather than grow without bound
+		if len(checker.entries) >= maxCacheEntries {
+			checker.entries = make(map[string]cacheEntry)
+		}
+	}
+
+	checker.entries[sessionID] = entry
+}
@@ -0,0 +1,84 @@
+package revocation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/token"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	calls int
+	state db.GetSessionFamilyStateRow
+}
+
+func (store *fakeStore) GetSessionFamilyState(ctx context.Context, arg db.GetSessionFamilyStateParams) (db.GetSessionFamilyStateRow, error) {
+	store.calls++
+	return store.state, nil
+}
+
+func newAccessPayload(t *testing.T, username string, sessionID string) *token.Payload {
+	payload, err := token.NewPayload(username, util.DepositorRole, sessionID, time.Minute, token.TokenTypeAccessToken)
+	require.NoError(t, err)
+	return payload
+}
+
+func TestCheckerCachesSessionState(t *testing.T) {
+	store := &fakeStore{state: db.GetSessionFamilyStateRow{SessionActive: true}}
+	checker := NewChecker(store, time.Minute)
+	payload := newAccessPayload(t, util.RandomOwner(), util.RandomString(16))
+
+	require.NoError(t, checker.Check(context.Background(), payload))
+	require.NoError(t, checker.Check(context.Background(), payload))
+	require.Equal(t, 1, store.calls)
+
+	// Once forgotten, the revocation is seen right away
+	store.state.SessionActive = false
+	checker.ForgetSession(payload.SessionID)
+	require.ErrorIs(t, checker.Check(context.Background(), payload), ErrSessionRevoked)
+	require.Equal(t, 2, store.calls)
+}
+
+func TestCheckerCacheExpires(t *testing.T) {
+	store := &fakeStore{state: db.GetSessionFamilyStateRow{SessionActive: true}}
+	checker := NewChecker(store, time.Millisecond)
+	payload := newAccessPayload(t, util.RandomOwner(), util.RandomString(16))
+
+	require.NoError(t, checker.Check(context.Background(), payload))
+	time.Sleep(5 * time.Millisecond)
+
+	store.state.SessionActive = false
+	require.ErrorIs(t, checker.Check(context.Background(), payload), ErrSessionRevoked)
+	require.Equal(t, 2, store.calls)
+}
+
+func TestCheckerPasswordChanged(t *testing.T) {
+	store := &fakeStore{state: db.GetSessionFamilyStateRow{
+		SessionActive:     true,
+		PasswordChangedAt: pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	}}
+	checker := NewChecker(store, time.Minute)
+	username := util.RandomOwner()
+	payload := newAccessPayload(t, username, util.RandomString(16))
+
+	require.ErrorIs(t, checker.Check(context.Background(), payload), ErrPasswordChanged)
+
+	// A token issued within the same second as the change is still accepted
+	store.state.PasswordChangedAt.Time = payload.IssuedAt.Time.Add(500 * time.Millisecond)
+	checker.ForgetUser(username)
+	require.NoError(t, checker.Check(context.Background(), payload))
+}
+
+func TestCheckerNoSession(t *testing.T) {
+	store := &fakeStore{}
+	checker := NewChecker(store, time.Minute)
+	payload := newAccessPayload(t, util.RandomOwner(), "")
+
+	require.ErrorIs(t, checker.Check(context.Background(), payload), ErrNoSession)
+	require.Zero(t, store.calls)
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Aadityaa2606/Bank-API/api"
+	"github.com/Aadityaa2606/Bank-API/currency"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/gapi"
+	"github.com/Aadityaa2606/Bank-API/health"
+	"github.com/Aadityaa2606/Bank-API/mail"
+	"github.com/Aadityaa2606/Bank-API/metrics"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/revocation"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// gatewayPathPrefix starts every route of the gateway, see the google.api.http options in proto/
+	gatewayPathPrefix = "/v1/"
+
+	readHeaderTimeout = 10 * time.Second
+)
+
+// listenerServers are the servers enabled on one address
+type listenerServers struct {
+	address string
+	grpc    *grpc.Server
+	gateway http.Handler
+	gin     http.Handler
+}
+
+func (servers *listenerServers) name() string {
+	var names []string
+	if servers.grpc != nil {
+		names = append(names, "gRPC")
+	}
+	if servers.gateway != nil {
+		names = append(names, "HTTP gateway")
+	}
+	if servers.gin != nil {
+		names = append(names, "HTTP/REST")
+	}
+	return strings.Join(names, " + ") + " server"
+}
+
+type namedHTTPServer struct {
+	*http.Server
+	name string
+}
+
+// runServers starts every enabled server. Servers configured with the same
+// address share its listener and get their requests through sharedHandler.
+func runServers(
+	ctx context.Context,
+	waitGroup *errgroup.Group,
+	config util.Config,
+	store db.Store,
+	mailer *mail.Queue,
+	revocationChecker *revocation.Checker,
+	currencies *currency.Registry,
+	healthChecker *health.Checker,
+) {
+	var listeners []*listenerServers
+	listenerFor := func(address string) *listenerServers {
+		for _, servers := range listeners {
+			if servers.address == address {
+				return servers
+			}
+		}
+		servers := &listenerServers{address: address}
+		listeners = append(listeners, servers)
+		return servers
+	}
+
+	var grpcServer *grpc.Server
+	if config.GRPCServerEnabled {
+		grpcServer = newGrpcServer(config, store, mailer, revocationChecker, currencies, healthChecker)
+		listenerFor(config.GRPCServerAddress).grpc = grpcServer
+	}
+
+	// The connection of the gateway to the gRPC server is closed when dialCtx is
+	// cancelled, which only happens after the gateway has finished its in-flight requests
+	dialCtx, cancelDial := context.WithCancel(context.Background())
+	if config.GatewayServerEnabled {
+		listenerFor(config.GatewayServerAddress).gateway = newGatewayHandler(dialCtx, config, healthChecker)
+	}
+
+	if config.HTTPServerEnabled {
+		listenerFor(config.HTTPServerAddress).gin = newGinHandler(config, store, mailer, revocationChecker, currencies, healthChecker)
+	}
+
+	var httpServers []namedHTTPServer
+	for _, servers := range listeners {
+		listener, err := net.Listen("tcp", servers.address)
+		if err != nil {
+			log.Fatal().Err(err).Msg("cannot start server: ")
+		}
+
+		name := servers.name()
+		log.Info().Msgf("starting %s on %s", name, servers.address)
+
+		if servers.gateway == nil && servers.gin == nil {
+			waitGroup.Go(func() error {
+				err := grpcServer.Serve(listener)
+				if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+					return fmt.Errorf("%s failed: %w", name, err)
+				}
+				return nil
+			})
+			continue
+		}
+
+		// gRPC clients speak HTTP/2 without TLS, which has to be allowed explicitly
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(servers.grpc != nil)
+
+		httpServer := namedHTTPServer{
+			Server: &http.Server{
+				Handler:           sharedHandler(servers),
+				ReadHeaderTimeout: readHeaderTimeout,
+				Protocols:         protocols,
+			},
+			name: name,
+		}
+		httpServers = append(httpServers, httpServer)
+
+		waitGroup.Go(func() error {
+			err := httpServer.Serve(listener)
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("%s failed: %w", name, err)
+			}
+			return nil
+		})
+	}
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		healthChecker.SetReady(false)
+		return shutdownServers(config.ShutdownTimeout, httpServers, grpcServer, cancelDial)
+	})
+}
+
+// sharedHandler routes the requests of an address several servers listen on:
+// gRPC calls by their content type, gateway routes by their prefix and everything
+// else to Gin, or to the gateway when Gin is not on this address.
+func sharedHandler(servers *listenerServers) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case servers.grpc != nil && isGrpcRequest(r):
+			servers.grpc.ServeHTTP(w, r)
+		case servers.gateway != nil && (servers.gin == nil || strings.HasPrefix(r.URL.Path, gatewayPathPrefix)):
+			servers.gateway.ServeHTTP(w, r)
+		default:
+			servers.gin.ServeHTTP(w, r)
+		}
+	})
+}
+
+func isGrpcRequest(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// shutdownServers drains the HTTP servers before the gRPC server, so the gateway
+// can still forward its in-flight requests and no gRPC call served through an
+// HTTP server is left when the gRPC server stops. Everything shares one deadline.
+func shutdownServers(timeout time.Duration, httpServers []namedHTTPServer, grpcServer *grpc.Server, cancelDial context.CancelFunc) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	for _, server := range httpServers {
+		log.Info().Msgf("shutting down %s", server.name)
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("cannot shut down %s: %w", server.name, err))
+			_ = server.Close()
+			continue
+		}
+		log.Info().Msgf("%s stopped", server.name)
+	}
+	cancelDial()
+
+	if grpcServer == nil {
+		return errors.Join(errs...)
+	}
+
+	log.Info().Msg("shutting down gRPC server")
+
+	// GracefulStop cannot drain gRPC calls still running inside a closed HTTP server
+	if len(errs) > 0 {
+		grpcServer.Stop()
+		return errors.Join(errs...)
+	}
+
+	// GracefulStop waits for every pending RPC, Stop cuts them off once the deadline is over
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Warn().Msg("gRPC server did not drain in time, closing remaining connections")
+		grpcServer.Stop()
+		<-stopped
+	}
+
+	log.Info().Msg("gRPC server stopped")
+	return nil
+}
+
+func newGrpcServer(
+	config util.Config,
+	store db.Store,
+	mailer *mail.Queue,
+	revocationChecker *revocation.Checker,
+	currencies *currency.Registry,
+	healthChecker *health.Checker,
+) *grpc.Server {
+	server, err := gapi.NewServer(config, store, mailer, revocationChecker, currencies)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create server: ")
+	}
+
+	// The logger runs first so calls refused by the auth interceptor are logged too
+	unaryInterceptors := grpc.ChainUnaryInterceptor(
+		gapi.GrpcLogger,
+		metrics.UnaryServerInterceptor,
+		server.UnaryAuthInterceptor,
+	)
+	streamInterceptors := grpc.ChainStreamInterceptor(
+		metrics.StreamServerInterceptor,
+		server.StreamAuthInterceptor,
+	)
+
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		unaryInterceptors,
+		streamInterceptors,
+	)
+
+	pb.RegisterSimpleBankServer(grpcServer, server)
+	healthpb.RegisterHealthServer(grpcServer, healthChecker.GrpcServer())
+	reflection.Register(grpcServer)
+
+	return grpcServer
+}
+
+// newGatewayHandler translates REST calls into calls to the gRPC server,
+// so they go through the same interceptors as any other gRPC client.
+func newGatewayHandler(dialCtx context.Context, config util.Config, healthChecker *health.Checker) http.Handler {
+	jsonOption := runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+		MarshalOptions: protojson.MarshalOptions{
+			UseProtoNames: true,
+		},
+		UnmarshalOptions: protojson.UnmarshalOptions{
+			DiscardUnknown: true,
+		},
+	})
+
+	grpcMux := runtime.NewServeMux(
+		jsonOption,
+		runtime.WithIncomingHeaderMatcher(gatewayIncomingHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(gatewayOutgoingHeaderMatcher),
+	)
+
+	// The client stats handler passes the trace context of the HTTP request on to the gRPC server
+	dialOptions := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		gapi.GatewayCredentials(),
+	}
+	err := pb.RegisterSimpleBankHandlerFromEndpoint(dialCtx, grpcMux, config.GRPCServerAddress, dialOptions)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot register service: ")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", grpcMux)
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/healthz", healthChecker.LivenessHandler())
+	mux.Handle("/readyz", healthChecker.ReadinessHandler())
+
+	return otelhttp.NewHandler(mux, "gateway")
+}
+
+// gatewayIncomingHeaderMatcher forwards the request ID to the gRPC server on top of the default headers
+func gatewayIncomingHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, util.RequestIDHeader) {
+		return util.RequestIDMetadataKey, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewayOutgoingHeaderMatcher returns the request ID set by the gRPC server as a plain header
+func gatewayOutgoingHeaderMatcher(key string) (string, bool) {
+	if key == util.RequestIDMetadataKey {
+		return util.RequestIDHeader, true
+	}
+	return fmt.Sprintf("%s%s", runtime.MetadataHeaderPrefix, key), true
+}
+
+func newGinHandler(
+	config util.Config,
+	store db.Store,
+	mailer *mail.Queue,
+	revocationChecker *revocation.Checker,
+	currencies *currency.Registry,
+	healthChecker *health.Checker,
+) http.Handler {
+	server, err := api.NewServer(config, store, mailer, revocationChecker, currencies, healthChecker)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create server: ")
+	}
+
+	return server.Handler()
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Aadityaa2606/Bank-API/currency"
+	db "github.com/Aadityaa2606/Bank-API/db/sqlc"
+	"github.com/Aadityaa2606/Bank-API/health"
+	"github.com/Aadityaa2606/Bank-API/pb"
+	"github.com/Aadityaa2606/Bank-API/revocation"
+	"github.com/Aadityaa2606/Bank-API/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+func namedHandler(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, name)
+	})
+}
+
+func TestSharedHandlerRouting(t *testing.T) {
+	testCases := []struct {
+		name    string
+		servers listenerServers
+		path    string
+		handler string
+	}{
+		{
+			name:    "GatewayRouteNextToGin",
+			servers: listenerServers{gateway: namedHandler("gateway"), gin: namedHandler("gin")},
+			path:    "/v1/accounts",
+			handler: "gateway",
+		},
+		{
+			name:    "GinRouteNextToGateway",
+			servers: listenerServers{gateway: namedHandler("gateway"), gin: namedHandler("gin")},
+			path:    "/accounts",
+			handler: "gin",
+		},
+		{
+			name:    "HealthNextToGateway",
+			servers: listenerServers{gateway: namedHandler("gateway"), gin: namedHandler("gin")},
+			path:    "/readyz",
+			handler: "gin",
+		},
+		{
+			name:    "GatewayAlone",
+			servers: listenerServers{gateway: namedHandler("gateway")},
+			path:    "/readyz",
+			handler: "gateway",
+		},
+		{
+			name:    "HTTP1NextToGRPC",
+			servers: listenerServers{grpc: grpc.NewServer(), gin: namedHandler("gin")},
+			path:    "/v1/accounts",
+			handler: "gin",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			request.Header.Set("Content-Type", "application/grpc")
+
+			sharedHandler(&tc.servers).ServeHTTP(recorder, request)
+			require.Equal(t, tc.handler, recorder.Body.String())
+		})
+	}
+}
+
+func TestSharedListenerServesGRPCAndHTTP(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, grpchealth.NewServer())
+
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	servers := &listenerServers{grpc: grpcServer, gin: namedHandler("gin")}
+	httpServer := &http.Server{Handler: sharedHandler(servers), Protocols: protocols}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go httpServer.Serve(listener)
+	defer httpServer.Close()
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	rsp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, rsp.GetStatus())
+
+	httpRsp, err := http.Get("http://" + listener.Addr().String() + "/accounts")
+	require.NoError(t, err)
+	defer httpRsp.Body.Close()
+
+	body, err := io.ReadAll(httpRsp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "gin", string(body))
+
+	err = shutdownServers(readHeaderTimeout, []namedHTTPServer{{Server: httpServer, name: "shared"}}, grpcServer, func() {})
+	require.NoError(t, err)
+}
+
+// blockingStore holds every lookup by email until the test releases it,
+// to keep a request in flight while the servers shut down
+type blockingStore struct {
+	db.Store
+	entered  chan string
+	releases map[string]chan struct{}
+}
+
+func (store blockingStore) GetUserByEmail(ctx context.Context, email string) (db.User, error) {
+	store.entered <- email
+	<-store.releases[email]
+	return store.Store.GetUserByEmail(ctx, email)
+}
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	return listener.Addr().String()
+}
+
+// requireListenerClosed waits until the address refuses new connections
+func requireListenerClosed(t *testing.T, address string) {
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", address)
+		if err != nil {
+			return true
+		}
+		conn.Close()
+		return false
+	}, 5*time.Second, 10*time.Millisecond, "%s still accepts connections", address)
+}
+
+func TestRunServersGracefulShutdown(t *testing.T) {
+	httpEmail := util.RandomEmail()
+	grpcEmail := util.RandomEmail()
+	store := blockingStore{
+		Store:   db.NewMemoryStore(),
+		entered: make(chan string, 2),
+		releases: map[string]chan struct{}{
+			httpEmail: make(chan struct{}),
+			grpcEmail: make(chan struct{}),
+		},
+	}
+
+	config := util.Config{
+		HTTPServerEnabled:    true,
+		HTTPServerAddress:    freeAddress(t),
+		GRPCServerEnabled:    true,
+		GRPCServerAddress:    freeAddress(t),
+		ShutdownTimeout:      5 * time.Second,
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		MFAChallengeDuration: time.Minute,
+	}
+	healthChecker := health.NewChecker(nil, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	waitGroup, ctx := errgroup.WithContext(ctx)
+
+	runServers(ctx, waitGroup, config, store, nil, revocation.NewChecker(store, time.Minute), currency.NewRegistry(store, time.Minute), healthChecker)
+	healthChecker.SetReady(true)
+
+	conn, err := grpc.NewClient(config.GRPCServerAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	httpDone := make(chan int, 1)
+	go func() {
+		body := strings.NewReader(`{"email": "` + httpEmail + `"}`)
+		rsp, err := http.Post("http://"+config.HTTPServerAddress+"/users/password/reset", "application/json", body)
+		if err != nil {
+			httpDone <- 0
+			return
+		}
+		rsp.Body.Close()
+		httpDone <- rsp.StatusCode
+	}()
+
+	grpcDone := make(chan error, 1)
+	go func() {
+		_, err := pb.NewSimpleBankClient(conn).RequestPasswordReset(context.Background(), &pb.RequestPasswordResetRequest{Email: grpcEmail})
+		grpcDone <- err
+	}()
+
+	for range 2 {
+		select {
+		case <-store.entered:
+		case <-time.After(5 * time.Second):
+			t.Fatal("requests did not reach the store")
+		}
+	}
+
+	cancel()
+
+	// Load balancers see the service as not ready before it stops taking connections
+	requireListenerClosed(t, config.HTTPServerAddress)
+	require.ErrorIs(t, healthChecker.Ready(context.Background()), health.ErrNotReady)
+
+	close(store.releases[httpEmail])
+	require.Equal(t, http.StatusAccepted, <-httpDone)
+
+	// The gRPC server stops listening once the HTTP server is drained, and
+	// GracefulStop lets the call still running finish instead of cutting it off
+	requireListenerClosed(t, config.GRPCServerAddress)
+	close(store.releases[grpcEmail])
+	require.NoError(t, <-grpcDone)
+
+	require.NoError(t, waitGroup.Wait())
+}
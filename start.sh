@@ -3,7 +3,7 @@
 set -e
 
 echo "run db migration"
-goose postgres "$DB_SOURCE" -dir /app/migration up
+/app/main migrate up
 
 echo "start the app"
-exec "$@"
\ No newline at end of file
+exec "$@"
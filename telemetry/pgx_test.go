@@ -0,0 +1,23 @@
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryName(t *testing.T) {
+	testCases := []struct {
+		sql  string
+		name string
+	}{
+		{"-- name: GetAccount :one\nSELECT * FROM accounts WHERE id = $1", "GetAccount"},
+		{"\n-- name: ListAccounts :many\nSELECT 1", "ListAccounts"},
+		{"select version()", "SELECT"},
+		{"", "query"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.name, queryName(tc.sql))
+	}
+}
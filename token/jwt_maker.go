@@ -20,22 +20,22 @@ func NewJWTMaker(secretKey string) (Maker, error) {
 	return &JWTMaker{secretKey}, nil
 }
 
-// CreateToken creates a new token for a specific username and duration
-func (maker *JWTMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+// CreateToken creates a new token for a specific username and role, session, duration and token type
+func (maker *JWTMaker) CreateToken(username string, role string, sessionID string, duration time.Duration, tokenType TokenType) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, sessionID, duration, tokenType)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
 	tokenStr, err := jwtToken.SignedString([]byte(maker.secretKey))
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return tokenStr, nil
+	return tokenStr, payload, nil
 }
 
-// VerifyToken checks if the token is valid or not
-func (maker *JWTMaker) VerifyToken(tokenStr string) (*Payload, error) {
+// VerifyToken checks if the token is valid and of the expected type
+func (maker *JWTMaker) VerifyToken(tokenStr string, tokenType TokenType) (*Payload, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &Payload{}, func(token *jwt.Token) (interface{}, error) {
 		_, ok := token.Method.(*jwt.SigningMethodHMAC)
 		if !ok {
@@ -50,5 +50,8 @@ func (maker *JWTMaker) VerifyToken(tokenStr string) (*Payload, error) {
 	if !ok {
 		return nil, fmt.Errorf("invalid token claims")
 	}
+	if payload.Type != tokenType {
+		return nil, ErrInvalidTokenType
+	}
 	return payload, nil
 }
\ No newline at end of file
@@ -13,18 +13,25 @@ func TestJWTMaker(t *testing.T) {
 	require.NoError(t, err)
 
 	username := util.RandomOwner()
+	role := util.DepositorRole
+	sessionID := util.RandomString(16)
 	duration := time.Duration(time.Second * 10)
 
-	token, err := maker.CreateToken(username, duration)
+	token, createdPayload, err := maker.CreateToken(username, role, sessionID, duration, TokenTypeAccessToken)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, createdPayload)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err := maker.VerifyToken(token, TokenTypeAccessToken)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
 	require.NotZero(t, payload.ID)
+	require.Equal(t, createdPayload.ID, payload.ID)
+	require.Equal(t, TokenTypeAccessToken, payload.Type)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.Equal(t, sessionID, payload.SessionID)
 	require.WithinDuration(t, payload.IssuedAt.Time, time.Now(), time.Second)
 	require.WithinDuration(t, payload.ExpiresAt.Time, time.Now().Add(duration), time.Second)
 }
@@ -33,12 +40,24 @@ func TestExpiredJWTToken(t *testing.T) {
 	maker, err := NewJWTMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	token, err := maker.CreateToken(util.RandomOwner(), -time.Second)
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, "", -time.Second, TokenTypeAccessToken)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err := maker.VerifyToken(token, TokenTypeAccessToken)
 	require.Error(t, err)
 	require.EqualError(t, err, "token has invalid claims: token is expired")
 	require.Nil(t, payload)
 }
+
+func TestJWTTokenTypeMismatch(t *testing.T) {
+	maker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, "", time.Minute, TokenTypeMFAChallenge)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token, TokenTypeAccessToken)
+	require.ErrorIs(t, err, ErrInvalidTokenType)
+	require.Nil(t, payload)
+}
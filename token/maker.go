@@ -3,9 +3,9 @@ package token
 import "time"
 
 type Maker interface {
-	// CreateToken creates a new token for a specific username and duration
-	CreateToken(username string, duration time.Duration) (string, error)
+	// CreateToken creates a new token for a specific username and role, session, duration and token type
+	CreateToken(username string, role string, sessionID string, duration time.Duration, tokenType TokenType) (string, *Payload, error)
 
-	// VerifyToken checks if the token is valid or not
-	VerifyToken(token string) (*Payload, error)
+	// VerifyToken checks if the token is valid and of the expected type
+	VerifyToken(token string, tokenType TokenType) (*Payload, error)
 }
@@ -1,27 +1,47 @@
 package token
 
 import (
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// TokenType tells apart tokens signed with the same key so one kind cannot be used as another
+type TokenType byte
+
+const (
+	TokenTypeAccessToken  TokenType = 1
+	TokenTypeRefreshToken TokenType = 2
+	// TokenTypeMFAChallenge proves the password was checked while the second factor is pending
+	TokenTypeMFAChallenge TokenType = 3
+)
+
+var ErrInvalidTokenType = errors.New("invalid token type")
+
 type Payload struct {
 	ID       uuid.UUID `json:"id"`
+	Type     TokenType `json:"token_type"`
 	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	// SessionID is the family of the session an access token was issued for
+	SessionID string `json:"session_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+func NewPayload(username string, role string, sessionID string, duration time.Duration, tokenType TokenType) (*Payload, error) {
 	tokenId, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
 	}
 
 	payload := &Payload{
-		ID:       tokenId,
-		Username: username,
+		ID:        tokenId,
+		Type:      tokenType,
+		Username:  username,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        tokenId.String(),
 			Subject:   username,
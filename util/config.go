@@ -0,0 +1,383 @@
+package util
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+const (
+	// DefaultConfigFile is read when it exists, other paths given with -config must exist
+	DefaultConfigFile = ".env"
+
+	// minTokenKeySize matches the minimum key size of the JWT maker
+	minTokenKeySize = 32
+)
+
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentProduction  = "production"
+)
+
+const (
+	StoreDriverPostgres = "postgres"
+	StoreDriverMemory   = "memory"
+)
+
+// Config holds every setting of the application. Each field is loaded from, in
+// increasing order of precedence, its default, the config file, the environment
+// variable named in the config tag and the matching command line flag, which is
+// the lowercase key with dashes, e.g. -http-server-addr.
+type Config struct {
+	Environment string `config:"ENVIRONMENT" default:"production" usage:"development logs to the console in color"`
+	StoreDriver string `config:"STORE_DRIVER" default:"postgres" usage:"postgres, or memory to run without a database and lose all data on exit"`
+	DBSource    string `config:"DB_SOURCE" usage:"PostgreSQL connection string"`
+	AutoMigrate bool   `config:"AUTO_MIGRATE" default:"false" usage:"apply pending migrations on startup"`
+
+	// Transactions aborted by a serialization failure or deadlock are run again
+	TxMaxRetries      int64         `config:"TX_MAX_RETRIES" default:"3" usage:"times a transaction aborted by a conflict is run again, 0 disables retries"`
+	TxRetryBaseDelay  time.Duration `config:"TX_RETRY_BASE_DELAY" default:"10ms" usage:"backoff before the first retry, doubled for every further one"`
+	TxRetryMaxDelay   time.Duration `config:"TX_RETRY_MAX_DELAY" default:"200ms"`
+	TxIsolationLevels string        `config:"TX_ISOLATION_LEVELS" usage:"isolation levels overriding the defaults, e.g. transfer=serializable,rotate_session=repeatable_read"`
+
+	// Servers enabled on the same address share one listener, see sharedHandler in main
+	HTTPServerEnabled    bool          `config:"HTTP_SERVER_ENABLED" default:"true" usage:"run the Gin REST server"`
+	HTTPServerAddress    string        `config:"HTTP_SERVER_ADDR" default:"0.0.0.0:8080" usage:"address of the Gin REST server"`
+	GRPCServerEnabled    bool          `config:"GRPC_SERVER_ENABLED" default:"true" usage:"run the gRPC server"`
+	GRPCServerAddress    string        `config:"GRPC_SERVER_ADDR" default:"0.0.0.0:9090" usage:"address of the gRPC server"`
+	GatewayServerEnabled bool          `config:"GATEWAY_SERVER_ENABLED" default:"true" usage:"run the REST gateway in front of the gRPC server"`
+	GatewayServerAddress string        `config:"GATEWAY_SERVER_ADDR" default:"0.0.0.0:8081" usage:"address of the REST gateway"`
+	ShutdownTimeout      time.Duration `config:"SHUTDOWN_TIMEOUT" default:"10s" usage:"time in-flight requests get to finish on shutdown"`
+	TrustedProxies       string        `config:"TRUSTED_PROXIES" usage:"comma separated IPs or CIDRs of reverse proxies whose X-Forwarded-For is believed, empty trusts none"`
+
+	TokenSymmetricKey          string        `config:"TOKEN_SYMMETRIC_KEY" usage:"key signing the tokens, at least 32 characters"`
+	AccessTokenDuration        time.Duration `config:"ACCESS_TOKEN_DURATION" default:"15m"`
+	RefreshTokenDuration       time.Duration `config:"REFRESH_TOKEN_DURATION" default:"24h"`
+	PasswordResetTokenDuration time.Duration `config:"PASSWORD_RESET_TOKEN_DURATION" default:"30m"`
+	PasswordResetURL           string        `config:"PASSWORD_RESET_URL" default:"http://localhost:8080/reset-password" usage:"page the password reset email links to"`
+	MFAChallengeDuration       time.Duration `config:"MFA_CHALLENGE_DURATION" default:"5m"`
+	TransferMFAThreshold       int64         `config:"TRANSFER_MFA_THRESHOLD" default:"0" usage:"transfers of this amount in minor units, e.g. cents, or more need a TOTP code, 0 disables it"`
+	RevocationCacheTTL         time.Duration `config:"REVOCATION_CACHE_TTL" default:"10s" usage:"how long a session revoked by another instance can go unnoticed"`
+	CurrencyCacheTTL           time.Duration `config:"CURRENCY_CACHE_TTL" default:"1m" usage:"how long a currency disabled by another instance can go unnoticed"`
+
+	SMTPHost           string `config:"SMTP_HOST" usage:"required in production, leave empty in development to log emails instead of sending them"`
+	SMTPPort           string `config:"SMTP_PORT" default:"587"`
+	SMTPUsername       string `config:"SMTP_USERNAME"`
+	SMTPPassword       string `config:"SMTP_PASSWORD"`
+	EmailSenderAddress string `config:"EMAIL_SENDER_ADDRESS" default:"no-reply@simplebank.local"`
+	EmailQueueSize     int64  `config:"EMAIL_QUEUE_SIZE" default:"100" usage:"emails waiting to be sent, more are dropped"`
+
+	TracingExporter string `config:"TRACING_EXPORTER" default:"none" usage:"none, otlp, stdout or file"`
+	TracingFile     string `config:"TRACING_FILE" default:"traces.jsonl" usage:"output of the file exporter"`
+
+	// UnknownKeys lists the keys of the config file that match no setting, usually typos
+	UnknownKeys []string `config:"-"`
+	// Args are the command line arguments left after the flags, such as a subcommand
+	Args []string `config:"-"`
+}
+
+// foreignConfigKeys are read by other tools from the same .env file, such as the
+// Makefile, docker and the OpenTelemetry exporters, and are not reported as unknown
+var foreignConfigKeys = map[string]bool{
+	"POSTGRES_PASSWORD":       true,
+	"POSTGRES_CONTAINER_NAME": true,
+	"DB_USER":                 true,
+	"DB_NAME":                 true,
+	"DB_PORT":                 true,
+	"GOOSE_MIGRATION_DIR":     true,
+	"GIN_MODE":                true,
+}
+
+type configField struct {
+	key   string
+	value reflect.Value
+	def   string
+	usage string
+}
+
+func (config *Config) fields() []configField {
+	value := reflect.ValueOf(config).Elem()
+	fields := make([]configField, 0, value.NumField())
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		key := field.Tag.Get("config")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		fields = append(fields, configField{
+			key:   key,
+			value: value.Field(i),
+			def:   field.Tag.Get("default"),
+			usage: field.Tag.Get("usage"),
+		})
+	}
+
+	return fields
+}
+
+func flagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+func setConfigValue(value reflect.Value, raw string) error {
+	switch value.Interface().(type) {
+	case string:
+		value.SetString(raw)
+	case time.Duration:
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(duration))
+	case bool:
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(enabled)
+	case int64:
+		number, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetInt(number)
+	default:
+		return fmt.Errorf("unsupported config type %s", value.Type())
+	}
+	return nil
+}
+
+// LoadConfig reads the configuration for the command line arguments, without the
+// program name. It only checks that values parse, what else is needed depends on
+// the command, see Validate.
+func LoadConfig(args []string) (config Config, err error) {
+	flagSet := flag.NewFlagSet("simplebank", flag.ContinueOnError)
+	configFile := flagSet.String("config", DefaultConfigFile, "file with KEY=value lines")
+
+	fields := config.fields()
+	flagValues := make(map[string]*string, len(fields))
+	for _, field := range fields {
+		usage := field.usage
+		if usage == "" {
+			usage = field.key
+		}
+		flagValues[field.key] = flagSet.String(flagName(field.key), field.def, usage)
+	}
+
+	if err = flagSet.Parse(args); err != nil {
+		return
+	}
+	config.Args = flagSet.Args()
+
+	fileValues, err := godotenv.Read(*configFile)
+	if err != nil {
+		configFileSet := false
+		flagSet.Visit(func(f *flag.Flag) {
+			configFileSet = configFileSet || f.Name == "config"
+		})
+		// Without a config file everything comes from the environment, as in containers
+		if configFileSet || !errors.Is(err, fs.ErrNotExist) {
+			err = fmt.Errorf("cannot read config file %s: %w", *configFile, err)
+			return
+		}
+		fileValues, err = map[string]string{}, nil
+	}
+
+	setFlags := map[string]bool{}
+	flagSet.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field.key] = true
+
+		raw, source := field.def, "default"
+		if value, ok := fileValues[field.key]; ok {
+			raw, source = value, *configFile
+		}
+		if value, ok := os.LookupEnv(field.key); ok {
+			raw, source = value, "environment"
+		}
+		if setFlags[flagName(field.key)] {
+			raw, source = *flagValues[field.key], "flag"
+		}
+
+		if raw == "" {
+			continue
+		}
+		if err = setConfigValue(field.value, raw); err != nil {
+			err = fmt.Errorf("invalid %s from %s: %w", field.key, source, err)
+			return
+		}
+	}
+
+	for key := range fileValues {
+		if !known[key] && !foreignConfigKeys[key] && !strings.HasPrefix(key, "OTEL_") {
+			config.UnknownKeys = append(config.UnknownKeys, key)
+		}
+	}
+	sort.Strings(config.UnknownKeys)
+
+	return
+}
+
+// ValidateDatabase checks the settings needed by commands that only use the database
+func (config Config) ValidateDatabase() error {
+	if config.DBSource == "" {
+		return errors.New("DB_SOURCE is required")
+	}
+	return nil
+}
+
+// Validate checks every setting the servers need. It reports all invalid settings
+// at once, so they can be fixed in one go.
+func (config Config) Validate() error {
+	var errs []error
+
+	if config.Environment != EnvironmentDevelopment && config.Environment != EnvironmentProduction {
+		errs = append(errs, fmt.Errorf("ENVIRONMENT must be %s or %s, got %q", EnvironmentDevelopment, EnvironmentProduction, config.Environment))
+	}
+
+	switch config.StoreDriver {
+	case StoreDriverPostgres:
+		if err := config.ValidateDatabase(); err != nil {
+			errs = append(errs, err)
+		}
+	case StoreDriverMemory:
+	default:
+		errs = append(errs, fmt.Errorf("STORE_DRIVER must be %s or %s, got %q", StoreDriverPostgres, StoreDriverMemory, config.StoreDriver))
+	}
+
+	if config.TxMaxRetries < 0 {
+		errs = append(errs, errors.New("TX_MAX_RETRIES cannot be negative"))
+	}
+	if config.TxRetryMaxDelay < config.TxRetryBaseDelay {
+		errs = append(errs, errors.New("TX_RETRY_MAX_DELAY cannot be shorter than TX_RETRY_BASE_DELAY"))
+	}
+
+	if err := validateAddress(config.HTTPServerAddress); err != nil {
+		errs = append(errs, fmt.Errorf("HTTP_SERVER_ADDR: %w", err))
+	}
+	if err := validateAddress(config.GRPCServerAddress); err != nil {
+		errs = append(errs, fmt.Errorf("GRPC_SERVER_ADDR: %w", err))
+	}
+	if err := validateAddress(config.GatewayServerAddress); err != nil {
+		errs = append(errs, fmt.Errorf("GATEWAY_SERVER_ADDR: %w", err))
+	}
+
+	if _, err := ParseTrustedProxies(config.TrustedProxies); err != nil {
+		errs = append(errs, fmt.Errorf("TRUSTED_PROXIES: %w", err))
+	}
+
+	if !config.HTTPServerEnabled && !config.GRPCServerEnabled && !config.GatewayServerEnabled {
+		errs = append(errs, errors.New("at least one of HTTP_SERVER_ENABLED, GRPC_SERVER_ENABLED and GATEWAY_SERVER_ENABLED must be true"))
+	}
+	// The gateway forwards every call to the gRPC server of the same process
+	if config.GatewayServerEnabled && !config.GRPCServerEnabled {
+		errs = append(errs, errors.New("GATEWAY_SERVER_ENABLED needs GRPC_SERVER_ENABLED"))
+	}
+
+	if len(config.TokenSymmetricKey) < minTokenKeySize {
+		errs = append(errs, fmt.Errorf("TOKEN_SYMMETRIC_KEY must be at least %d characters", minTokenKeySize))
+	}
+
+	durations := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"SHUTDOWN_TIMEOUT", config.ShutdownTimeout},
+		{"ACCESS_TOKEN_DURATION", config.AccessTokenDuration},
+		{"REFRESH_TOKEN_DURATION", config.RefreshTokenDuration},
+		{"PASSWORD_RESET_TOKEN_DURATION", config.PasswordResetTokenDuration},
+		{"MFA_CHALLENGE_DURATION", config.MFAChallengeDuration},
+		{"REVOCATION_CACHE_TTL", config.RevocationCacheTTL},
+		{"CURRENCY_CACHE_TTL", config.CurrencyCacheTTL},
+		{"TX_RETRY_BASE_DELAY", config.TxRetryBaseDelay},
+	}
+	for _, duration := range durations {
+		if duration.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %s", duration.key, duration.value))
+		}
+	}
+
+	if config.RefreshTokenDuration < config.AccessTokenDuration {
+		errs = append(errs, errors.New("REFRESH_TOKEN_DURATION cannot be shorter than ACCESS_TOKEN_DURATION"))
+	}
+
+	if config.TransferMFAThreshold < 0 {
+		errs = append(errs, errors.New("TRANSFER_MFA_THRESHOLD cannot be negative"))
+	}
+
+	if resetURL, err := url.Parse(config.PasswordResetURL); err != nil || !resetURL.IsAbs() {
+		errs = append(errs, fmt.Errorf("PASSWORD_RESET_URL must be an absolute URL, got %q", config.PasswordResetURL))
+	}
+
+	if config.SMTPHost != "" {
+		if _, err := strconv.ParseUint(config.SMTPPort, 10, 16); err != nil {
+			errs = append(errs, fmt.Errorf("SMTP_PORT must be a port number, got %q", config.SMTPPort))
+		}
+	} else if config.Environment == EnvironmentProduction {
+		// Without SMTP emails are only logged, and users never get their reset links
+		errs = append(errs, errors.New("SMTP_HOST is required in production"))
+	}
+	if config.EmailQueueSize <= 0 {
+		errs = append(errs, errors.New("EMAIL_QUEUE_SIZE must be positive"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateAddress(address string) error {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// ParseTrustedProxies reads a comma separated list of IPs and CIDRs, a single IP
+// becoming a prefix of its full length. An empty list trusts no proxy.
+func ParseTrustedProxies(value string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, err
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return proxies, nil
+}
@@ -0,0 +1,214 @@
+package util
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "app.env")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// loadValidConfig loads the configuration the way the servers do
+func loadValidConfig(args []string) (Config, error) {
+	config, err := LoadConfig(args)
+	if err != nil {
+		return config, err
+	}
+	return config, config.Validate()
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := writeConfigFile(t, `
+DB_SOURCE=postgresql://file
+TOKEN_SYMMETRIC_KEY=12345678901234567890123456789012
+SMTP_HOST=smtp.example.com
+ACCESS_TOKEN_DURATION=10m
+REFRESH_TOKEN_DURATION=1h
+HTTP_SERVER_ADDR=0.0.0.0:8000
+ENVIROMENT=development
+DB_USER=postgres
+`)
+	t.Setenv("ACCESS_TOKEN_DURATION", "20m")
+	t.Setenv("HTTP_SERVER_ADDR", "0.0.0.0:8001")
+
+	config, err := loadValidConfig([]string{"-config", path, "-http-server-addr", "127.0.0.1:8002", "migrate", "up"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"migrate", "up"}, config.Args)
+
+	// Defaults
+	require.Equal(t, EnvironmentProduction, config.Environment)
+	require.Equal(t, "0.0.0.0:9090", config.GRPCServerAddress)
+	require.Equal(t, 30*time.Minute, config.PasswordResetTokenDuration)
+	require.Zero(t, config.TransferMFAThreshold)
+	require.True(t, config.GatewayServerEnabled)
+
+	// File, environment and flag, each overriding the one before
+	require.Equal(t, "postgresql://file", config.DBSource)
+	require.Equal(t, time.Hour, config.RefreshTokenDuration)
+	require.Equal(t, 20*time.Minute, config.AccessTokenDuration)
+	require.Equal(t, "127.0.0.1:8002", config.HTTPServerAddress)
+
+	// The misspelled key is reported, keys read by other tools are not
+	require.Equal(t, []string{"ENVIROMENT"}, config.UnknownKeys)
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	t.Setenv("DB_SOURCE", "postgresql://env")
+	t.Setenv("TOKEN_SYMMETRIC_KEY", "12345678901234567890123456789012")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+
+	// The default file is optional
+	t.Chdir(t.TempDir())
+	_, err := loadValidConfig(nil)
+	require.NoError(t, err)
+
+	// A file given explicitly is not
+	_, err = LoadConfig([]string{"-config", "missing.env"})
+	require.ErrorContains(t, err, "cannot read config file missing.env")
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{
+			name:   "UnparsableDuration",
+			args:   []string{"-access-token-duration", "15"},
+			errMsg: "invalid ACCESS_TOKEN_DURATION from flag",
+		},
+		{
+			name:   "NegativeDuration",
+			args:   []string{"-mfa-challenge-duration", "-5m"},
+			errMsg: "MFA_CHALLENGE_DURATION must be positive",
+		},
+		{
+			name:   "RefreshShorterThanAccess",
+			args:   []string{"-refresh-token-duration", "1m"},
+			errMsg: "REFRESH_TOKEN_DURATION cannot be shorter than ACCESS_TOKEN_DURATION",
+		},
+		{
+			name:   "ShortKey",
+			args:   []string{"-token-symmetric-key", "secret"},
+			errMsg: "TOKEN_SYMMETRIC_KEY must be at least 32 characters",
+		},
+		{
+			name:   "AddressWithoutPort",
+			args:   []string{"-grpc-server-addr", "localhost"},
+			errMsg: "GRPC_SERVER_ADDR",
+		},
+		{
+			name:   "UnparsableBool",
+			args:   []string{"-grpc-server-enabled", "maybe"},
+			errMsg: "invalid GRPC_SERVER_ENABLED from flag",
+		},
+		{
+			name:   "NoServer",
+			args:   []string{"-http-server-enabled=false", "-grpc-server-enabled=false", "-gateway-server-enabled=false"},
+			errMsg: "at least one of HTTP_SERVER_ENABLED, GRPC_SERVER_ENABLED and GATEWAY_SERVER_ENABLED must be true",
+		},
+		{
+			name:   "GatewayWithoutGRPC",
+			args:   []string{"-grpc-server-enabled=false"},
+			errMsg: "GATEWAY_SERVER_ENABLED needs GRPC_SERVER_ENABLED",
+		},
+		{
+			name:   "RelativeResetURL",
+			args:   []string{"-password-reset-url", "/reset-password"},
+			errMsg: "PASSWORD_RESET_URL must be an absolute URL",
+		},
+		{
+			name:   "NegativeTxRetries",
+			args:   []string{"-tx-max-retries", "-1"},
+			errMsg: "TX_MAX_RETRIES cannot be negative",
+		},
+		{
+			name:   "TxRetryMaxBelowBase",
+			args:   []string{"-tx-retry-base-delay", "1s", "-tx-retry-max-delay", "100ms"},
+			errMsg: "TX_RETRY_MAX_DELAY cannot be shorter than TX_RETRY_BASE_DELAY",
+		},
+		{
+			name:   "InvalidTrustedProxy",
+			args:   []string{"-trusted-proxies", "10.0.0.0/8, proxy.local"},
+			errMsg: "TRUSTED_PROXIES",
+		},
+		{
+			name:   "EmptyEmailQueue",
+			args:   []string{"-email-queue-size", "0"},
+			errMsg: "EMAIL_QUEUE_SIZE must be positive",
+		},
+		{
+			name:   "UnknownStoreDriver",
+			args:   []string{"-store-driver", "sqlite"},
+			errMsg: `STORE_DRIVER must be postgres or memory, got "sqlite"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("DB_SOURCE", "postgresql://env")
+			t.Setenv("TOKEN_SYMMETRIC_KEY", "12345678901234567890123456789012")
+			t.Setenv("SMTP_HOST", "smtp.example.com")
+			t.Chdir(t.TempDir())
+
+			_, err := loadValidConfig(tc.args)
+			require.ErrorContains(t, err, tc.errMsg)
+		})
+	}
+}
+
+func TestLoadConfigMissingRequired(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	config, err := LoadConfig(nil)
+	require.NoError(t, err)
+
+	err = config.Validate()
+	require.ErrorContains(t, err, "DB_SOURCE is required")
+	require.ErrorContains(t, err, "TOKEN_SYMMETRIC_KEY must be at least 32 characters")
+	require.ErrorContains(t, err, "SMTP_HOST is required in production")
+
+	// Development logs emails instead
+	config.Environment = EnvironmentDevelopment
+	require.NotContains(t, config.Validate().Error(), "SMTP_HOST")
+
+	// Database commands need no token key
+	require.ErrorContains(t, config.ValidateDatabase(), "DB_SOURCE is required")
+	config.DBSource = "postgresql://env"
+	require.NoError(t, config.ValidateDatabase())
+}
+
+func TestLoadConfigMemoryStore(t *testing.T) {
+	t.Setenv("TOKEN_SYMMETRIC_KEY", "12345678901234567890123456789012")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Chdir(t.TempDir())
+
+	// The memory store needs no database
+	config, err := loadValidConfig([]string{"-store-driver", "memory"})
+	require.NoError(t, err)
+	require.Equal(t, StoreDriverMemory, config.StoreDriver)
+	require.Empty(t, config.DBSource)
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies("")
+	require.NoError(t, err)
+	require.Empty(t, proxies)
+
+	proxies, err = ParseTrustedProxies("10.1.2.3/8, 192.0.2.1,::1")
+	require.NoError(t, err)
+	require.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.1/32"),
+		netip.MustParsePrefix("::1/128"),
+	}, proxies)
+}
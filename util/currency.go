@@ -1,15 +1,9 @@
 package util
 
+// The currencies the first migration enables. The currencies table is the
+// source of truth, these are handy for tests and defaults.
 const (
 	USD = "USD"
 	INR = "INR"
 	EUR = "EUR"
 )
-
-func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case USD, EUR, INR:
-		return true
-	}
-	return false
-}
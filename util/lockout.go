@@ -0,0 +1,35 @@
+package util
+
+import "time"
+
+const (
+	// MaxFailedLoginsPerUser is the amount of consecutive failures before an account is locked
+	MaxFailedLoginsPerUser = 5
+	// MaxFailedLoginsPerIP is the amount of failures a client IP may have within FailedLoginWindow
+	MaxFailedLoginsPerIP = 20
+	FailedLoginWindow    = 15 * time.Minute
+
+	baseLockoutDuration = time.Minute
+	maxLockoutDuration  = 24 * time.Hour
+)
+
+// LockoutDuration returns how long an account stays locked after the given amount of
+// consecutive failed logins. The lock doubles with every failure past the limit.
+func LockoutDuration(failedAttempts int32) time.Duration {
+	if failedAttempts < MaxFailedLoginsPerUser {
+		return 0
+	}
+
+	exponent := failedAttempts - MaxFailedLoginsPerUser
+	if exponent >= 20 {
+		return maxLockoutDuration
+	}
+
+	return min(baseLockoutDuration<<exponent, maxLockoutDuration)
+}
+
+// LockedUntil returns when an account is unlocked again, given its consecutive failed
+// logins and the time of the last one
+func LockedUntil(failedAttempts int32, lastFailure time.Time) time.Time {
+	return lastFailure.Add(LockoutDuration(failedAttempts))
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	testCases := []struct {
+		failedAttempts int32
+		duration       time.Duration
+	}{
+		{failedAttempts: 0, duration: 0},
+		{failedAttempts: MaxFailedLoginsPerUser - 1, duration: 0},
+		{failedAttempts: MaxFailedLoginsPerUser, duration: time.Minute},
+		{failedAttempts: MaxFailedLoginsPerUser + 1, duration: 2 * time.Minute},
+		{failedAttempts: MaxFailedLoginsPerUser + 3, duration: 8 * time.Minute},
+		{failedAttempts: MaxFailedLoginsPerUser + 15, duration: maxLockoutDuration},
+		{failedAttempts: 1000, duration: maxLockoutDuration},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.duration, LockoutDuration(tc.failedAttempts), "failed attempts: %d", tc.failedAttempts)
+	}
+}
+
+func TestLockedUntil(t *testing.T) {
+	lastFailure := time.Now()
+
+	require.Equal(t, lastFailure, LockedUntil(MaxFailedLoginsPerUser-1, lastFailure))
+	require.Equal(t, lastFailure.Add(time.Minute), LockedUntil(MaxFailedLoginsPerUser, lastFailure))
+	require.Equal(t, lastFailure.Add(2*time.Minute), LockedUntil(MaxFailedLoginsPerUser+1, lastFailure))
+}
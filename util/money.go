@@ -0,0 +1,173 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"strings"
+	"sync"
+)
+
+// currencyExponents holds the ISO 4217 exponent of every known currency, the number
+// of decimals between the major and the minor unit. It starts with the currencies of
+// the first migration and is replaced by the currency registry whenever it loads them.
+var (
+	currencyExponentsMu sync.RWMutex
+	currencyExponents   = map[string]int{
+		USD: 2,
+		EUR: 2,
+		INR: 2,
+	}
+)
+
+// SetCurrencyExponents replaces the exponents Money parses and formats amounts with
+func SetCurrencyExponents(exponents map[string]int) {
+	currencyExponentsMu.Lock()
+	defer currencyExponentsMu.Unlock()
+
+	currencyExponents = maps.Clone(exponents)
+}
+
+var (
+	ErrInvalidAmount    = errors.New("amount must be a decimal number like 12.34")
+	ErrAmountPrecision  = errors.New("amount has more decimals than its currency allows")
+	ErrAmountOutOfRange = errors.New("amount is out of range")
+)
+
+// CurrencyExponent returns the number of decimals of the minor unit of a currency,
+// e.g. 2 for USD, whose minor unit is the cent
+func CurrencyExponent(currency string) (int, error) {
+	currencyExponentsMu.RLock()
+	defer currencyExponentsMu.RUnlock()
+
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return 0, fmt.Errorf("unsupported currency %q", currency)
+	}
+	return exponent, nil
+}
+
+// Money is an amount in the minor unit of its currency, so 1234 USD is $12.34.
+// Amounts are stored and computed in minor units and only turned into decimals
+// at the edges of the API.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney returns amount minor units of currency
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney reads a decimal amount in the major unit of currency, e.g. "12.34" USD.
+// The amount may have a leading minus and at most as many decimals as the currency.
+func ParseMoney(value string, currency string) (Money, error) {
+	exponent, err := CurrencyExponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	digits, negative := strings.CutPrefix(value, "-")
+	whole, fraction, hasPoint := strings.Cut(digits, ".")
+	if whole == "" || (hasPoint && fraction == "") || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, ErrInvalidAmount
+	}
+
+	// Trailing zeros add no precision, 12.340 USD is still 1234 cents
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > exponent {
+		return Money{}, ErrAmountPrecision
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	var amount int64
+	for _, digit := range whole + fraction {
+		d := int64(digit - '0')
+		if amount > (math.MaxInt64-d)/10 {
+			return Money{}, ErrAmountOutOfRange
+		}
+		amount = amount*10 + d
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decimal formats the amount in the major unit with exactly the decimals of
+// the currency, e.g. "12.34" or "-0.05"
+func (m Money) Decimal() (string, error) {
+	exponent, err := CurrencyExponent(m.Currency)
+	if err != nil {
+		return "", err
+	}
+
+	// Formatted from the absolute value in a uint64, so math.MinInt64 has one too
+	abs := uint64(m.Amount)
+	sign := ""
+	if m.Amount < 0 {
+		abs = -abs
+		sign = "-"
+	}
+
+	digits := fmt.Sprintf("%0*d", exponent+1, abs)
+	if exponent == 0 {
+		return sign + digits, nil
+	}
+	point := len(digits) - exponent
+	return sign + digits[:point] + "." + digits[point:], nil
+}
+
+// String formats the amount with its currency, e.g. "12.34 USD"
+func (m Money) String() string {
+	decimal, err := m.Decimal()
+	if err != nil {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+	return decimal + " " + m.Currency
+}
+
+type moneyJSON struct {
+	Value    string `json:"value"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON writes the amount as a decimal string next to its currency,
+// e.g. {"value":"12.34","currency":"USD"}, so clients never guess the unit
+func (m Money) MarshalJSON() ([]byte, error) {
+	decimal, err := m.Decimal()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(moneyJSON{Value: decimal, Currency: m.Currency})
+}
+
+// UnmarshalJSON reads the format written by MarshalJSON and rejects values
+// with more decimals than the currency has
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	money, err := ParseMoney(raw.Value, raw.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = money
+	return nil
+}
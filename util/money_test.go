@@ -0,0 +1,95 @@
+package util
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	testCases := []struct {
+		value    string
+		currency string
+		amount   int64
+		err      error
+	}{
+		{value: "12.34", currency: USD, amount: 1234},
+		{value: "12", currency: USD, amount: 1200},
+		{value: "12.3", currency: EUR, amount: 1230},
+		{value: "0.05", currency: INR, amount: 5},
+		{value: "-0.05", currency: USD, amount: -5},
+		{value: "12.340", currency: USD, amount: 1234},
+		{value: "92233720368547758.07", currency: USD, amount: math.MaxInt64},
+		{value: "12.345", currency: USD, err: ErrAmountPrecision},
+		{value: "92233720368547758.08", currency: USD, err: ErrAmountOutOfRange},
+		{value: "", currency: USD, err: ErrInvalidAmount},
+		{value: ".5", currency: USD, err: ErrInvalidAmount},
+		{value: "5.", currency: USD, err: ErrInvalidAmount},
+		{value: "1e3", currency: USD, err: ErrInvalidAmount},
+		{value: "+1", currency: USD, err: ErrInvalidAmount},
+		{value: "1,5", currency: USD, err: ErrInvalidAmount},
+		{value: " 1", currency: USD, err: ErrInvalidAmount},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			money, err := ParseMoney(tc.value, tc.currency)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, Money{Amount: tc.amount, Currency: tc.currency}, money)
+		})
+	}
+
+	_, err := ParseMoney("1", "XYZ")
+	require.ErrorContains(t, err, "unsupported currency")
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	testCases := []struct {
+		money   Money
+		decimal string
+	}{
+		{money: NewMoney(1234, USD), decimal: "12.34"},
+		{money: NewMoney(5, USD), decimal: "0.05"},
+		{money: NewMoney(0, EUR), decimal: "0.00"},
+		{money: NewMoney(-5, USD), decimal: "-0.05"},
+		{money: NewMoney(math.MinInt64, USD), decimal: "-92233720368547758.08"},
+	}
+
+	for _, tc := range testCases {
+		decimal, err := tc.money.Decimal()
+		require.NoError(t, err)
+		require.Equal(t, tc.decimal, decimal)
+
+		parsed, err := ParseMoney(decimal, tc.money.Currency)
+		if tc.money.Amount != math.MinInt64 {
+			require.NoError(t, err)
+			require.Equal(t, tc.money, parsed)
+		}
+	}
+
+	_, err := NewMoney(1, "XYZ").Decimal()
+	require.Error(t, err)
+	require.Equal(t, "12.34 USD", NewMoney(1234, USD).String())
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(1234, USD))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"value":"12.34","currency":"USD"}`, string(data))
+
+	var money Money
+	require.NoError(t, json.Unmarshal(data, &money))
+	require.Equal(t, NewMoney(1234, USD), money)
+
+	err = json.Unmarshal([]byte(`{"value":"12.345","currency":"USD"}`), &money)
+	require.ErrorIs(t, err, ErrAmountPrecision)
+
+	_, err = json.Marshal(NewMoney(1, "XYZ"))
+	require.Error(t, err)
+}
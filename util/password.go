@@ -2,6 +2,8 @@ package util
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -17,3 +19,23 @@ func HashPassword(password string) (string, error) {
 func CheckPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
+// SimulatePasswordCheck spends the same time as CheckPassword without a real hash,
+// so logins for unknown usernames cannot be told apart by how long they take.
+func SimulatePasswordCheck(password string) {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+}
+
+// IssuedBeforePasswordChange tells if a token predates the last password change.
+// Token timestamps only have second precision, so the change time is truncated the same way.
+func IssuedBeforePasswordChange(issuedAt time.Time, passwordChangedAt time.Time) bool {
+	return issuedAt.Before(passwordChangedAt.Truncate(time.Second))
+}
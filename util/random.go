@@ -32,7 +32,7 @@ func RandomMoney() int64 {
 
 // RandomCurrency generates a random currency code
 func RandomCurrency() string {
-	currencies := []string{"USD", "EUR", "CAD", "INR"}
+	currencies := []string{USD, EUR, INR}
 	n := len(currencies)
 	return currencies[rand.Intn(n)]
 }
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// RequestIDHeader is the HTTP header carrying the request ID
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDMetadataKey is the gRPC metadata key carrying the request ID
+	RequestIDMetadataKey = "x-request-id"
+
+	maxRequestIDLength = 128
+)
+
+type requestIDKey struct{}
+
+// RequestIDOrNew keeps a request ID received from a client when it is sane,
+// otherwise it generates a new one.
+func RequestIDOrNew(requestID string) string {
+	if isValidRequestID(requestID) {
+		return requestID
+	}
+	return uuid.NewString()
+}
+
+// isValidRequestID only accepts IDs that are safe to echo in headers and logs
+func isValidRequestID(requestID string) bool {
+	if len(requestID) == 0 || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range requestID {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ContextWithRequestID returns a copy of the context carrying the request ID
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID of the context, or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
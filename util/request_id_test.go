@@ -0,0 +1,26 @@
+package util
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDOrNew(t *testing.T) {
+	require.Equal(t, "abc-123", RequestIDOrNew("abc-123"))
+
+	for _, requestID := range []string{"", "has space", "new\nline", strings.Repeat("a", maxRequestIDLength+1)} {
+		generated := RequestIDOrNew(requestID)
+		require.NotEqual(t, requestID, generated)
+		require.True(t, isValidRequestID(generated))
+	}
+}
+
+func TestRequestIDContext(t *testing.T) {
+	require.Empty(t, RequestIDFromContext(context.Background()))
+
+	ctx := ContextWithRequestID(context.Background(), "abc-123")
+	require.Equal(t, "abc-123", RequestIDFromContext(ctx))
+}
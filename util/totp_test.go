@@ -0,0 +1,80 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA1 test key from RFC 6238 appendix B, base32 encoded
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTPCode(t *testing.T) {
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+	}
+
+	for _, tc := range testCases {
+		code, err := GenerateTOTPCode(rfc6238Secret, time.Unix(tc.unix, 0))
+		require.NoError(t, err)
+		require.Equal(t, tc.code, code)
+	}
+}
+
+func TestMatchTOTPCode(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := GenerateTOTPCode(secret, now)
+	require.NoError(t, err)
+
+	step, ok := MatchTOTPCode(secret, code, now)
+	require.True(t, ok)
+	require.Equal(t, now.Unix()/totpPeriod, step)
+
+	// One step of drift is allowed, the code still belongs to the step it was made in
+	driftedStep, ok := MatchTOTPCode(secret, code, now.Add(totpPeriod*time.Second))
+	require.True(t, ok)
+	require.Equal(t, step, driftedStep)
+
+	_, ok = MatchTOTPCode(secret, code, now.Add(3*totpPeriod*time.Second))
+	require.False(t, ok)
+	_, ok = MatchTOTPCode(secret, "12345", now)
+	require.False(t, ok)
+	_, ok = MatchTOTPCode("not base32!", code, now)
+	require.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("SimpleBank", "alice", rfc6238Secret)
+	require.True(t, strings.HasPrefix(uri, "otpauth://totp/SimpleBank:alice?"))
+	require.Contains(t, uri, "secret="+rfc6238Secret)
+	require.Contains(t, uri, "issuer=SimpleBank")
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		require.Len(t, code, recoveryCodeLength+1)
+		require.Len(t, NormalizeRecoveryCode(code), recoveryCodeLength)
+		for _, letter := range NormalizeRecoveryCode(code) {
+			require.Contains(t, alphabet, string(letter))
+		}
+		require.Equal(t, NormalizeRecoveryCode(code), NormalizeRecoveryCode(strings.ToUpper(code)))
+		require.NotContains(t, seen, code)
+		seen[code] = true
+	}
+}